@@ -0,0 +1,164 @@
+package bstates
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/nayarsystems/buffer/buffer"
+)
+
+// ErrAuthentication is returned when encrypted data can't be authenticated: the data has been tampered with
+// or it was encrypted with a different key.
+var ErrAuthentication = errors.New("authentication failed")
+
+// ErrKeyNotFound is returned by key providers when there is no key with the requested id.
+var ErrKeyNotFound = errors.New("key not found")
+
+// KeyProvider looks up the keys used by the [MOD_AESGCM] modifier by key id.
+//
+// Keys must be 16, 24 or 32 bytes long to select AES-128, AES-192 or AES-256.
+type KeyProvider interface {
+	GetKey(keyID string) ([]byte, error)
+}
+
+// StaticKeyProvider is a [KeyProvider] which holds the keys in a map indexed by key id.
+type StaticKeyProvider map[string][]byte
+
+// GetKey returns the key with the provided id or [ErrKeyNotFound].
+func (p StaticKeyProvider) GetKey(keyID string) ([]byte, error) {
+	key, ok := p[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: \"%s\"", ErrKeyNotFound, keyID)
+	}
+	return key, nil
+}
+
+// authenticationError is an [ErrAuthentication] error caused by another error, e.g. a key lookup failure
+// for a key id which may have been tampered with. Both errors can be matched with [errors.Is].
+type authenticationError struct {
+	err error
+}
+
+func (e *authenticationError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrAuthentication, e.err)
+}
+
+func (e *authenticationError) Is(target error) bool {
+	return target == ErrAuthentication
+}
+
+func (e *authenticationError) Unwrap() error {
+	return e.err
+}
+
+// aesGcmMaxKeyIDSize is the maximum length of a key id, which is stored in the output prefixed by its length.
+const aesGcmMaxKeyIDSize = 255
+
+// AesGcmEnc encrypts and authenticates the provided buffer with AES-GCM using the key with the provided id.
+// The output contains the key id (prefixed by its length in one byte), the random nonce and the sealed data.
+// The key id is authenticated too.
+func AesGcmEnc(b *buffer.Buffer, keyID string, provider KeyProvider) (*buffer.Buffer, error) {
+	if len(keyID) == 0 || len(keyID) > aesGcmMaxKeyIDSize {
+		return nil, fmt.Errorf("key id size must be between 1 and %d bytes", aesGcmMaxKeyIDSize)
+	}
+	key, err := getAesGcmKey(keyID, provider)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAesGcm(keyID, key)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, 0, 1+len(keyID)+aead.NonceSize())
+	header = append(header, byte(len(keyID)))
+	header = append(header, keyID...)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	out := aead.Seal(append(header, nonce...), nonce, b.GetRawBuffer()[:b.GetByteSize()], header)
+	outb := &buffer.Buffer{}
+	outb.InitFromRawBuffer(out)
+	return outb, nil
+}
+
+// AesGcmDec reverts [AesGcmEnc], looking up the key by the id stored in the data. It returns
+// [ErrAuthentication] if the data has been tampered with. Since the key id is authenticated, errors looking up
+// the key (e.g. [ErrKeyNotFound]) are returned as [ErrAuthentication] too, wrapping the lookup error.
+func AesGcmDec(b *buffer.Buffer, provider KeyProvider) (*buffer.Buffer, error) {
+	in := b.GetRawBuffer()[:b.GetByteSize()]
+	if len(in) < 1 || len(in) < 1+int(in[0]) {
+		return nil, fmt.Errorf("%w: truncated data", ErrAuthentication)
+	}
+	header := in[:1+int(in[0])]
+	keyID := string(header[1:])
+	key, err := getAesGcmKey(keyID, provider)
+	if err != nil {
+		if provider != nil {
+			err = &authenticationError{err: err}
+		}
+		return nil, err
+	}
+	aead, err := newAesGcm(keyID, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(in) < len(header)+aead.NonceSize()+aead.Overhead() {
+		return nil, fmt.Errorf("%w: truncated data", ErrAuthentication)
+	}
+	nonce := in[len(header) : len(header)+aead.NonceSize()]
+	out, err := aead.Open(nil, nonce, in[len(header)+aead.NonceSize():], header)
+	if err != nil {
+		return nil, ErrAuthentication
+	}
+	outb := &buffer.Buffer{}
+	outb.InitFromRawBuffer(out)
+	return outb, nil
+}
+
+func getAesGcmKey(keyID string, provider KeyProvider) ([]byte, error) {
+	if provider == nil {
+		return nil, errors.New("no key provider set (see StateSchema.SetKeyProvider)")
+	}
+	return provider.GetKey(keyID)
+}
+
+func newAesGcm(keyID string, key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("key \"%s\": %w", keyID, err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// aesGcmModifier is the [MOD_AESGCM] modifier. The key parameter is the id of the key used to encrypt.
+type aesGcmModifier struct{}
+
+func (aesGcmModifier) VerifiesIntegrity() bool {
+	return true
+}
+
+func (aesGcmModifier) ValidateParams(step PipelineStep) error {
+	if err := checkParams(step, "key"); err != nil {
+		return err
+	}
+	if _, ok := step.Params["key"]; !ok {
+		return fmt.Errorf("modifier \"%s\": key parameter is required", step.Modifier)
+	}
+	if len(step.Params["key"]) > aesGcmMaxKeyIDSize {
+		return fmt.Errorf("modifier \"%s\": key id is longer than %d bytes", step.Modifier, aesGcmMaxKeyIDSize)
+	}
+	return nil
+}
+
+func (aesGcmModifier) Encode(b *buffer.Buffer, schema *StateSchema, step PipelineStep) (*buffer.Buffer, error) {
+	return AesGcmEnc(b, step.Params["key"], schema.GetKeyProvider())
+}
+
+func (aesGcmModifier) Decode(b *buffer.Buffer, schema *StateSchema, step PipelineStep) (*buffer.Buffer, error) {
+	return AesGcmDec(b, schema.GetKeyProvider())
+}
@@ -0,0 +1,147 @@
+package bstates
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AesGcm(t *testing.T) {
+	keys := StaticKeyProvider{
+		"k1": bytes.Repeat([]byte{1}, 32),
+		"k2": bytes.Repeat([]byte{2}, 16),
+	}
+	for _, pipeline := range []string{"aesgcm(key=k1)", "t:zstd:aesgcm(key=k2)", "aesgcm(key=k1):z"} {
+		schema := testPipelineComparativeCreateSchema(t, pipeline)
+		schema.SetKeyProvider(keys)
+		states := testCreateCounterStates(t, schema, 100)
+		q := CreateStateQueue(schema)
+		require.NoError(t, q.PushAll(states))
+		blob, err := q.Encode()
+		require.NoError(t, err)
+
+		// Random nonce
+		blob2, err := q.Encode()
+		require.NoError(t, err)
+		require.NotEqual(t, blob, blob2)
+
+		dq := CreateStateQueue(schema)
+		require.NoError(t, dq.Decode(blob))
+		decoded, err := dq.GetStates()
+		require.NoError(t, err)
+		testEqualStates(t, states, decoded)
+
+		r, err := CreateQueueReader(schema, bytes.NewReader(blob))
+		require.NoError(t, err)
+		streamed := []*State{}
+		require.NoError(t, r.ForEach(func(state *State) error {
+			streamed = append(streamed, state)
+			return nil
+		}))
+		testEqualStates(t, states, streamed)
+	}
+}
+
+func Test_AesGcm_Tampered(t *testing.T) {
+	keys := StaticKeyProvider{"k1": bytes.Repeat([]byte{1}, 32)}
+	schema := testPipelineComparativeCreateSchema(t, "aesgcm(key=k1)")
+	schema.SetKeyProvider(keys)
+	q := CreateStateQueue(schema)
+	require.NoError(t, q.PushAll(testCreateCounterStates(t, schema, 10)))
+	blob, err := q.Encode()
+	require.NoError(t, err)
+
+	// Key id, nonce, data and tag are authenticated
+	for _, i := range []int{1, 3, 10, len(blob) - 1} {
+		tampered := append([]byte{}, blob...)
+		tampered[i] ^= 0x01
+		err = CreateStateQueue(schema).Decode(tampered)
+		require.ErrorIs(t, err, ErrAuthentication)
+		if i == 1 {
+			// Key id changed from "k1" to "j1"
+			require.ErrorIs(t, err, ErrKeyNotFound)
+		}
+	}
+	err = CreateStateQueue(schema).Decode(blob[:10])
+	require.ErrorIs(t, err, ErrAuthentication)
+
+	r, err := CreateQueueReader(schema, bytes.NewReader(append(append([]byte{}, blob[:len(blob)-1]...), ^blob[len(blob)-1])))
+	require.NoError(t, err)
+	_, err = r.Next()
+	require.ErrorIs(t, err, ErrAuthentication)
+
+	// Wrong key
+	other := testPipelineComparativeCreateSchema(t, "aesgcm(key=k1)")
+	other.SetKeyProvider(StaticKeyProvider{"k1": bytes.Repeat([]byte{2}, 32)})
+	err = CreateStateQueue(other).Decode(blob)
+	require.ErrorIs(t, err, ErrAuthentication)
+}
+
+func Test_AesGcm_Errors(t *testing.T) {
+	schema := testPipelineComparativeCreateSchema(t, "aesgcm(key=k1)")
+	q := CreateStateQueue(schema)
+	require.NoError(t, q.PushAll(testCreateCounterStates(t, schema, 10)))
+
+	// No key provider
+	_, err := q.Encode()
+	require.ErrorContains(t, err, "no key provider")
+
+	// Missing or invalid key
+	for _, keys := range []StaticKeyProvider{{}, {"k1": {1, 2, 3}}} {
+		schema := testPipelineComparativeCreateSchema(t, "aesgcm(key=k1)")
+		schema.SetKeyProvider(keys)
+		q := CreateStateQueue(schema)
+		require.NoError(t, q.PushAll(testCreateCounterStates(t, schema, 10)))
+		_, err = q.Encode()
+		require.Error(t, err)
+		if len(keys) == 0 {
+			require.ErrorIs(t, err, ErrKeyNotFound)
+		}
+	}
+
+	// Streaming encoder
+	_, err = CreateQueueWriter(schema, &bytes.Buffer{})
+	require.ErrorIs(t, err, ErrStreamingNotSupported)
+
+	// Pipeline
+	for _, pipeline := range []string{"aesgcm", "aesgcm(level=1)", "aesgcm(key=" + string(bytes.Repeat([]byte{'k'}, 256)) + ")"} {
+		_, err = ParsePipeline(pipeline)
+		require.Error(t, err, pipeline)
+	}
+}
+
+func Test_AesGcm_SetKeyProviderConcurrent(t *testing.T) {
+	keys := StaticKeyProvider{"k1": bytes.Repeat([]byte{1}, 32)}
+	schema := testPipelineComparativeCreateSchema(t, "aesgcm(key=k1)")
+	schema.SetKeyProvider(keys)
+	q := CreateStateQueue(schema)
+	require.NoError(t, q.PushAll(testCreateCounterStates(t, schema, 10)))
+	blob, err := q.Encode()
+	require.NoError(t, err)
+
+	// The key provider can be replaced while the schema is used by other goroutines (run with -race)
+	wg := sync.WaitGroup{}
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if err := CreateStateQueue(schema).Decode(blob); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	for j := 0; j < 50; j++ {
+		schema.SetKeyProvider(StaticKeyProvider{"k1": bytes.Repeat([]byte{1}, 32)})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+}
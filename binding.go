@@ -0,0 +1,357 @@
+package bstates
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jaracil/ei"
+)
+
+// StructTagName is the struct tag used to bind Go struct fields to [State] fields.
+//
+//	type Status struct {
+//		Temp    float64   `bstates:"TEMP"`            // T_FIXED field
+//		Message string    `bstates:"MESSAGE_BUFFER"`  // T_BUFFER field read as string
+//		State   string    `bstates:"STATE,readonly"`  // IntMap decoded field, skipped by State.Marshal
+//		Flags   []string  `bstates:"FLAGS"`           // Flags decoded field
+//		Time    time.Time `bstates:"TIMESTAMP_MS"`    // NumberToUnixTsMs decoded field
+//		Ignored int       `bstates:"-"`
+//	}
+const StructTagName = "bstates"
+
+// FieldBindingError describes an error binding a single field.
+type FieldBindingError struct {
+	Field       string // Name of the state field
+	StructField string // Name of the Go struct field
+	Err         error  // Cause (usually wraps [ErrInvalidType] or [ErrOutOfRange])
+}
+
+func (e *FieldBindingError) Error() string {
+	return fmt.Sprintf("field \"%s\" (%s): %v", e.Field, e.StructField, e.Err)
+}
+
+func (e *FieldBindingError) Unwrap() error {
+	return e.Err
+}
+
+// BindingError is returned by [State.Unmarshal] and [State.Marshal] when one or more fields can't be bound.
+// Every other field is processed anyway.
+type BindingError struct {
+	Errors []*FieldBindingError
+}
+
+func (e *BindingError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		msgs = append(msgs, fe.Error())
+	}
+	return "binding error: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the per field errors.
+func (e *BindingError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		errs = append(errs, fe)
+	}
+	return errs
+}
+
+// structTag holds the parsed content of a "bstates" struct tag. The tag can be either a field name followed
+// by options ("NAME,readonly") or a list of key=value pairs ("name=NAME,type=uint,size=4").
+type structTag struct {
+	name    string
+	options map[string]string
+}
+
+func parseStructTag(tag string) structTag {
+	st := structTag{options: map[string]string{}}
+	for i, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if k, v, ok := strings.Cut(part, "="); ok {
+			st.options[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		} else if i == 0 {
+			st.name = part
+		} else {
+			st.options[part] = ""
+		}
+	}
+	if name, ok := st.options["name"]; ok {
+		st.name = name
+	}
+	return st
+}
+
+func (t structTag) has(option string) bool {
+	_, ok := t.options[option]
+	return ok
+}
+
+// boundField is a struct field tagged with a state field name.
+type boundField struct {
+	tag   structTag
+	field reflect.StructField
+	value reflect.Value
+}
+
+// getBoundFields returns the tagged fields of the struct, including the ones of embedded structs.
+func getBoundFields(v reflect.Value) []boundField {
+	fields := []boundField{}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tagStr, tagged := sf.Tag.Lookup(StructTagName)
+		if tagStr == "-" {
+			continue
+		}
+		if !tagged {
+			if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+				fields = append(fields, getBoundFields(v.Field(i))...)
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		tag := parseStructTag(tagStr)
+		if tag.name == "" {
+			tag.name = sf.Name
+		}
+		fields = append(fields, boundField{tag: tag, field: sf, value: v.Field(i)})
+	}
+	return fields
+}
+
+func getStructValue(v any, method string) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	} else if method == "Unmarshal" {
+		return reflect.Value{}, fmt.Errorf("%s: expected a non nil pointer to struct, got %T", method, v)
+	}
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("%s: expected a struct, got %T", method, v)
+	}
+	return rv, nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Unmarshal copies the values of the state into the struct pointed to by v. Struct fields are bound to
+// state fields (regular or decoded) using the "bstates" struct tag (see [StructTagName]).
+//
+// Supported Go types are bool, signed and unsigned integers, floats (T_FIXED and T_UFIXED values are read as float64),
+// string and []byte (T_BUFFER fields and string decoders), []string (Flags decoder), time.Time (NumberToUnixTsMs decoder),
+// [GeoPoint] (GeoPoint decoder) and any. Fields which can't be bound are reported in a [BindingError].
+func (e *State) Unmarshal(v any) error {
+	rv, err := getStructValue(v, "Unmarshal")
+	if err != nil {
+		return err
+	}
+	bindErr := &BindingError{}
+	for _, bf := range getBoundFields(rv) {
+		value, err := e.Get(bf.tag.name)
+		if err == nil {
+			err = setGoValue(bf.value, value)
+		}
+		if err != nil {
+			bindErr.Errors = append(bindErr.Errors, &FieldBindingError{Field: bf.tag.name, StructField: bf.field.Name, Err: err})
+		}
+	}
+	if len(bindErr.Errors) > 0 {
+		return bindErr
+	}
+	return nil
+}
+
+// Marshal sets the state fields from the values of the struct v (or pointer to struct). See [State.Unmarshal]
+// for the binding rules. Struct fields tagged with the "readonly" option are skipped.
+//
+// Values are validated by [State.Set]. Fields which can't be bound are reported in a [BindingError].
+func (e *State) Marshal(v any) error {
+	rv, err := getStructValue(v, "Marshal")
+	if err != nil {
+		return err
+	}
+	bindErr := &BindingError{}
+	for _, bf := range getBoundFields(rv) {
+		if bf.tag.has("readonly") {
+			continue
+		}
+		value := bf.value.Interface()
+		if t, ok := value.(time.Time); ok {
+			value = uint64(t.UnixMilli())
+		}
+		if err := e.Set(bf.tag.name, value); err != nil {
+			bindErr.Errors = append(bindErr.Errors, &FieldBindingError{Field: bf.tag.name, StructField: bf.field.Name, Err: err})
+		}
+	}
+	if len(bindErr.Errors) > 0 {
+		return bindErr
+	}
+	return nil
+}
+
+// setGoValue stores the state value v into the Go value dst converting it to the type of dst.
+func setGoValue(dst reflect.Value, v any) error {
+	if dst.Type() == timeType {
+		ms, err := ei.N(v).Int64()
+		if err != nil {
+			return fmt.Errorf("%w: can't convert %T to time.Time", ErrInvalidType, v)
+		}
+		dst.Set(reflect.ValueOf(time.UnixMilli(ms).UTC()))
+		return nil
+	}
+	switch dst.Kind() {
+	case reflect.Interface:
+		if v == nil {
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		rv := reflect.ValueOf(v)
+		if !rv.Type().AssignableTo(dst.Type()) {
+			return fmt.Errorf("%w: can't assign %T to %s", ErrInvalidType, v, dst.Type())
+		}
+		dst.Set(rv)
+	case reflect.Bool:
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("%w: can't convert %T to bool", ErrInvalidType, v)
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := toInt64(v)
+		if err != nil {
+			return err
+		}
+		if dst.OverflowInt(i) {
+			return fmt.Errorf("%w: %d overflows %s", ErrOutOfRange, i, dst.Type())
+		}
+		dst.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := toUint64(v)
+		if err != nil {
+			return err
+		}
+		if dst.OverflowUint(u) {
+			return fmt.Errorf("%w: %d overflows %s", ErrOutOfRange, u, dst.Type())
+		}
+		dst.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		switch v.(type) {
+		case bool, string, []byte, []string, nil:
+			return fmt.Errorf("%w: can't convert %T to %s", ErrInvalidType, v, dst.Type())
+		}
+		f, err := ei.N(v).Float64()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidType, err)
+		}
+		if dst.Kind() == reflect.Float32 && math.Abs(f) > math.MaxFloat32 && !math.IsInf(f, 0) {
+			return fmt.Errorf("%w: %v overflows float32", ErrOutOfRange, f)
+		}
+		dst.SetFloat(f)
+	case reflect.String:
+		switch s := v.(type) {
+		case string:
+			dst.SetString(s)
+		case []byte:
+			dst.SetString(bufferToString(s))
+		default:
+			return fmt.Errorf("%w: can't convert %T to string", ErrInvalidType, v)
+		}
+	case reflect.Slice:
+		switch dst.Type().Elem().Kind() {
+		case reflect.Uint8:
+			b, ok := v.([]byte)
+			if !ok {
+				return fmt.Errorf("%w: can't convert %T to []byte", ErrInvalidType, v)
+			}
+			dst.SetBytes(append([]byte{}, b...))
+			return nil
+		case reflect.String:
+			ss, ok := v.([]string)
+			if !ok {
+				return fmt.Errorf("%w: can't convert %T to []string", ErrInvalidType, v)
+			}
+			out := reflect.MakeSlice(dst.Type(), len(ss), len(ss))
+			for i, s := range ss {
+				out.Index(i).SetString(s)
+			}
+			dst.Set(out)
+			return nil
+		}
+		return fmt.Errorf("%w: unsupported type %s", ErrInvalidType, dst.Type())
+	case reflect.Struct:
+		rv := reflect.ValueOf(v)
+		if v == nil || rv.Type() != dst.Type() {
+			return fmt.Errorf("%w: can't convert %T to %s", ErrInvalidType, v, dst.Type())
+		}
+		dst.Set(rv)
+	default:
+		return fmt.Errorf("%w: unsupported type %s", ErrInvalidType, dst.Type())
+	}
+	return nil
+}
+
+func toInt64(v any) (int64, error) {
+	switch n := v.(type) {
+	case int8, int16, int32, int, int64:
+		return ei.N(n).Int64()
+	case uint8, uint16, uint32, uint, uint64:
+		u := ei.N(n).Uint64Z()
+		if u > math.MaxInt64 {
+			return 0, fmt.Errorf("%w: %d overflows int64", ErrOutOfRange, u)
+		}
+		return int64(u), nil
+	case float32, float64:
+		f := ei.N(n).Float64Z()
+		if f != math.Trunc(f) {
+			return 0, fmt.Errorf("%w: %v is not an integer", ErrInvalidType, f)
+		}
+		if f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, fmt.Errorf("%w: %v overflows int64", ErrOutOfRange, f)
+		}
+		return int64(f), nil
+	}
+	return 0, fmt.Errorf("%w: can't convert %T to integer", ErrInvalidType, v)
+}
+
+func toUint64(v any) (uint64, error) {
+	switch n := v.(type) {
+	case uint8, uint16, uint32, uint, uint64:
+		return ei.N(n).Uint64()
+	case int8, int16, int32, int, int64:
+		i := ei.N(n).Int64Z()
+		if i < 0 {
+			return 0, fmt.Errorf("%w: negative value %d", ErrOutOfRange, i)
+		}
+		return uint64(i), nil
+	case float32, float64:
+		f := ei.N(n).Float64Z()
+		if f != math.Trunc(f) {
+			return 0, fmt.Errorf("%w: %v is not an integer", ErrInvalidType, f)
+		}
+		if f < 0 || f >= math.MaxUint64 {
+			return 0, fmt.Errorf("%w: %v overflows uint64", ErrOutOfRange, f)
+		}
+		return uint64(f), nil
+	}
+	return 0, fmt.Errorf("%w: can't convert %T to unsigned integer", ErrInvalidType, v)
+}
+
+// bufferToString returns the content of the buffer up to the first null character.
+func bufferToString(b []byte) string {
+	i := 0
+	for ; i < len(b); i++ {
+		if b[i] == 0 {
+			break
+		}
+	}
+	return string(b[:i])
+}
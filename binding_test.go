@@ -0,0 +1,244 @@
+package bstates
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createBindingTestSchema(t *testing.T) *StateSchema {
+	schema, err := CreateStateSchema(&StateSchemaParams{
+		Fields: []StateField{
+			{Name: "INT", Type: T_INT, Size: 12},
+			{Name: "UINT", Type: T_UINT, Size: 12},
+			{Name: "FLOAT32", Type: T_FLOAT32},
+			{Name: "FLOAT64", Type: T_FLOAT64},
+			{Name: "BOOL", Type: T_BOOL},
+			{Name: "BUFFER", Type: T_BUFFER, Size: 64},
+			{Name: "FIXED", Type: T_FIXED, Size: 16, Decimals: 1},
+			{Name: "UFIXED", Type: T_UFIXED, Size: 16, Decimals: 2},
+			{Name: "FLAGS_RAW", Type: T_UINT, Size: 4},
+			{Name: "STATE_CODE", Type: T_UINT, Size: 2},
+			{Name: "TS_RAW", Type: T_UINT, Size: 32},
+		},
+		DecodedFields: []DecodedStateField{
+			{
+				Name:    "FLAGS",
+				Decoder: &FlagsDecoder{From: "FLAGS_RAW", Flags: map[string]uint8{"A": 0, "B": 1}},
+			},
+			{
+				Name:    "STATE",
+				Decoder: &IntMapDecoder{From: "STATE_CODE", MapId: "STATE_MAP"},
+			},
+			{
+				Name:    "MESSAGE",
+				Decoder: &BufferToStringDecoder{From: "BUFFER"},
+			},
+			{
+				Name:    "TS",
+				Decoder: &NumberToUnixTsMsDecoder{From: "TS_RAW", Year: 2020, Factor: 1000},
+			},
+		},
+		DecoderIntMaps: map[string]map[int64]any{
+			"STATE_MAP": {0: "IDLE", 1: "RUNNING"},
+		},
+	})
+	require.NoError(t, err)
+	return schema
+}
+
+type bindingTestBase struct {
+	Int  int   `bstates:"INT"`
+	Uint uint8 `bstates:"UINT,readonly"`
+}
+
+type bindingTestStruct struct {
+	bindingTestBase
+	Float32 float32   `bstates:"FLOAT32"`
+	Float64 float64   `bstates:"FLOAT64"`
+	Bool    bool      `bstates:"BOOL"`
+	Buffer  []byte    `bstates:"BUFFER"`
+	Fixed   float64   `bstates:"FIXED"`
+	Ufixed  float64   `bstates:"UFIXED"`
+	Flags   []string  `bstates:"FLAGS"`
+	State   string    `bstates:"STATE,readonly"`
+	Message string    `bstates:"MESSAGE,readonly"`
+	Ts      time.Time `bstates:"TS"`
+	Raw     any       `bstates:"STATE_CODE"`
+	Ignored int       `bstates:"-"`
+	NoTag   int
+}
+
+func Test_State_Unmarshal(t *testing.T) {
+	schema := createBindingTestSchema(t)
+	state, err := schema.CreateState()
+	require.NoError(t, err)
+
+	ts := time.Date(2021, 5, 4, 3, 2, 1, 0, time.UTC)
+	require.NoError(t, state.Set("INT", -100))
+	require.NoError(t, state.Set("UINT", 200))
+	require.NoError(t, state.Set("FLOAT32", 1.5))
+	require.NoError(t, state.Set("FLOAT64", 2.25))
+	require.NoError(t, state.Set("BOOL", true))
+	require.NoError(t, state.Set("BUFFER", "hello"))
+	require.NoError(t, state.Set("FIXED", -12.3))
+	require.NoError(t, state.Set("UFIXED", 1.23))
+	require.NoError(t, state.Set("FLAGS", []string{"B"}))
+	require.NoError(t, state.Set("STATE_CODE", 1))
+	require.NoError(t, state.Set("TS", uint64(ts.UnixMilli())))
+
+	var out bindingTestStruct
+	out.Ignored = 5
+	err = state.Unmarshal(&out)
+	require.NoError(t, err)
+
+	require.Equal(t, -100, out.Int)
+	require.Equal(t, uint8(200), out.Uint)
+	require.Equal(t, float32(1.5), out.Float32)
+	require.Equal(t, 2.25, out.Float64)
+	require.True(t, out.Bool)
+	require.Equal(t, []byte{'h', 'e', 'l', 'l', 'o', 0, 0, 0}, out.Buffer)
+	require.Equal(t, -12.3, out.Fixed)
+	require.Equal(t, 1.23, out.Ufixed)
+	require.Equal(t, []string{"B"}, out.Flags)
+	require.Equal(t, "RUNNING", out.State)
+	require.Equal(t, "hello", out.Message)
+	require.Equal(t, ts, out.Ts)
+	require.Equal(t, uint64(1), out.Raw)
+	require.Equal(t, 5, out.Ignored)
+	require.Equal(t, 0, out.NoTag)
+}
+
+func Test_State_Marshal(t *testing.T) {
+	schema := createBindingTestSchema(t)
+	state, err := schema.CreateState()
+	require.NoError(t, err)
+
+	ts := time.Date(2022, 1, 1, 0, 0, 10, 0, time.UTC)
+	in := bindingTestStruct{
+		bindingTestBase: bindingTestBase{Int: 5, Uint: 7},
+		Float32:         0.5,
+		Float64:         -1,
+		Bool:            true,
+		Buffer:          []byte("abc"),
+		Fixed:           3.2,
+		Ufixed:          4.56,
+		Flags:           []string{"A", "B"},
+		State:           "ignored",
+		Ts:              ts,
+		Raw:             1,
+	}
+	err = state.Marshal(in)
+	require.NoError(t, err)
+
+	var out bindingTestStruct
+	err = state.Unmarshal(&out)
+	require.NoError(t, err)
+
+	require.Equal(t, 5, out.Int)
+	require.Equal(t, uint8(0), out.Uint) // readonly
+	require.Equal(t, float32(0.5), out.Float32)
+	require.Equal(t, -1.0, out.Float64)
+	require.True(t, out.Bool)
+	require.Equal(t, "abc", out.Message)
+	require.Equal(t, 3.2, out.Fixed)
+	require.Equal(t, 4.56, out.Ufixed)
+	require.ElementsMatch(t, []string{"A", "B"}, out.Flags)
+	require.Equal(t, "RUNNING", out.State)
+	require.Equal(t, ts, out.Ts)
+}
+
+func Test_State_Binding_Errors(t *testing.T) {
+	schema := createBindingTestSchema(t)
+	state, err := schema.CreateState()
+	require.NoError(t, err)
+	require.NoError(t, state.Set("INT", -1))
+	require.NoError(t, state.Set("UINT", 300))
+
+	var wrongTypes struct {
+		Int     uint     `bstates:"INT"`     // negative value
+		Uint    uint8    `bstates:"UINT"`    // overflow
+		Bool    string   `bstates:"BOOL"`    // wrong type
+		Buffer  float64  `bstates:"BUFFER"`  // wrong type
+		Flags   []int    `bstates:"FLAGS"`   // unsupported type
+		Missing int      `bstates:"MISSING"` // unknown field
+		Fixed   int      `bstates:"FIXED"`   // ok (0.0)
+		State   []string `bstates:"STATE"`   // wrong type
+	}
+	err = state.Unmarshal(&wrongTypes)
+	require.Error(t, err)
+	var bindErr *BindingError
+	require.True(t, errors.As(err, &bindErr))
+
+	fieldErrs := map[string]error{}
+	for _, fe := range bindErr.Errors {
+		fieldErrs[fe.Field] = fe.Err
+	}
+	require.Len(t, fieldErrs, 7)
+	require.True(t, errors.Is(fieldErrs["INT"], ErrOutOfRange))
+	require.True(t, errors.Is(fieldErrs["UINT"], ErrOutOfRange))
+	require.True(t, errors.Is(fieldErrs["BOOL"], ErrInvalidType))
+	require.True(t, errors.Is(fieldErrs["BUFFER"], ErrInvalidType))
+	require.True(t, errors.Is(fieldErrs["FLAGS"], ErrInvalidType))
+	require.True(t, errors.Is(fieldErrs["STATE"], ErrInvalidType))
+	require.Error(t, fieldErrs["MISSING"])
+
+	// Marshal errors are reported per field too
+	var wrongValues struct {
+		Int  int    `bstates:"INT"`
+		Uint int    `bstates:"UINT"`
+		Bool string `bstates:"BOOL"`
+	}
+	wrongValues.Int = 1
+	wrongValues.Uint = 5000
+	wrongValues.Bool = "maybe"
+	err = state.Marshal(&wrongValues)
+	require.True(t, errors.As(err, &bindErr))
+	require.Len(t, bindErr.Errors, 2)
+	require.Equal(t, "UINT", bindErr.Errors[0].Field)
+	require.Equal(t, "BOOL", bindErr.Errors[1].Field)
+
+	v, err := state.Get("INT")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), v)
+
+	// Not a pointer to struct
+	require.Error(t, state.Unmarshal(wrongTypes))
+	require.Error(t, state.Unmarshal(new(int)))
+	require.Error(t, state.Marshal(5))
+}
+
+func Test_State_Binding_GeoPoint(t *testing.T) {
+	schema, err := CreateStateSchema(&StateSchemaParams{
+		Fields: []StateField{
+			{Name: "LAT", Type: T_FIXED, Size: 32, Decimals: 6},
+			{Name: "LON", Type: T_FIXED, Size: 32, Decimals: 6},
+		},
+		DecodedFields: []DecodedStateField{
+			{Name: "POSITION", Decoder: &GeoPointDecoder{From: []string{"LAT", "LON"}}},
+		},
+	})
+	require.NoError(t, err)
+	state, err := schema.CreateState()
+	require.NoError(t, err)
+
+	in := struct {
+		Position GeoPoint `bstates:"POSITION"`
+	}{GeoPoint{Lat: 41.387917, Lon: 2.169919}}
+	require.NoError(t, state.Marshal(&in))
+
+	var out struct {
+		Position GeoPoint `bstates:"POSITION"`
+		Lat      float64  `bstates:"LAT"`
+	}
+	require.NoError(t, state.Unmarshal(&out))
+	require.Equal(t, in.Position, out.Position)
+	require.Equal(t, 41.387917, out.Lat)
+
+	var wrong struct {
+		Lat GeoPoint `bstates:"LAT"`
+	}
+	require.ErrorIs(t, state.Unmarshal(&wrong), ErrInvalidType)
+}
@@ -0,0 +1,23 @@
+package bstates
+
+import (
+	"github.com/nayarsystems/buffer/buffer"
+	"github.com/nayarsystems/buffer/shuffling"
+)
+
+// bitTransModifier is the [MOD_BITTRANS] modifier. It transposes the bit matrix of the states (one row per state)
+// so bits of the same field in consecutive states become adjacent.
+type bitTransModifier struct{}
+
+func (bitTransModifier) Encode(b *buffer.Buffer, schema *StateSchema, step PipelineStep) (*buffer.Buffer, error) {
+	stateBitSize := schema.GetByteSize() * 8
+	return shuffling.TransposeBits(b, stateBitSize)
+}
+
+func (bitTransModifier) Decode(b *buffer.Buffer, schema *StateSchema, step PipelineStep) (*buffer.Buffer, error) {
+	if b.GetBitSize() == 0 {
+		return b, nil
+	}
+	numStates := b.GetByteSize() / schema.GetByteSize()
+	return shuffling.TransposeBits(b, numStates)
+}
@@ -0,0 +1,469 @@
+/*
+Package cgen generates C code to build states with the exact binary layout used by [bstates.State.Encode].
+
+[GenerateHeader] outputs a self-contained header (C99, no dependencies besides the standard library and libm)
+which defines the bit offset and size of every field, the default state of the schema and inline setter and
+getter functions for every field:
+
+	uint8_t state[EXAMPLE_STATE_BYTE_SIZE];
+	example_init(state);                 // default values
+	example_set_temp(state, -12.5);      // T_FIXED field "TEMP"
+	example_set_state_code(state, 2);    // T_UINT field "STATE_CODE"
+
+The buffer filled by the generated code is the same buffer [bstates.State.Encode] returns, so it can be pushed as is
+into an encoded [bstates.StateQueue].
+
+[GenerateTestVectors] outputs a C program which checks the generated header against states encoded by the Go
+implementation.
+*/
+package cgen
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jaracil/ei"
+	"github.com/nayarsystems/bstates"
+)
+
+// DefaultPrefix is the prefix used for the generated identifiers when none is provided.
+const DefaultPrefix = "bstates"
+
+// Options of the code generator.
+type Options struct {
+	Prefix     string // Prefix of the generated identifiers (DefaultPrefix if empty, see [CleanPrefix])
+	HeaderName string // Name of the header file included by the test vectors (CleanPrefix(Prefix) + ".h" if empty)
+}
+
+// CleanPrefix returns the prefix used for the generated identifiers: the provided prefix (DefaultPrefix if empty)
+// in lower case, with every character not valid in a C identifier replaced by '_'. It's also safe to use as the
+// base name of the generated files.
+func CleanPrefix(prefix string) string {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return strings.ToLower(sanitize(prefix))
+}
+
+func (o *Options) prefix() string {
+	if o == nil || o.Prefix == "" {
+		return DefaultPrefix
+	}
+	return o.Prefix
+}
+
+func (o *Options) headerName() string {
+	if o == nil || o.HeaderName == "" {
+		return CleanPrefix(o.prefix()) + ".h"
+	}
+	return o.HeaderName
+}
+
+// cField holds the C representation of a schema field.
+type cField struct {
+	*bstates.StateField
+	ident  string // lower case identifier used in function names
+	macro  string // upper case identifier used in macros
+	offset int    // bit offset of the field inside the state
+	ctype  string // C type of the setter argument and getter result (raw value for fixed point fields)
+}
+
+func (f *cField) byteSize() int {
+	return (f.Size + 7) / 8
+}
+
+func (f *cField) isFixed() bool {
+	return f.Type == bstates.T_FIXED || f.Type == bstates.T_UFIXED
+}
+
+func (f *cField) factor() float64 {
+	return math.Pow(10, float64(f.Decimals))
+}
+
+type generator struct {
+	schema *bstates.StateSchema
+	fields []*cField
+	prefix string // lower case prefix
+	macro  string // upper case prefix
+	names  map[string]string
+}
+
+func newGenerator(schema *bstates.StateSchema, opts *Options) (*generator, error) {
+	prefix := CleanPrefix(opts.prefix())
+	g := &generator{
+		schema: schema,
+		prefix: prefix,
+		macro:  strings.ToUpper(prefix),
+		names:  map[string]string{},
+	}
+	offset := 0
+	for _, f := range schema.GetFields() {
+		cf := &cField{
+			StateField: f,
+			ident:      strings.ToLower(sanitize(f.Name)),
+			macro:      strings.ToUpper(sanitize(f.Name)),
+			offset:     offset,
+		}
+		switch f.Type {
+		case bstates.T_INT, bstates.T_FIXED:
+			cf.ctype = fmt.Sprintf("int%d_t", cIntSize(f.Size))
+		case bstates.T_UINT, bstates.T_UFIXED:
+			cf.ctype = fmt.Sprintf("uint%d_t", cIntSize(f.Size))
+		case bstates.T_BOOL:
+			cf.ctype = "bool"
+		case bstates.T_FLOAT32:
+			cf.ctype = "float"
+		case bstates.T_FLOAT64:
+			cf.ctype = "double"
+		case bstates.T_BUFFER:
+			cf.ctype = "uint8_t *"
+		default:
+			return nil, fmt.Errorf("field \"%s\": unsupported type %v", f.Name, f.Type)
+		}
+		offset += f.Size
+		g.fields = append(g.fields, cf)
+	}
+	return g, nil
+}
+
+// declare registers a generated identifier, failing if two schema items are mapped to the same C identifier.
+func (g *generator) declare(ident, owner string) error {
+	if prev, ok := g.names[ident]; ok && prev != owner {
+		return fmt.Errorf("identifier %s generated for both \"%s\" and \"%s\"", ident, prev, owner)
+	}
+	g.names[ident] = owner
+	return nil
+}
+
+// GenerateHeader returns a C header with the field layout of the schema and inline functions to
+// set and get the fields of an encoded state.
+func GenerateHeader(schema *bstates.StateSchema, opts *Options) ([]byte, error) {
+	g, err := newGenerator(schema, opts)
+	if err != nil {
+		return nil, err
+	}
+	defaultState, err := schema.CreateState()
+	if err != nil {
+		return nil, err
+	}
+	defaultBuf, err := defaultState.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &bytes.Buffer{}
+	guard := g.macro + "_H"
+	fmt.Fprintf(w, "/* Code generated by bstates-cgen. DO NOT EDIT. */\n")
+	fmt.Fprintf(w, "/* Schema hash: %s */\n\n", schema.GetHashString())
+	fmt.Fprintf(w, "#ifndef %s\n#define %s\n\n", guard, guard)
+	fmt.Fprintf(w, "#include <math.h>\n#include <stdbool.h>\n#include <stddef.h>\n#include <stdint.h>\n#include <string.h>\n\n")
+	fmt.Fprintf(w, "#define %s_SCHEMA_HASH %s\n", g.macro, strconv.Quote(schema.GetHashString()))
+	fmt.Fprintf(w, "#define %s_STATE_BIT_SIZE %d\n", g.macro, schema.GetBitSize())
+	fmt.Fprintf(w, "#define %s_STATE_BYTE_SIZE %d\n\n", g.macro, schema.GetByteSize())
+
+	fmt.Fprintf(w, "/* Field layout: bit offset and bit size of every field (bits are numbered MSB first) */\n")
+	for _, f := range g.fields {
+		for _, suffix := range []string{"_OFFSET", "_SIZE"} {
+			if err := g.declare(g.macro+"_"+f.macro+suffix, f.Name); err != nil {
+				return nil, err
+			}
+		}
+		fmt.Fprintf(w, "#define %s_%s_OFFSET %d\n", g.macro, f.macro, f.offset)
+		fmt.Fprintf(w, "#define %s_%s_SIZE %d\n", g.macro, f.macro, f.Size)
+		if f.Type == bstates.T_BUFFER {
+			if err := g.declare(g.macro+"_"+f.macro+"_BYTE_SIZE", f.Name); err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(w, "#define %s_%s_BYTE_SIZE %d\n", g.macro, f.macro, f.byteSize())
+		}
+		if f.isFixed() {
+			if err := g.declare(g.macro+"_"+f.macro+"_FACTOR", f.Name); err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(w, "#define %s_%s_FACTOR %s\n", g.macro, f.macro, cDouble(f.factor()))
+		}
+	}
+	fmt.Fprintln(w)
+	if err := g.writeDecoderConstants(w); err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(w, "static const uint8_t %s_default_state[%s_STATE_BYTE_SIZE] = {%s};\n\n", g.prefix, g.macro, cBytes(defaultBuf))
+	g.writeBitFunctions(w)
+	fmt.Fprintf(w, "/* Initializes the state with the default values defined by the schema */\n")
+	fmt.Fprintf(w, "static inline void %s_init(uint8_t *state) {\n", g.prefix)
+	fmt.Fprintf(w, "\tmemcpy(state, %s_default_state, %s_STATE_BYTE_SIZE);\n}\n\n", g.prefix, g.macro)
+
+	for _, f := range g.fields {
+		if err := g.writeFieldFunctions(w, f); err != nil {
+			return nil, err
+		}
+	}
+	fmt.Fprintf(w, "#endif /* %s */\n", guard)
+	return w.Bytes(), nil
+}
+
+// writeDecoderConstants writes the bit masks of Flags decoders and the values of IntMap decoders
+// whose mapped values are strings.
+func (g *generator) writeDecoderConstants(w *bytes.Buffer) error {
+	decodedFields := g.schema.GetDecodedFields()
+	sort.Slice(decodedFields, func(i, j int) bool { return decodedFields[i].Name < decodedFields[j].Name })
+	written := false
+	for _, df := range decodedFields {
+		type constant struct {
+			name  string
+			value string
+		}
+		consts := []constant{}
+		switch d := df.Decoder.(type) {
+		case *bstates.FlagsDecoder:
+			for name, bit := range d.Flags {
+				consts = append(consts, constant{name, fmt.Sprintf("(UINT64_C(1) << %d)", bit)})
+			}
+		case *bstates.IntMapDecoder:
+			intMap, ok := g.schema.GetDecoderIntMap(d.MapId)
+			if !ok {
+				return fmt.Errorf("field \"%s\": int map \"%s\" not found", df.Name, d.MapId)
+			}
+			for k, v := range intMap {
+				if s, ok := v.(string); ok {
+					consts = append(consts, constant{s, cInt64(k)})
+				}
+			}
+		default:
+			continue
+		}
+		if len(consts) == 0 {
+			continue
+		}
+		sort.Slice(consts, func(i, j int) bool { return consts[i].name < consts[j].name })
+		fmt.Fprintf(w, "/* %s (%s decoder) */\n", df.Name, df.Decoder.Name())
+		for _, c := range consts {
+			macro := fmt.Sprintf("%s_%s_%s", g.macro, strings.ToUpper(sanitize(df.Name)), strings.ToUpper(sanitize(c.name)))
+			if err := g.declare(macro, df.Name+"."+c.name); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "#define %s %s\n", macro, c.value)
+		}
+		written = true
+	}
+	if written {
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+func (g *generator) writeBitFunctions(w *bytes.Buffer) {
+	p := g.prefix
+	fmt.Fprintf(w, `/* Writes the size (<= 64) least significant bits of v at the bit offset of buf (MSB first) */
+static inline void %[1]s__set_bits(uint8_t *buf, uint32_t offset, uint32_t size, uint64_t v) {
+	for (uint32_t i = 0; i < size; i++) {
+		uint32_t idx = offset + size - 1 - i;
+		uint8_t mask = (uint8_t)(0x80 >> (idx %% 8));
+		if ((v >> i) & 1) {
+			buf[idx / 8] |= mask;
+		} else {
+			buf[idx / 8] &= (uint8_t)~mask;
+		}
+	}
+}
+
+/* Reads size (<= 64) bits at the bit offset of buf (MSB first) */
+static inline uint64_t %[1]s__get_bits(const uint8_t *buf, uint32_t offset, uint32_t size) {
+	uint64_t v = 0;
+	for (uint32_t i = 0; i < size; i++) {
+		uint32_t idx = offset + i;
+		v = (v << 1) | ((buf[idx / 8] >> (7 - idx %% 8)) & 1);
+	}
+	return v;
+}
+
+/* Same as %[1]s__get_bits, extending the sign of two's complement values */
+static inline int64_t %[1]s__get_signed_bits(const uint8_t *buf, uint32_t offset, uint32_t size) {
+	uint64_t v = %[1]s__get_bits(buf, offset, size);
+	if (size < 64 && ((v >> (size - 1)) & 1)) {
+		v |= ~UINT64_C(0) << size;
+	}
+	return (int64_t)v;
+}
+
+/* Writes the first size bits of src (len bytes, zero padded) at the bit offset of buf */
+static inline void %[1]s__set_raw(uint8_t *buf, uint32_t offset, uint32_t size, const uint8_t *src, size_t len) {
+	for (uint32_t i = 0; i < size; i++) {
+		uint32_t idx = offset + i;
+		uint8_t mask = (uint8_t)(0x80 >> (idx %% 8));
+		if (i / 8 < len && ((src[i / 8] << (i %% 8)) & 0x80)) {
+			buf[idx / 8] |= mask;
+		} else {
+			buf[idx / 8] &= (uint8_t)~mask;
+		}
+	}
+}
+
+/* Reads size bits at the bit offset of buf into dst ((size + 7) / 8 bytes, unused bits are set to 0) */
+static inline void %[1]s__get_raw(const uint8_t *buf, uint32_t offset, uint32_t size, uint8_t *dst) {
+	memset(dst, 0, (size + 7) / 8);
+	for (uint32_t i = 0; i < size; i++) {
+		uint32_t idx = offset + i;
+		if ((buf[idx / 8] >> (7 - idx %% 8)) & 1) {
+			dst[i / 8] |= (uint8_t)(0x80 >> (i %% 8));
+		}
+	}
+}
+
+`, p)
+}
+
+func (g *generator) writeFieldFunctions(w *bytes.Buffer, f *cField) error {
+	p, fn := g.prefix, g.prefix+"_%s_"+f.ident
+	layout := fmt.Sprintf("state, %s_%s_OFFSET, %s_%s_SIZE", g.macro, f.macro, g.macro, f.macro)
+	declare := func(names ...string) error {
+		for _, n := range names {
+			if err := g.declare(fmt.Sprintf(fn, n), f.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	desc := f.typeDesc()
+	fmt.Fprintf(w, "/* %s: %s at bit %d */\n", f.Name, desc, f.offset)
+	switch f.Type {
+	case bstates.T_INT, bstates.T_UINT:
+		if err := declare("set", "get"); err != nil {
+			return err
+		}
+		getter := "get_bits"
+		if f.Type == bstates.T_INT {
+			getter = "get_signed_bits"
+		}
+		fmt.Fprintf(w, "static inline void "+fn+"(uint8_t *state, %s v) {\n", "set", f.ctype)
+		fmt.Fprintf(w, "\t%s__set_bits(%s, (uint64_t)v);\n}\n\n", p, layout)
+		fmt.Fprintf(w, "static inline %s "+fn+"(const uint8_t *state) {\n", f.ctype, "get")
+		fmt.Fprintf(w, "\treturn (%s)%s__%s(%s);\n}\n\n", f.ctype, p, getter, layout)
+	case bstates.T_FIXED, bstates.T_UFIXED:
+		if err := declare("set", "get", "set_raw", "get_raw"); err != nil {
+			return err
+		}
+		getter, rawType := "get_bits", "uint64_t"
+		if f.Type == bstates.T_FIXED {
+			getter, rawType = "get_signed_bits", "int64_t"
+		}
+		factor := fmt.Sprintf("%s_%s_FACTOR", g.macro, f.macro)
+		fmt.Fprintf(w, "static inline void "+fn+"(uint8_t *state, %s v) {\n", "set_raw", f.ctype)
+		fmt.Fprintf(w, "\t%s__set_bits(%s, (uint64_t)v);\n}\n\n", p, layout)
+		fmt.Fprintf(w, "static inline %s "+fn+"(const uint8_t *state) {\n", f.ctype, "get_raw")
+		fmt.Fprintf(w, "\treturn (%s)%s__%s(%s);\n}\n\n", f.ctype, p, getter, layout)
+		fmt.Fprintf(w, "static inline void "+fn+"(uint8_t *state, double v) {\n", "set")
+		fmt.Fprintf(w, "\t%s__set_bits(%s, (uint64_t)(%s)round(v * %s));\n}\n\n", p, layout, rawType, factor)
+		fmt.Fprintf(w, "static inline double "+fn+"(const uint8_t *state) {\n", "get")
+		fmt.Fprintf(w, "\treturn (double)(%s)%s__%s(%s) / %s;\n}\n\n", rawType, p, getter, layout, factor)
+	case bstates.T_BOOL:
+		if err := declare("set", "get"); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "static inline void "+fn+"(uint8_t *state, bool v) {\n", "set")
+		fmt.Fprintf(w, "\t%s__set_bits(%s, v ? 1 : 0);\n}\n\n", p, layout)
+		fmt.Fprintf(w, "static inline bool "+fn+"(const uint8_t *state) {\n", "get")
+		fmt.Fprintf(w, "\treturn %s__get_bits(%s) != 0;\n}\n\n", p, layout)
+	case bstates.T_FLOAT32, bstates.T_FLOAT64:
+		if err := declare("set", "get"); err != nil {
+			return err
+		}
+		bitsType := fmt.Sprintf("uint%d_t", f.Size)
+		fmt.Fprintf(w, "static inline void "+fn+"(uint8_t *state, %s v) {\n", "set", f.ctype)
+		fmt.Fprintf(w, "\t%s bits;\n\tmemcpy(&bits, &v, sizeof(bits));\n", bitsType)
+		fmt.Fprintf(w, "\t%s__set_bits(%s, bits);\n}\n\n", p, layout)
+		fmt.Fprintf(w, "static inline %s "+fn+"(const uint8_t *state) {\n", f.ctype, "get")
+		fmt.Fprintf(w, "\t%s bits = (%s)%s__get_bits(%s);\n", bitsType, bitsType, p, layout)
+		fmt.Fprintf(w, "\t%s v;\n\tmemcpy(&v, &bits, sizeof(v));\n\treturn v;\n}\n\n", f.ctype)
+	case bstates.T_BUFFER:
+		if err := declare("set", "get"); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "/* Copies up to %s_%s_BYTE_SIZE bytes of data, the remaining bytes are set to 0 */\n", g.macro, f.macro)
+		fmt.Fprintf(w, "static inline void "+fn+"(uint8_t *state, const uint8_t *data, size_t len) {\n", "set")
+		fmt.Fprintf(w, "\t%s__set_raw(%s, data, len);\n}\n\n", p, layout)
+		fmt.Fprintf(w, "/* dst must have room for %s_%s_BYTE_SIZE bytes */\n", g.macro, f.macro)
+		fmt.Fprintf(w, "static inline void "+fn+"(const uint8_t *state, uint8_t *dst) {\n", "get")
+		fmt.Fprintf(w, "\t%s__get_raw(%s, dst);\n}\n\n", p, layout)
+	}
+	return nil
+}
+
+func (f *cField) typeDesc() string {
+	msi, err := f.ToMsi()
+	if err != nil {
+		return "?"
+	}
+	desc := fmt.Sprintf("%v, %d bits", msi["type"], f.Size)
+	if f.isFixed() {
+		desc += fmt.Sprintf(", %d decimals", f.Decimals)
+	}
+	return desc
+}
+
+// sanitize replaces every character not valid in a C identifier with '_'.
+func sanitize(name string) string {
+	out := []byte(name)
+	for i, c := range out {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			out[i] = '_'
+		}
+	}
+	return string(out)
+}
+
+// cIntSize returns the size of the smallest C integer type with room for size bits.
+func cIntSize(size int) int {
+	switch {
+	case size <= 8:
+		return 8
+	case size <= 16:
+		return 16
+	case size <= 32:
+		return 32
+	}
+	return 64
+}
+
+func cBytes(b []byte) string {
+	items := make([]string, 0, len(b))
+	for _, v := range b {
+		items = append(items, fmt.Sprintf("0x%02x", v))
+	}
+	return strings.Join(items, ", ")
+}
+
+func cDouble(v float64) string {
+	return strconv.FormatFloat(v, 'e', -1, 64)
+}
+
+func cInt64(v int64) string {
+	if v == math.MinInt64 {
+		return "(-INT64_C(9223372036854775807) - 1)"
+	}
+	return fmt.Sprintf("INT64_C(%d)", v)
+}
+
+func cUint64(v uint64) string {
+	return fmt.Sprintf("UINT64_C(%d)", v)
+}
+
+// rawValue returns the value of the field as stored in the state (integers for fixed point fields).
+func rawValue(state *bstates.State, f *cField) (any, error) {
+	v, err := state.Frame.Get(f.Name)
+	if err != nil {
+		return nil, err
+	}
+	switch f.Type {
+	case bstates.T_INT, bstates.T_FIXED:
+		return ei.N(v).Int64()
+	case bstates.T_UINT, bstates.T_UFIXED:
+		return ei.N(v).Uint64()
+	}
+	return v, nil
+}
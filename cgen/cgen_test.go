@@ -0,0 +1,112 @@
+package cgen
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/nayarsystems/bstates"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func loadTestSchema(t *testing.T) *bstates.StateSchema {
+	raw, err := os.ReadFile(filepath.Join("testdata", "schema.json"))
+	require.NoError(t, err)
+	var schema bstates.StateSchema
+	require.NoError(t, json.Unmarshal(raw, &schema))
+	return &schema
+}
+
+func generateTestFiles(t *testing.T) (header, vectors []byte) {
+	schema := loadTestSchema(t)
+	opts := &Options{Prefix: "example"}
+	header, err := GenerateHeader(schema, opts)
+	require.NoError(t, err)
+	states, err := CreateRandomStates(schema, 16, 1)
+	require.NoError(t, err)
+	vectors, err = GenerateTestVectors(schema, states, opts)
+	require.NoError(t, err)
+	return header, vectors
+}
+
+func Test_Golden(t *testing.T) {
+	header, vectors := generateTestFiles(t)
+	headerPath := filepath.Join("testdata", "example.h")
+	vectorsPath := filepath.Join("testdata", "example_test.c")
+	if *update {
+		require.NoError(t, os.WriteFile(headerPath, header, 0644))
+		require.NoError(t, os.WriteFile(vectorsPath, vectors, 0644))
+	}
+	expected, err := os.ReadFile(headerPath)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(header))
+	expected, err = os.ReadFile(vectorsPath)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(vectors))
+}
+
+func Test_CompileAndRun(t *testing.T) {
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("C compiler not found")
+	}
+	header, vectors := generateTestFiles(t)
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "example.h"), header, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "example_test.c"), vectors, 0644))
+	bin := filepath.Join(dir, "example_test")
+	out, err := exec.Command(cc, "-std=c99", "-Wall", "-Wextra", "-Werror", "-o", bin, filepath.Join(dir, "example_test.c"), "-lm").CombinedOutput()
+	require.NoError(t, err, string(out))
+	out, err = exec.Command(bin).CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.Equal(t, "17 vectors, 0 failures\n", string(out))
+}
+
+func Test_GenerateHeader_Errors(t *testing.T) {
+	schema, err := bstates.CreateStateSchema(&bstates.StateSchemaParams{
+		Fields: []bstates.StateField{
+			{Name: "A-B", Type: bstates.T_UINT, Size: 4},
+			{Name: "A.B", Type: bstates.T_UINT, Size: 4},
+		},
+	})
+	require.NoError(t, err)
+	_, err = GenerateHeader(schema, nil)
+	require.Error(t, err)
+
+	other, err := bstates.CreateStateSchema(&bstates.StateSchemaParams{
+		Fields: []bstates.StateField{{Name: "A", Type: bstates.T_UINT, Size: 4}},
+	})
+	require.NoError(t, err)
+	states, err := CreateRandomStates(other, 1, 1)
+	require.NoError(t, err)
+	_, err = GenerateTestVectors(loadTestSchema(t), states, nil)
+	require.Error(t, err)
+
+	missingMap, err := bstates.CreateStateSchema(&bstates.StateSchemaParams{
+		Fields: []bstates.StateField{{Name: "A", Type: bstates.T_UINT, Size: 4}},
+		DecodedFields: []bstates.DecodedStateField{
+			{Name: "A_LABEL", Decoder: &bstates.IntMapDecoder{From: "A", MapId: "MISSING"}},
+		},
+	})
+	require.NoError(t, err)
+	_, err = GenerateHeader(missingMap, nil)
+	require.ErrorContains(t, err, "MISSING")
+}
+
+func Test_CleanPrefix(t *testing.T) {
+	require.Equal(t, DefaultPrefix, CleanPrefix(""))
+	require.Equal(t, "status", CleanPrefix("Status"))
+	require.Equal(t, "___my_status", CleanPrefix("../my status"))
+
+	schema := loadTestSchema(t)
+	states, err := CreateRandomStates(schema, 1, 1)
+	require.NoError(t, err)
+	vectors, err := GenerateTestVectors(schema, states, &Options{Prefix: "../my status"})
+	require.NoError(t, err)
+	require.Contains(t, string(vectors), "#include \"___my_status.h\"")
+}
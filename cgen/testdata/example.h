@@ -0,0 +1,262 @@
+/* Code generated by bstates-cgen. DO NOT EDIT. */
+/* Schema hash: 5Jrl2XMfvxaKrvnDlDqh0P+5/Pr1SlSR532cWp40tY4= */
+
+#ifndef EXAMPLE_H
+#define EXAMPLE_H
+
+#include <math.h>
+#include <stdbool.h>
+#include <stddef.h>
+#include <stdint.h>
+#include <string.h>
+
+#define EXAMPLE_SCHEMA_HASH "5Jrl2XMfvxaKrvnDlDqh0P+5/Pr1SlSR532cWp40tY4="
+#define EXAMPLE_STATE_BIT_SIZE 370
+#define EXAMPLE_STATE_BYTE_SIZE 47
+
+/* Field layout: bit offset and bit size of every field (bits are numbered MSB first) */
+#define EXAMPLE_STATE_CODE_OFFSET 0
+#define EXAMPLE_STATE_CODE_SIZE 2
+#define EXAMPLE_3BITS_INT_OFFSET 2
+#define EXAMPLE_3BITS_INT_SIZE 3
+#define EXAMPLE_ENABLED_OFFSET 5
+#define EXAMPLE_ENABLED_SIZE 1
+#define EXAMPLE_TEMP_OFFSET 6
+#define EXAMPLE_TEMP_SIZE 12
+#define EXAMPLE_TEMP_FACTOR 1e+01
+#define EXAMPLE_LEVEL_OFFSET 18
+#define EXAMPLE_LEVEL_SIZE 17
+#define EXAMPLE_LEVEL_FACTOR 1e+02
+#define EXAMPLE_FLAGS_RAW_OFFSET 35
+#define EXAMPLE_FLAGS_RAW_SIZE 6
+#define EXAMPLE_RATIO_OFFSET 41
+#define EXAMPLE_RATIO_SIZE 32
+#define EXAMPLE_POSITION_OFFSET 73
+#define EXAMPLE_POSITION_SIZE 64
+#define EXAMPLE_MESSAGE_BUFFER_OFFSET 137
+#define EXAMPLE_MESSAGE_BUFFER_SIZE 45
+#define EXAMPLE_MESSAGE_BUFFER_BYTE_SIZE 6
+#define EXAMPLE_COUNTER_OFFSET 182
+#define EXAMPLE_COUNTER_SIZE 64
+#define EXAMPLE_OFFSET_OFFSET 246
+#define EXAMPLE_OFFSET_SIZE 64
+#define EXAMPLE_BIG_FIXED_OFFSET 310
+#define EXAMPLE_BIG_FIXED_SIZE 60
+#define EXAMPLE_BIG_FIXED_FACTOR 1e+03
+
+/* FLAGS (Flags decoder) */
+#define EXAMPLE_FLAGS_DOOR_OPEN (UINT64_C(1) << 0)
+#define EXAMPLE_FLAGS_LOW_BATTERY (UINT64_C(1) << 5)
+/* STATE (IntMap decoder) */
+#define EXAMPLE_STATE_IDLE INT64_C(0)
+#define EXAMPLE_STATE_RUNNING INT64_C(2)
+#define EXAMPLE_STATE_STOPPED INT64_C(1)
+
+static const uint8_t example_default_state[EXAMPLE_STATE_BYTE_SIZE] = {0x77, 0xcc, 0xc0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00};
+
+/* Writes the size (<= 64) least significant bits of v at the bit offset of buf (MSB first) */
+static inline void example__set_bits(uint8_t *buf, uint32_t offset, uint32_t size, uint64_t v) {
+	for (uint32_t i = 0; i < size; i++) {
+		uint32_t idx = offset + size - 1 - i;
+		uint8_t mask = (uint8_t)(0x80 >> (idx % 8));
+		if ((v >> i) & 1) {
+			buf[idx / 8] |= mask;
+		} else {
+			buf[idx / 8] &= (uint8_t)~mask;
+		}
+	}
+}
+
+/* Reads size (<= 64) bits at the bit offset of buf (MSB first) */
+static inline uint64_t example__get_bits(const uint8_t *buf, uint32_t offset, uint32_t size) {
+	uint64_t v = 0;
+	for (uint32_t i = 0; i < size; i++) {
+		uint32_t idx = offset + i;
+		v = (v << 1) | ((buf[idx / 8] >> (7 - idx % 8)) & 1);
+	}
+	return v;
+}
+
+/* Same as example__get_bits, extending the sign of two's complement values */
+static inline int64_t example__get_signed_bits(const uint8_t *buf, uint32_t offset, uint32_t size) {
+	uint64_t v = example__get_bits(buf, offset, size);
+	if (size < 64 && ((v >> (size - 1)) & 1)) {
+		v |= ~UINT64_C(0) << size;
+	}
+	return (int64_t)v;
+}
+
+/* Writes the first size bits of src (len bytes, zero padded) at the bit offset of buf */
+static inline void example__set_raw(uint8_t *buf, uint32_t offset, uint32_t size, const uint8_t *src, size_t len) {
+	for (uint32_t i = 0; i < size; i++) {
+		uint32_t idx = offset + i;
+		uint8_t mask = (uint8_t)(0x80 >> (idx % 8));
+		if (i / 8 < len && ((src[i / 8] << (i % 8)) & 0x80)) {
+			buf[idx / 8] |= mask;
+		} else {
+			buf[idx / 8] &= (uint8_t)~mask;
+		}
+	}
+}
+
+/* Reads size bits at the bit offset of buf into dst ((size + 7) / 8 bytes, unused bits are set to 0) */
+static inline void example__get_raw(const uint8_t *buf, uint32_t offset, uint32_t size, uint8_t *dst) {
+	memset(dst, 0, (size + 7) / 8);
+	for (uint32_t i = 0; i < size; i++) {
+		uint32_t idx = offset + i;
+		if ((buf[idx / 8] >> (7 - idx % 8)) & 1) {
+			dst[i / 8] |= (uint8_t)(0x80 >> (i % 8));
+		}
+	}
+}
+
+/* Initializes the state with the default values defined by the schema */
+static inline void example_init(uint8_t *state) {
+	memcpy(state, example_default_state, EXAMPLE_STATE_BYTE_SIZE);
+}
+
+/* STATE_CODE: uint, 2 bits at bit 0 */
+static inline void example_set_state_code(uint8_t *state, uint8_t v) {
+	example__set_bits(state, EXAMPLE_STATE_CODE_OFFSET, EXAMPLE_STATE_CODE_SIZE, (uint64_t)v);
+}
+
+static inline uint8_t example_get_state_code(const uint8_t *state) {
+	return (uint8_t)example__get_bits(state, EXAMPLE_STATE_CODE_OFFSET, EXAMPLE_STATE_CODE_SIZE);
+}
+
+/* 3BITS_INT: int, 3 bits at bit 2 */
+static inline void example_set_3bits_int(uint8_t *state, int8_t v) {
+	example__set_bits(state, EXAMPLE_3BITS_INT_OFFSET, EXAMPLE_3BITS_INT_SIZE, (uint64_t)v);
+}
+
+static inline int8_t example_get_3bits_int(const uint8_t *state) {
+	return (int8_t)example__get_signed_bits(state, EXAMPLE_3BITS_INT_OFFSET, EXAMPLE_3BITS_INT_SIZE);
+}
+
+/* ENABLED: bool, 1 bits at bit 5 */
+static inline void example_set_enabled(uint8_t *state, bool v) {
+	example__set_bits(state, EXAMPLE_ENABLED_OFFSET, EXAMPLE_ENABLED_SIZE, v ? 1 : 0);
+}
+
+static inline bool example_get_enabled(const uint8_t *state) {
+	return example__get_bits(state, EXAMPLE_ENABLED_OFFSET, EXAMPLE_ENABLED_SIZE) != 0;
+}
+
+/* TEMP: fixed, 12 bits, 1 decimals at bit 6 */
+static inline void example_set_raw_temp(uint8_t *state, int16_t v) {
+	example__set_bits(state, EXAMPLE_TEMP_OFFSET, EXAMPLE_TEMP_SIZE, (uint64_t)v);
+}
+
+static inline int16_t example_get_raw_temp(const uint8_t *state) {
+	return (int16_t)example__get_signed_bits(state, EXAMPLE_TEMP_OFFSET, EXAMPLE_TEMP_SIZE);
+}
+
+static inline void example_set_temp(uint8_t *state, double v) {
+	example__set_bits(state, EXAMPLE_TEMP_OFFSET, EXAMPLE_TEMP_SIZE, (uint64_t)(int64_t)round(v * EXAMPLE_TEMP_FACTOR));
+}
+
+static inline double example_get_temp(const uint8_t *state) {
+	return (double)(int64_t)example__get_signed_bits(state, EXAMPLE_TEMP_OFFSET, EXAMPLE_TEMP_SIZE) / EXAMPLE_TEMP_FACTOR;
+}
+
+/* LEVEL: ufixed, 17 bits, 2 decimals at bit 18 */
+static inline void example_set_raw_level(uint8_t *state, uint32_t v) {
+	example__set_bits(state, EXAMPLE_LEVEL_OFFSET, EXAMPLE_LEVEL_SIZE, (uint64_t)v);
+}
+
+static inline uint32_t example_get_raw_level(const uint8_t *state) {
+	return (uint32_t)example__get_bits(state, EXAMPLE_LEVEL_OFFSET, EXAMPLE_LEVEL_SIZE);
+}
+
+static inline void example_set_level(uint8_t *state, double v) {
+	example__set_bits(state, EXAMPLE_LEVEL_OFFSET, EXAMPLE_LEVEL_SIZE, (uint64_t)(uint64_t)round(v * EXAMPLE_LEVEL_FACTOR));
+}
+
+static inline double example_get_level(const uint8_t *state) {
+	return (double)(uint64_t)example__get_bits(state, EXAMPLE_LEVEL_OFFSET, EXAMPLE_LEVEL_SIZE) / EXAMPLE_LEVEL_FACTOR;
+}
+
+/* FLAGS_RAW: uint, 6 bits at bit 35 */
+static inline void example_set_flags_raw(uint8_t *state, uint8_t v) {
+	example__set_bits(state, EXAMPLE_FLAGS_RAW_OFFSET, EXAMPLE_FLAGS_RAW_SIZE, (uint64_t)v);
+}
+
+static inline uint8_t example_get_flags_raw(const uint8_t *state) {
+	return (uint8_t)example__get_bits(state, EXAMPLE_FLAGS_RAW_OFFSET, EXAMPLE_FLAGS_RAW_SIZE);
+}
+
+/* RATIO: float32, 32 bits at bit 41 */
+static inline void example_set_ratio(uint8_t *state, float v) {
+	uint32_t bits;
+	memcpy(&bits, &v, sizeof(bits));
+	example__set_bits(state, EXAMPLE_RATIO_OFFSET, EXAMPLE_RATIO_SIZE, bits);
+}
+
+static inline float example_get_ratio(const uint8_t *state) {
+	uint32_t bits = (uint32_t)example__get_bits(state, EXAMPLE_RATIO_OFFSET, EXAMPLE_RATIO_SIZE);
+	float v;
+	memcpy(&v, &bits, sizeof(v));
+	return v;
+}
+
+/* POSITION: float64, 64 bits at bit 73 */
+static inline void example_set_position(uint8_t *state, double v) {
+	uint64_t bits;
+	memcpy(&bits, &v, sizeof(bits));
+	example__set_bits(state, EXAMPLE_POSITION_OFFSET, EXAMPLE_POSITION_SIZE, bits);
+}
+
+static inline double example_get_position(const uint8_t *state) {
+	uint64_t bits = (uint64_t)example__get_bits(state, EXAMPLE_POSITION_OFFSET, EXAMPLE_POSITION_SIZE);
+	double v;
+	memcpy(&v, &bits, sizeof(v));
+	return v;
+}
+
+/* MESSAGE_BUFFER: buffer, 45 bits at bit 137 */
+/* Copies up to EXAMPLE_MESSAGE_BUFFER_BYTE_SIZE bytes of data, the remaining bytes are set to 0 */
+static inline void example_set_message_buffer(uint8_t *state, const uint8_t *data, size_t len) {
+	example__set_raw(state, EXAMPLE_MESSAGE_BUFFER_OFFSET, EXAMPLE_MESSAGE_BUFFER_SIZE, data, len);
+}
+
+/* dst must have room for EXAMPLE_MESSAGE_BUFFER_BYTE_SIZE bytes */
+static inline void example_get_message_buffer(const uint8_t *state, uint8_t *dst) {
+	example__get_raw(state, EXAMPLE_MESSAGE_BUFFER_OFFSET, EXAMPLE_MESSAGE_BUFFER_SIZE, dst);
+}
+
+/* COUNTER: uint, 64 bits at bit 182 */
+static inline void example_set_counter(uint8_t *state, uint64_t v) {
+	example__set_bits(state, EXAMPLE_COUNTER_OFFSET, EXAMPLE_COUNTER_SIZE, (uint64_t)v);
+}
+
+static inline uint64_t example_get_counter(const uint8_t *state) {
+	return (uint64_t)example__get_bits(state, EXAMPLE_COUNTER_OFFSET, EXAMPLE_COUNTER_SIZE);
+}
+
+/* OFFSET: int, 64 bits at bit 246 */
+static inline void example_set_offset(uint8_t *state, int64_t v) {
+	example__set_bits(state, EXAMPLE_OFFSET_OFFSET, EXAMPLE_OFFSET_SIZE, (uint64_t)v);
+}
+
+static inline int64_t example_get_offset(const uint8_t *state) {
+	return (int64_t)example__get_signed_bits(state, EXAMPLE_OFFSET_OFFSET, EXAMPLE_OFFSET_SIZE);
+}
+
+/* BIG_FIXED: fixed, 60 bits, 3 decimals at bit 310 */
+static inline void example_set_raw_big_fixed(uint8_t *state, int64_t v) {
+	example__set_bits(state, EXAMPLE_BIG_FIXED_OFFSET, EXAMPLE_BIG_FIXED_SIZE, (uint64_t)v);
+}
+
+static inline int64_t example_get_raw_big_fixed(const uint8_t *state) {
+	return (int64_t)example__get_signed_bits(state, EXAMPLE_BIG_FIXED_OFFSET, EXAMPLE_BIG_FIXED_SIZE);
+}
+
+static inline void example_set_big_fixed(uint8_t *state, double v) {
+	example__set_bits(state, EXAMPLE_BIG_FIXED_OFFSET, EXAMPLE_BIG_FIXED_SIZE, (uint64_t)(int64_t)round(v * EXAMPLE_BIG_FIXED_FACTOR));
+}
+
+static inline double example_get_big_fixed(const uint8_t *state) {
+	return (double)(int64_t)example__get_signed_bits(state, EXAMPLE_BIG_FIXED_OFFSET, EXAMPLE_BIG_FIXED_SIZE) / EXAMPLE_BIG_FIXED_FACTOR;
+}
+
+#endif /* EXAMPLE_H */
@@ -0,0 +1,695 @@
+/* Code generated by bstates-cgen. DO NOT EDIT. */
+/* Test vectors for schema 5Jrl2XMfvxaKrvnDlDqh0P+5/Pr1SlSR532cWp40tY4= */
+
+#include <stdio.h>
+
+#include "example.h"
+
+static int failures = 0;
+
+#define CHECK(vector, cond) do { if (!(cond)) { failures++; printf("vector %d: check failed: %s\n", vector, #cond); } } while (0)
+
+static void check_default(void) {
+	uint8_t state[EXAMPLE_STATE_BYTE_SIZE];
+	example_init(state);
+	CHECK(0, memcmp(state, example_default_state, EXAMPLE_STATE_BYTE_SIZE) == 0);
+}
+
+static void check_vector_1(void) {
+	static const uint8_t expected[EXAMPLE_STATE_BYTE_SIZE] = {0x7f, 0x00, 0xcd, 0x96, 0xaa, 0x88, 0x66, 0xcb, 0x39, 0x4a, 0x04, 0x69, 0x56, 0x11, 0x62, 0x69, 0x4a, 0x50, 0x03, 0x94, 0x9c, 0xa4, 0x3e, 0x9a, 0x11, 0x1e, 0x90, 0x62, 0x77, 0xae, 0x65, 0x07, 0xc9, 0xf3, 0x1b, 0xce, 0x1d, 0x74, 0x12, 0x09, 0x56, 0xab, 0xe5, 0x7a, 0x51, 0x89, 0xc0};
+	uint8_t state[EXAMPLE_STATE_BYTE_SIZE];
+	example_init(state);
+	example_set_state_code(state, UINT64_C(1));
+	example_set_3bits_int(state, INT64_C(-1));
+	example_set_enabled(state, true);
+	example_set_temp(state, -1.021e+02);
+	example_set_level(state, 2.7829e+02);
+	example_set_flags_raw(state, UINT64_C(21));
+	example_set_ratio(state, 0x1.9b2ce4p-94f);
+	example_set_position(state, -0x1.8d2ac22c4d294p-703);
+	{
+		static const uint8_t data[6] = {0xa0, 0x07, 0x29, 0x39, 0x48, 0x78};
+		example_set_message_buffer(state, data, sizeof(data));
+	}
+	example_set_counter(state, UINT64_C(11998794077335055257));
+	example_set_offset(state, INT64_C(4751997750760398084));
+	example_set_raw_big_fixed(state, INT64_C(-565946467984259545));
+	CHECK(1, memcmp(state, expected, EXAMPLE_STATE_BYTE_SIZE) == 0);
+	CHECK(1, example_get_state_code(state) == UINT64_C(1));
+	CHECK(1, example_get_3bits_int(state) == INT64_C(-1));
+	CHECK(1, example_get_enabled(state) == true);
+	CHECK(1, example_get_raw_temp(state) == INT64_C(-1021));
+	CHECK(1, example_get_temp(state) == -1.021e+02);
+	CHECK(1, example_get_raw_level(state) == UINT64_C(27829));
+	CHECK(1, example_get_level(state) == 2.7829e+02);
+	CHECK(1, example_get_flags_raw(state) == UINT64_C(21));
+	CHECK(1, example_get_ratio(state) == 0x1.9b2ce4p-94f);
+	CHECK(1, example_get_position(state) == -0x1.8d2ac22c4d294p-703);
+	{
+		static const uint8_t data[6] = {0xa0, 0x07, 0x29, 0x39, 0x48, 0x78};
+		uint8_t out[EXAMPLE_MESSAGE_BUFFER_BYTE_SIZE];
+		example_get_message_buffer(state, out);
+		CHECK(1, memcmp(out, data, sizeof(out)) == 0);
+	}
+	CHECK(1, example_get_counter(state) == UINT64_C(11998794077335055257));
+	CHECK(1, example_get_offset(state) == INT64_C(4751997750760398084));
+	CHECK(1, example_get_raw_big_fixed(state) == INT64_C(-565946467984259545));
+}
+
+static void check_vector_2(void) {
+	static const uint8_t expected[EXAMPLE_STATE_BYTE_SIZE] = {0xa1, 0x76, 0x69, 0x0e, 0x94, 0xd6, 0xec, 0x3f, 0x25, 0x4d, 0xfc, 0xc5, 0xf1, 0x54, 0xeb, 0xc6, 0xb9, 0xb4, 0x90, 0x88, 0xce, 0x0b, 0x04, 0xb8, 0xc4, 0x23, 0x6a, 0xec, 0x56, 0x19, 0x13, 0x24, 0x2f, 0x49, 0xa2, 0xda, 0x39, 0xa8, 0xff, 0x99, 0x87, 0xa4, 0x9d, 0x66, 0x01, 0x7d, 0x40};
+	uint8_t state[EXAMPLE_STATE_BYTE_SIZE];
+	example_init(state);
+	example_set_state_code(state, UINT64_C(2));
+	example_set_3bits_int(state, INT64_C(-4));
+	example_set_enabled(state, false);
+	example_set_temp(state, 1.497e+02);
+	example_set_level(state, 8.4084e+02);
+	example_set_flags_raw(state, UINT64_C(41));
+	example_set_ratio(state, -0x1.b0fc94p-36f);
+	example_set_position(state, -0x1.98be2a9d78d73p-576);
+	{
+		static const uint8_t data[6] = {0x69, 0x21, 0x11, 0x9c, 0x16, 0x08};
+		example_set_message_buffer(state, data, sizeof(data));
+	}
+	example_set_counter(state, UINT64_C(3328451335138149956));
+	example_set_offset(state, INT64_C(-3959840100161000897));
+	example_set_raw_big_fixed(state, INT64_C(-115371126064413195));
+	CHECK(2, memcmp(state, expected, EXAMPLE_STATE_BYTE_SIZE) == 0);
+	CHECK(2, example_get_state_code(state) == UINT64_C(2));
+	CHECK(2, example_get_3bits_int(state) == INT64_C(-4));
+	CHECK(2, example_get_enabled(state) == false);
+	CHECK(2, example_get_raw_temp(state) == INT64_C(1497));
+	CHECK(2, example_get_temp(state) == 1.497e+02);
+	CHECK(2, example_get_raw_level(state) == UINT64_C(84084));
+	CHECK(2, example_get_level(state) == 8.4084e+02);
+	CHECK(2, example_get_flags_raw(state) == UINT64_C(41));
+	CHECK(2, example_get_ratio(state) == -0x1.b0fc94p-36f);
+	CHECK(2, example_get_position(state) == -0x1.98be2a9d78d73p-576);
+	{
+		static const uint8_t data[6] = {0x69, 0x21, 0x11, 0x9c, 0x16, 0x08};
+		uint8_t out[EXAMPLE_MESSAGE_BUFFER_BYTE_SIZE];
+		example_get_message_buffer(state, out);
+		CHECK(2, memcmp(out, data, sizeof(out)) == 0);
+	}
+	CHECK(2, example_get_counter(state) == UINT64_C(3328451335138149956));
+	CHECK(2, example_get_offset(state) == INT64_C(-3959840100161000897));
+	CHECK(2, example_get_raw_big_fixed(state) == INT64_C(-115371126064413195));
+}
+
+static void check_vector_3(void) {
+	static const uint8_t expected[EXAMPLE_STATE_BYTE_SIZE] = {0xac, 0x38, 0xbb, 0xb0, 0xdb, 0x43, 0x0c, 0x8b, 0x35, 0x81, 0xcf, 0xb7, 0xbc, 0x50, 0xae, 0xa9, 0x1d, 0x83, 0x81, 0x7f, 0x84, 0xa1, 0x3d, 0x36, 0xe9, 0xec, 0x3e, 0x76, 0x87, 0x5f, 0xaf, 0xf3, 0x52, 0xde, 0x95, 0x68, 0x97, 0x83, 0x2e, 0x8a, 0xe2, 0x53, 0x3e, 0x10, 0x3b, 0x12, 0xc0};
+	uint8_t state[EXAMPLE_STATE_BYTE_SIZE];
+	example_init(state);
+	example_set_state_code(state, UINT64_C(2));
+	example_set_3bits_int(state, INT64_C(-3));
+	example_set_enabled(state, true);
+	example_set_temp(state, 2.26e+01);
+	example_set_level(state, 1.22246e+03);
+	example_set_flags_raw(state, UINT64_C(54));
+	example_set_ratio(state, -0x1.322cd6p-115f);
+	example_set_position(state, 0x1.f6f78a15d523bp-966);
+	{
+		static const uint8_t data[6] = {0x07, 0x02, 0xff, 0x09, 0x42, 0x78};
+		example_set_message_buffer(state, data, sizeof(data));
+	}
+	example_set_counter(state, UINT64_C(5600924393587988459));
+	example_set_offset(state, INT64_C(-228355760279134005));
+	example_set_raw_big_fixed(state, INT64_C(-420091176553485237));
+	CHECK(3, memcmp(state, expected, EXAMPLE_STATE_BYTE_SIZE) == 0);
+	CHECK(3, example_get_state_code(state) == UINT64_C(2));
+	CHECK(3, example_get_3bits_int(state) == INT64_C(-3));
+	CHECK(3, example_get_enabled(state) == true);
+	CHECK(3, example_get_raw_temp(state) == INT64_C(226));
+	CHECK(3, example_get_temp(state) == 2.26e+01);
+	CHECK(3, example_get_raw_level(state) == UINT64_C(122246));
+	CHECK(3, example_get_level(state) == 1.22246e+03);
+	CHECK(3, example_get_flags_raw(state) == UINT64_C(54));
+	CHECK(3, example_get_ratio(state) == -0x1.322cd6p-115f);
+	CHECK(3, example_get_position(state) == 0x1.f6f78a15d523bp-966);
+	{
+		static const uint8_t data[6] = {0x07, 0x02, 0xff, 0x09, 0x42, 0x78};
+		uint8_t out[EXAMPLE_MESSAGE_BUFFER_BYTE_SIZE];
+		example_get_message_buffer(state, out);
+		CHECK(3, memcmp(out, data, sizeof(out)) == 0);
+	}
+	CHECK(3, example_get_counter(state) == UINT64_C(5600924393587988459));
+	CHECK(3, example_get_offset(state) == INT64_C(-228355760279134005));
+	CHECK(3, example_get_raw_big_fixed(state) == INT64_C(-420091176553485237));
+}
+
+static void check_vector_4(void) {
+	static const uint8_t expected[EXAMPLE_STATE_BYTE_SIZE] = {0x5c, 0xc1, 0x25, 0x8b, 0x68, 0xc5, 0xa6, 0xe3, 0xca, 0xd1, 0xd3, 0x11, 0xa1, 0xd8, 0xc3, 0x5a, 0x8f, 0xed, 0x8c, 0xa2, 0x5e, 0x47, 0xcf, 0x0f, 0xa8, 0xee, 0x4e, 0x4f, 0xe4, 0xfc, 0xcc, 0x81, 0xd0, 0x0f, 0x7b, 0xd8, 0xe9, 0x6d, 0xbd, 0x06, 0xce, 0xb9, 0x06, 0x51, 0xdb, 0x0d, 0x80};
+	uint8_t state[EXAMPLE_STATE_BYTE_SIZE];
+	example_init(state);
+	example_set_state_code(state, UINT64_C(1));
+	example_set_3bits_int(state, INT64_C(3));
+	example_set_enabled(state, true);
+	example_set_temp(state, 7.72e+01);
+	example_set_level(state, 7.6891e+02);
+	example_set_flags_raw(state, UINT64_C(17));
+	example_set_ratio(state, -0x1.9b8f2ap-105f);
+	example_set_position(state, -0x1.62343b186b51fp-453);
+	{
+		static const uint8_t data[6] = {0xdb, 0x19, 0x44, 0xbc, 0x8f, 0x98};
+		example_set_message_buffer(state, data, sizeof(data));
+	}
+	example_set_counter(state, UINT64_C(14117161486975057715));
+	example_set_offset(state, INT64_C(2338498362660772719));
+	example_set_raw_big_fixed(state, INT64_C(295894951873965110));
+	CHECK(4, memcmp(state, expected, EXAMPLE_STATE_BYTE_SIZE) == 0);
+	CHECK(4, example_get_state_code(state) == UINT64_C(1));
+	CHECK(4, example_get_3bits_int(state) == INT64_C(3));
+	CHECK(4, example_get_enabled(state) == true);
+	CHECK(4, example_get_raw_temp(state) == INT64_C(772));
+	CHECK(4, example_get_temp(state) == 7.72e+01);
+	CHECK(4, example_get_raw_level(state) == UINT64_C(76891));
+	CHECK(4, example_get_level(state) == 7.6891e+02);
+	CHECK(4, example_get_flags_raw(state) == UINT64_C(17));
+	CHECK(4, example_get_ratio(state) == -0x1.9b8f2ap-105f);
+	CHECK(4, example_get_position(state) == -0x1.62343b186b51fp-453);
+	{
+		static const uint8_t data[6] = {0xdb, 0x19, 0x44, 0xbc, 0x8f, 0x98};
+		uint8_t out[EXAMPLE_MESSAGE_BUFFER_BYTE_SIZE];
+		example_get_message_buffer(state, out);
+		CHECK(4, memcmp(out, data, sizeof(out)) == 0);
+	}
+	CHECK(4, example_get_counter(state) == UINT64_C(14117161486975057715));
+	CHECK(4, example_get_offset(state) == INT64_C(2338498362660772719));
+	CHECK(4, example_get_raw_big_fixed(state) == INT64_C(295894951873965110));
+}
+
+static void check_vector_5(void) {
+	static const uint8_t expected[EXAMPLE_STATE_BYTE_SIZE] = {0x6e, 0xce, 0x5c, 0x9e, 0x81, 0xfd, 0x0b, 0x9c, 0xa8, 0xc4, 0xc1, 0x2f, 0x08, 0xb8, 0x1c, 0x9b, 0xa5, 0xbe, 0xf8, 0xec, 0x94, 0xf6, 0xb7, 0x5c, 0xb6, 0x4b, 0xeb, 0x7b, 0x5f, 0x90, 0x44, 0x7b, 0xa7, 0xdd, 0x9d, 0x99, 0xe3, 0x9e, 0xa9, 0xf7, 0xfd, 0xea, 0xc9, 0x13, 0xf3, 0x4d, 0x80};
+	uint8_t state[EXAMPLE_STATE_BYTE_SIZE];
+	example_init(state);
+	example_set_state_code(state, UINT64_C(1));
+	example_set_3bits_int(state, INT64_C(-3));
+	example_set_enabled(state, true);
+	example_set_temp(state, -1.223e+02);
+	example_set_level(state, 5.8612e+02);
+	example_set_flags_raw(state, UINT64_C(3));
+	example_set_ratio(state, -0x1.2e72a2p+117f);
+	example_set_position(state, -0x1.25e117039374bp-871);
+	{
+		static const uint8_t data[6] = {0x7d, 0xf1, 0xd9, 0x29, 0xed, 0x68};
+		example_set_message_buffer(state, data, sizeof(data));
+	}
+	example_set_counter(state, UINT64_C(15505210698284655633));
+	example_set_offset(state, INT64_C(2227583514184312746));
+	example_set_raw_big_fixed(state, INT64_C(567444392492649782));
+	CHECK(5, memcmp(state, expected, EXAMPLE_STATE_BYTE_SIZE) == 0);
+	CHECK(5, example_get_state_code(state) == UINT64_C(1));
+	CHECK(5, example_get_3bits_int(state) == INT64_C(-3));
+	CHECK(5, example_get_enabled(state) == true);
+	CHECK(5, example_get_raw_temp(state) == INT64_C(-1223));
+	CHECK(5, example_get_temp(state) == -1.223e+02);
+	CHECK(5, example_get_raw_level(state) == UINT64_C(58612));
+	CHECK(5, example_get_level(state) == 5.8612e+02);
+	CHECK(5, example_get_flags_raw(state) == UINT64_C(3));
+	CHECK(5, example_get_ratio(state) == -0x1.2e72a2p+117f);
+	CHECK(5, example_get_position(state) == -0x1.25e117039374bp-871);
+	{
+		static const uint8_t data[6] = {0x7d, 0xf1, 0xd9, 0x29, 0xed, 0x68};
+		uint8_t out[EXAMPLE_MESSAGE_BUFFER_BYTE_SIZE];
+		example_get_message_buffer(state, out);
+		CHECK(5, memcmp(out, data, sizeof(out)) == 0);
+	}
+	CHECK(5, example_get_counter(state) == UINT64_C(15505210698284655633));
+	CHECK(5, example_get_offset(state) == INT64_C(2227583514184312746));
+	CHECK(5, example_get_raw_big_fixed(state) == INT64_C(567444392492649782));
+}
+
+static void check_vector_6(void) {
+	static const uint8_t expected[EXAMPLE_STATE_BYTE_SIZE] = {0x6e, 0x8e, 0x85, 0xda, 0x96, 0xf2, 0xd0, 0xa1, 0xe9, 0x2b, 0xb0, 0x98, 0x41, 0x61, 0x19, 0xf8, 0x03, 0xa0, 0x92, 0xe4, 0x7d, 0x39, 0xdd, 0xd8, 0x2c, 0x34, 0x88, 0x14, 0x05, 0x0e, 0x98, 0x2e, 0x82, 0x39, 0x2d, 0xce, 0x2d, 0xa0, 0xa7, 0xc7, 0xd1, 0xba, 0x0d, 0xa6, 0x72, 0xbc, 0xc0};
+	uint8_t state[EXAMPLE_STATE_BYTE_SIZE];
+	example_init(state);
+	example_set_state_code(state, UINT64_C(1));
+	example_set_3bits_int(state, INT64_C(-3));
+	example_set_enabled(state, true);
+	example_set_temp(state, -1.478e+02);
+	example_set_level(state, 1.1988e+02);
+	example_set_flags_raw(state, UINT64_C(45));
+	example_set_ratio(state, -0x1.4287a4p+76f);
+	example_set_position(state, 0x1.13082c233f007p+375);
+	{
+		static const uint8_t data[6] = {0x41, 0x25, 0xc8, 0xfa, 0x73, 0xb8};
+		example_set_message_buffer(state, data, sizeof(data));
+	}
+	example_set_counter(state, UINT64_C(8505906760983331750));
+	example_set_offset(state, INT64_C(837825985403119657));
+	example_set_raw_big_fixed(state, INT64_C(-63253906597491981));
+	CHECK(6, memcmp(state, expected, EXAMPLE_STATE_BYTE_SIZE) == 0);
+	CHECK(6, example_get_state_code(state) == UINT64_C(1));
+	CHECK(6, example_get_3bits_int(state) == INT64_C(-3));
+	CHECK(6, example_get_enabled(state) == true);
+	CHECK(6, example_get_raw_temp(state) == INT64_C(-1478));
+	CHECK(6, example_get_temp(state) == -1.478e+02);
+	CHECK(6, example_get_raw_level(state) == UINT64_C(11988));
+	CHECK(6, example_get_level(state) == 1.1988e+02);
+	CHECK(6, example_get_flags_raw(state) == UINT64_C(45));
+	CHECK(6, example_get_ratio(state) == -0x1.4287a4p+76f);
+	CHECK(6, example_get_position(state) == 0x1.13082c233f007p+375);
+	{
+		static const uint8_t data[6] = {0x41, 0x25, 0xc8, 0xfa, 0x73, 0xb8};
+		uint8_t out[EXAMPLE_MESSAGE_BUFFER_BYTE_SIZE];
+		example_get_message_buffer(state, out);
+		CHECK(6, memcmp(out, data, sizeof(out)) == 0);
+	}
+	CHECK(6, example_get_counter(state) == UINT64_C(8505906760983331750));
+	CHECK(6, example_get_offset(state) == INT64_C(837825985403119657));
+	CHECK(6, example_get_raw_big_fixed(state) == INT64_C(-63253906597491981));
+}
+
+static void check_vector_7(void) {
+	static const uint8_t expected[EXAMPLE_STATE_BYTE_SIZE] = {0x75, 0x1e, 0x56, 0xbf, 0xc9, 0x53, 0x22, 0x78, 0x7c, 0x23, 0x50, 0x1e, 0x5a, 0x25, 0x7c, 0x32, 0x5d, 0x68, 0x75, 0x52, 0xcf, 0xc7, 0x27, 0x82, 0xfb, 0x4c, 0x7c, 0x72, 0xe7, 0x9b, 0x1a, 0xce, 0xbf, 0x4d, 0xe5, 0x05, 0x0e, 0x42, 0x24, 0x2e, 0x4b, 0x40, 0x5a, 0x68, 0xe4, 0x51, 0x00};
+	uint8_t state[EXAMPLE_STATE_BYTE_SIZE];
+	example_init(state);
+	example_set_state_code(state, UINT64_C(1));
+	example_set_3bits_int(state, INT64_C(-2));
+	example_set_enabled(state, true);
+	example_set_temp(state, 1.145e+02);
+	example_set_level(state, 4.659e+02);
+	example_set_flags_raw(state, UINT64_C(18));
+	example_set_ratio(state, -0x1.89e1fp-51f);
+	example_set_position(state, 0x1.03cb44af864bap+107);
+	{
+		static const uint8_t data[6] = {0xd0, 0xea, 0xa5, 0x9f, 0x8e, 0x48};
+		example_set_message_buffer(state, data, sizeof(data));
+	}
+	example_set_counter(state, UINT64_C(16194613440650274502));
+	example_set_offset(state, INT64_C(-5498944102256635767));
+	example_set_raw_big_fixed(state, INT64_C(52122354782015812));
+	CHECK(7, memcmp(state, expected, EXAMPLE_STATE_BYTE_SIZE) == 0);
+	CHECK(7, example_get_state_code(state) == UINT64_C(1));
+	CHECK(7, example_get_3bits_int(state) == INT64_C(-2));
+	CHECK(7, example_get_enabled(state) == true);
+	CHECK(7, example_get_raw_temp(state) == INT64_C(1145));
+	CHECK(7, example_get_temp(state) == 1.145e+02);
+	CHECK(7, example_get_raw_level(state) == UINT64_C(46590));
+	CHECK(7, example_get_level(state) == 4.659e+02);
+	CHECK(7, example_get_flags_raw(state) == UINT64_C(18));
+	CHECK(7, example_get_ratio(state) == -0x1.89e1fp-51f);
+	CHECK(7, example_get_position(state) == 0x1.03cb44af864bap+107);
+	{
+		static const uint8_t data[6] = {0xd0, 0xea, 0xa5, 0x9f, 0x8e, 0x48};
+		uint8_t out[EXAMPLE_MESSAGE_BUFFER_BYTE_SIZE];
+		example_get_message_buffer(state, out);
+		CHECK(7, memcmp(out, data, sizeof(out)) == 0);
+	}
+	CHECK(7, example_get_counter(state) == UINT64_C(16194613440650274502));
+	CHECK(7, example_get_offset(state) == INT64_C(-5498944102256635767));
+	CHECK(7, example_get_raw_big_fixed(state) == INT64_C(52122354782015812));
+}
+
+static void check_vector_8(void) {
+	static const uint8_t expected[EXAMPLE_STATE_BYTE_SIZE] = {0xfe, 0x45, 0xd4, 0xa0, 0x01, 0x55, 0xb4, 0xa4, 0xb2, 0xe7, 0xda, 0xc7, 0xdf, 0x57, 0xb1, 0xae, 0xa3, 0xb4, 0xc3, 0xe3, 0xbf, 0xac, 0x0e, 0x79, 0x40, 0xce, 0x0a, 0xf8, 0x50, 0x61, 0xbd, 0x12, 0x10, 0x77, 0xcc, 0xd4, 0xe6, 0xc7, 0xa9, 0xfd, 0xeb, 0x89, 0x3a, 0x45, 0xd1, 0x52, 0x00};
+	uint8_t state[EXAMPLE_STATE_BYTE_SIZE];
+	example_init(state);
+	example_set_state_code(state, UINT64_C(3));
+	example_set_3bits_int(state, INT64_C(-1));
+	example_set_enabled(state, true);
+	example_set_temp(state, -1.769e+02);
+	example_set_level(state, 4.224e+02);
+	example_set_flags_raw(state, UINT64_C(2));
+	example_set_ratio(state, -0x1.d292cap-41f);
+	example_set_position(state, -0x1.58fbeaf635d47p+252);
+	{
+		static const uint8_t data[6] = {0x69, 0x87, 0xc7, 0x7f, 0x58, 0x18};
+		example_set_message_buffer(state, data, sizeof(data));
+	}
+	example_set_counter(state, UINT64_C(11407674492757219439));
+	example_set_offset(state, INT64_C(4937104021912138218));
+	example_set_raw_big_fixed(state, INT64_C(574118951065699656));
+	CHECK(8, memcmp(state, expected, EXAMPLE_STATE_BYTE_SIZE) == 0);
+	CHECK(8, example_get_state_code(state) == UINT64_C(3));
+	CHECK(8, example_get_3bits_int(state) == INT64_C(-1));
+	CHECK(8, example_get_enabled(state) == true);
+	CHECK(8, example_get_raw_temp(state) == INT64_C(-1769));
+	CHECK(8, example_get_temp(state) == -1.769e+02);
+	CHECK(8, example_get_raw_level(state) == UINT64_C(42240));
+	CHECK(8, example_get_level(state) == 4.224e+02);
+	CHECK(8, example_get_flags_raw(state) == UINT64_C(2));
+	CHECK(8, example_get_ratio(state) == -0x1.d292cap-41f);
+	CHECK(8, example_get_position(state) == -0x1.58fbeaf635d47p+252);
+	{
+		static const uint8_t data[6] = {0x69, 0x87, 0xc7, 0x7f, 0x58, 0x18};
+		uint8_t out[EXAMPLE_MESSAGE_BUFFER_BYTE_SIZE];
+		example_get_message_buffer(state, out);
+		CHECK(8, memcmp(out, data, sizeof(out)) == 0);
+	}
+	CHECK(8, example_get_counter(state) == UINT64_C(11407674492757219439));
+	CHECK(8, example_get_offset(state) == INT64_C(4937104021912138218));
+	CHECK(8, example_get_raw_big_fixed(state) == INT64_C(574118951065699656));
+}
+
+static void check_vector_9(void) {
+	static const uint8_t expected[EXAMPLE_STATE_BYTE_SIZE] = {0x07, 0xcd, 0x0d, 0x21, 0x56, 0xd0, 0x03, 0x1d, 0x27, 0xe3, 0x35, 0x9b, 0x6d, 0xe4, 0xd9, 0xa2, 0x74, 0xa9, 0x3a, 0x82, 0x2f, 0xc7, 0x7d, 0x75, 0x57, 0x2d, 0x1c, 0x65, 0x07, 0x94, 0xab, 0xa9, 0x3b, 0xbd, 0xe8, 0x9a, 0x51, 0xd8, 0xf4, 0x04, 0x35, 0x8c, 0x9a, 0xd0, 0x3a, 0xaf, 0x00};
+	uint8_t state[EXAMPLE_STATE_BYTE_SIZE];
+	example_init(state);
+	example_set_state_code(state, UINT64_C(0));
+	example_set_3bits_int(state, INT64_C(0));
+	example_set_enabled(state, true);
+	example_set_temp(state, -2.04e+01);
+	example_set_level(state, 2.689e+02);
+	example_set_flags_raw(state, UINT64_C(45));
+	example_set_ratio(state, -0x1.0c749ep-63f);
+	example_set_position(state, -0x1.b36dbc9b344e9p+103);
+	{
+		static const uint8_t data[6] = {0x52, 0x75, 0x04, 0x5f, 0x8e, 0xf8};
+		example_set_message_buffer(state, data, sizeof(data));
+	}
+	example_set_counter(state, UINT64_C(6725505124774569258));
+	example_set_offset(state, INT64_C(-1563048712738671043));
+	example_set_big_fixed(state, 4.739111663495868e+12);
+	CHECK(9, memcmp(state, expected, EXAMPLE_STATE_BYTE_SIZE) == 0);
+	CHECK(9, example_get_state_code(state) == UINT64_C(0));
+	CHECK(9, example_get_3bits_int(state) == INT64_C(0));
+	CHECK(9, example_get_enabled(state) == true);
+	CHECK(9, example_get_raw_temp(state) == INT64_C(-204));
+	CHECK(9, example_get_temp(state) == -2.04e+01);
+	CHECK(9, example_get_raw_level(state) == UINT64_C(26890));
+	CHECK(9, example_get_level(state) == 2.689e+02);
+	CHECK(9, example_get_flags_raw(state) == UINT64_C(45));
+	CHECK(9, example_get_ratio(state) == -0x1.0c749ep-63f);
+	CHECK(9, example_get_position(state) == -0x1.b36dbc9b344e9p+103);
+	{
+		static const uint8_t data[6] = {0x52, 0x75, 0x04, 0x5f, 0x8e, 0xf8};
+		uint8_t out[EXAMPLE_MESSAGE_BUFFER_BYTE_SIZE];
+		example_get_message_buffer(state, out);
+		CHECK(9, memcmp(out, data, sizeof(out)) == 0);
+	}
+	CHECK(9, example_get_counter(state) == UINT64_C(6725505124774569258));
+	CHECK(9, example_get_offset(state) == INT64_C(-1563048712738671043));
+	CHECK(9, example_get_raw_big_fixed(state) == INT64_C(4739111663495868));
+	CHECK(9, example_get_big_fixed(state) == 4.739111663495868e+12);
+}
+
+static void check_vector_10(void) {
+	static const uint8_t expected[EXAMPLE_STATE_BYTE_SIZE] = {0xd2, 0xe7, 0x2d, 0x1b, 0x90, 0x00, 0x3e, 0x63, 0x06, 0x40, 0x2e, 0x94, 0x94, 0x37, 0x80, 0x78, 0x15, 0xb4, 0xe9, 0x3e, 0x9c, 0x83, 0x4f, 0x2d, 0xfe, 0xbf, 0x0d, 0x51, 0x60, 0xdb, 0x13, 0x1e, 0x68, 0xaf, 0xe4, 0xc7, 0x59, 0x05, 0xae, 0x17, 0xcc, 0x95, 0xc4, 0xbd, 0x04, 0xa3, 0x40};
+	uint8_t state[EXAMPLE_STATE_BYTE_SIZE];
+	example_init(state);
+	example_set_state_code(state, UINT64_C(3));
+	example_set_3bits_int(state, INT64_C(2));
+	example_set_enabled(state, false);
+	example_set_temp(state, -1.124e+02);
+	example_set_level(state, 9.238e+02);
+	example_set_flags_raw(state, UINT64_C(32));
+	example_set_ratio(state, 0x1.f3183p-127f);
+	example_set_position(state, -0x1.d29286f00f02bp-1018);
+	{
+		static const uint8_t data[6] = {0x69, 0xd2, 0x7d, 0x39, 0x06, 0x98};
+		example_set_message_buffer(state, data, sizeof(data));
+	}
+	example_set_counter(state, UINT64_C(14663632165210175172));
+	example_set_offset(state, INT64_C(-4063887364465475221));
+	example_set_raw_big_fixed(state, INT64_C(-549665279954382195));
+	CHECK(10, memcmp(state, expected, EXAMPLE_STATE_BYTE_SIZE) == 0);
+	CHECK(10, example_get_state_code(state) == UINT64_C(3));
+	CHECK(10, example_get_3bits_int(state) == INT64_C(2));
+	CHECK(10, example_get_enabled(state) == false);
+	CHECK(10, example_get_raw_temp(state) == INT64_C(-1124));
+	CHECK(10, example_get_temp(state) == -1.124e+02);
+	CHECK(10, example_get_raw_level(state) == UINT64_C(92380));
+	CHECK(10, example_get_level(state) == 9.238e+02);
+	CHECK(10, example_get_flags_raw(state) == UINT64_C(32));
+	CHECK(10, example_get_ratio(state) == 0x1.f3183p-127f);
+	CHECK(10, example_get_position(state) == -0x1.d29286f00f02bp-1018);
+	{
+		static const uint8_t data[6] = {0x69, 0xd2, 0x7d, 0x39, 0x06, 0x98};
+		uint8_t out[EXAMPLE_MESSAGE_BUFFER_BYTE_SIZE];
+		example_get_message_buffer(state, out);
+		CHECK(10, memcmp(out, data, sizeof(out)) == 0);
+	}
+	CHECK(10, example_get_counter(state) == UINT64_C(14663632165210175172));
+	CHECK(10, example_get_offset(state) == INT64_C(-4063887364465475221));
+	CHECK(10, example_get_raw_big_fixed(state) == INT64_C(-549665279954382195));
+}
+
+static void check_vector_11(void) {
+	static const uint8_t expected[EXAMPLE_STATE_BYTE_SIZE] = {0xea, 0xa4, 0x3b, 0x11, 0x03, 0xd2, 0x25, 0xfc, 0xd6, 0x0f, 0xd6, 0x7e, 0x2e, 0xe0, 0xaa, 0x04, 0x32, 0x00, 0xd1, 0x1c, 0xd5, 0x45, 0x17, 0x2f, 0x6c, 0x61, 0x6d, 0xc2, 0xad, 0x4e, 0xeb, 0xa0, 0xf8, 0x52, 0x94, 0xe3, 0x4e, 0xd2, 0x52, 0x33, 0xec, 0x09, 0x21, 0xe3, 0x50, 0x18, 0xc0};
+	uint8_t state[EXAMPLE_STATE_BYTE_SIZE];
+	example_init(state);
+	example_set_state_code(state, UINT64_C(3));
+	example_set_3bits_int(state, INT64_C(-3));
+	example_set_enabled(state, false);
+	example_set_temp(state, -1.392e+02);
+	example_set_level(state, 1.20968e+03);
+	example_set_flags_raw(state, UINT64_C(7));
+	example_set_ratio(state, -0x1.97f358p-55f);
+	example_set_position(state, 0x1.cfc5dc1540864p-517);
+	{
+		static const uint8_t data[6] = {0x01, 0xa2, 0x39, 0xaa, 0x8a, 0x28};
+		example_set_message_buffer(state, data, sizeof(data));
+	}
+	example_set_counter(state, UINT64_C(14689361390610371514));
+	example_set_offset(state, INT64_C(-1711908108498652012));
+	example_set_raw_big_fixed(state, INT64_C(-518001761184825245));
+	CHECK(11, memcmp(state, expected, EXAMPLE_STATE_BYTE_SIZE) == 0);
+	CHECK(11, example_get_state_code(state) == UINT64_C(3));
+	CHECK(11, example_get_3bits_int(state) == INT64_C(-3));
+	CHECK(11, example_get_enabled(state) == false);
+	CHECK(11, example_get_raw_temp(state) == INT64_C(-1392));
+	CHECK(11, example_get_temp(state) == -1.392e+02);
+	CHECK(11, example_get_raw_level(state) == UINT64_C(120968));
+	CHECK(11, example_get_level(state) == 1.20968e+03);
+	CHECK(11, example_get_flags_raw(state) == UINT64_C(7));
+	CHECK(11, example_get_ratio(state) == -0x1.97f358p-55f);
+	CHECK(11, example_get_position(state) == 0x1.cfc5dc1540864p-517);
+	{
+		static const uint8_t data[6] = {0x01, 0xa2, 0x39, 0xaa, 0x8a, 0x28};
+		uint8_t out[EXAMPLE_MESSAGE_BUFFER_BYTE_SIZE];
+		example_get_message_buffer(state, out);
+		CHECK(11, memcmp(out, data, sizeof(out)) == 0);
+	}
+	CHECK(11, example_get_counter(state) == UINT64_C(14689361390610371514));
+	CHECK(11, example_get_offset(state) == INT64_C(-1711908108498652012));
+	CHECK(11, example_get_raw_big_fixed(state) == INT64_C(-518001761184825245));
+}
+
+static void check_vector_12(void) {
+	static const uint8_t expected[EXAMPLE_STATE_BYTE_SIZE] = {0x38, 0xea, 0x69, 0x68, 0x6c, 0x13, 0x52, 0xca, 0x32, 0x16, 0xc4, 0xe4, 0x10, 0x7a, 0xe1, 0xa9, 0xe7, 0xf6, 0x67, 0x2d, 0x1d, 0x5b, 0xce, 0x76, 0xb2, 0x6d, 0x60, 0xb4, 0x8c, 0x05, 0xdb, 0x41, 0x73, 0x89, 0x8f, 0x8b, 0x5a, 0xa7, 0x3b, 0xf2, 0xd8, 0x9b, 0x0f, 0xc7, 0x5d, 0x09, 0xc0};
+	uint8_t state[EXAMPLE_STATE_BYTE_SIZE];
+	example_init(state);
+	example_set_state_code(state, UINT64_C(0));
+	example_set_3bits_int(state, INT64_C(-1));
+	example_set_enabled(state, false);
+	example_set_temp(state, 9.37e+01);
+	example_set_level(state, 8.4803e+02);
+	example_set_flags_raw(state, UINT64_C(24));
+	example_set_ratio(state, 0x1.4b28c8p-50f);
+	example_set_position(state, 0x1.9c820f5c353cfp-295);
+	{
+		static const uint8_t data[6] = {0xec, 0xce, 0x5a, 0x3a, 0xb7, 0x98};
+		example_set_message_buffer(state, data, sizeof(data));
+	}
+	example_set_counter(state, UINT64_C(11361626762965614966));
+	example_set_offset(state, INT64_C(-3432619897327801906));
+	example_set_raw_big_fixed(state, INT64_C(-14809956710779865));
+	CHECK(12, memcmp(state, expected, EXAMPLE_STATE_BYTE_SIZE) == 0);
+	CHECK(12, example_get_state_code(state) == UINT64_C(0));
+	CHECK(12, example_get_3bits_int(state) == INT64_C(-1));
+	CHECK(12, example_get_enabled(state) == false);
+	CHECK(12, example_get_raw_temp(state) == INT64_C(937));
+	CHECK(12, example_get_temp(state) == 9.37e+01);
+	CHECK(12, example_get_raw_level(state) == UINT64_C(84803));
+	CHECK(12, example_get_level(state) == 8.4803e+02);
+	CHECK(12, example_get_flags_raw(state) == UINT64_C(24));
+	CHECK(12, example_get_ratio(state) == 0x1.4b28c8p-50f);
+	CHECK(12, example_get_position(state) == 0x1.9c820f5c353cfp-295);
+	{
+		static const uint8_t data[6] = {0xec, 0xce, 0x5a, 0x3a, 0xb7, 0x98};
+		uint8_t out[EXAMPLE_MESSAGE_BUFFER_BYTE_SIZE];
+		example_get_message_buffer(state, out);
+		CHECK(12, memcmp(out, data, sizeof(out)) == 0);
+	}
+	CHECK(12, example_get_counter(state) == UINT64_C(11361626762965614966));
+	CHECK(12, example_get_offset(state) == INT64_C(-3432619897327801906));
+	CHECK(12, example_get_raw_big_fixed(state) == INT64_C(-14809956710779865));
+}
+
+static void check_vector_13(void) {
+	static const uint8_t expected[EXAMPLE_STATE_BYTE_SIZE] = {0x2f, 0xce, 0x1d, 0xbf, 0x79, 0x4b, 0x56, 0x78, 0x3c, 0xda, 0x5a, 0x51, 0xe4, 0x09, 0xe9, 0xa3, 0x5a, 0xfa, 0x82, 0x67, 0xda, 0xbe, 0x60, 0xfb, 0xb3, 0xf6, 0xf1, 0xb6, 0xcc, 0x38, 0xdd, 0xea, 0x78, 0xef, 0x73, 0x70, 0x0a, 0xce, 0x41, 0x82, 0xbb, 0x7c, 0x69, 0xb8, 0x88, 0xbd, 0x40};
+	uint8_t state[EXAMPLE_STATE_BYTE_SIZE];
+	example_init(state);
+	example_set_state_code(state, UINT64_C(0));
+	example_set_3bits_int(state, INT64_C(-3));
+	example_set_enabled(state, true);
+	example_set_temp(state, -2e+01);
+	example_set_level(state, 6.0923e+02);
+	example_set_flags_raw(state, UINT64_C(50));
+	example_set_ratio(state, -0x1.59e0f2p-82f);
+	example_set_position(state, -0x1.4a3c813d346b5p-180);
+	{
+		static const uint8_t data[6] = {0xf5, 0x04, 0xcf, 0xb5, 0x7c, 0xc0};
+		example_set_message_buffer(state, data, sizeof(data));
+	}
+	example_set_counter(state, UINT64_C(4534277910591376951));
+	example_set_offset(state, INT64_C(8835565338717500304));
+	example_set_raw_big_fixed(state, INT64_C(435421936137413365));
+	CHECK(13, memcmp(state, expected, EXAMPLE_STATE_BYTE_SIZE) == 0);
+	CHECK(13, example_get_state_code(state) == UINT64_C(0));
+	CHECK(13, example_get_3bits_int(state) == INT64_C(-3));
+	CHECK(13, example_get_enabled(state) == true);
+	CHECK(13, example_get_raw_temp(state) == INT64_C(-200));
+	CHECK(13, example_get_temp(state) == -2e+01);
+	CHECK(13, example_get_raw_level(state) == UINT64_C(60923));
+	CHECK(13, example_get_level(state) == 6.0923e+02);
+	CHECK(13, example_get_flags_raw(state) == UINT64_C(50));
+	CHECK(13, example_get_ratio(state) == -0x1.59e0f2p-82f);
+	CHECK(13, example_get_position(state) == -0x1.4a3c813d346b5p-180);
+	{
+		static const uint8_t data[6] = {0xf5, 0x04, 0xcf, 0xb5, 0x7c, 0xc0};
+		uint8_t out[EXAMPLE_MESSAGE_BUFFER_BYTE_SIZE];
+		example_get_message_buffer(state, out);
+		CHECK(13, memcmp(out, data, sizeof(out)) == 0);
+	}
+	CHECK(13, example_get_counter(state) == UINT64_C(4534277910591376951));
+	CHECK(13, example_get_offset(state) == INT64_C(8835565338717500304));
+	CHECK(13, example_get_raw_big_fixed(state) == INT64_C(435421936137413365));
+}
+
+static void check_vector_14(void) {
+	static const uint8_t expected[EXAMPLE_STATE_BYTE_SIZE] = {0x3b, 0xcd, 0x06, 0x14, 0xab, 0xd3, 0xb7, 0xa0, 0xff, 0x46, 0x4b, 0xeb, 0x85, 0x09, 0x9e, 0x4b, 0x39, 0xe0, 0x70, 0x2a, 0x23, 0xfa, 0x5d, 0x0a, 0x6f, 0x48, 0xe9, 0x3b, 0xfb, 0xab, 0x74, 0x33, 0x0f, 0xc4, 0x38, 0x3c, 0x84, 0x95, 0x44, 0xdb, 0xe6, 0xb1, 0xc1, 0xc3, 0xc2, 0x45, 0x00};
+	uint8_t state[EXAMPLE_STATE_BYTE_SIZE];
+	example_init(state);
+	example_set_state_code(state, UINT64_C(0));
+	example_set_3bits_int(state, INT64_C(-1));
+	example_set_enabled(state, false);
+	example_set_temp(state, -2.04e+01);
+	example_set_level(state, 1.2453e+02);
+	example_set_flags_raw(state, UINT64_C(23));
+	example_set_ratio(state, -0x1.de83fcp-49f);
+	example_set_position(state, -0x1.7d70a133c9673p-822);
+	{
+		static const uint8_t data[6] = {0xc0, 0xe0, 0x54, 0x47, 0xf4, 0xb8};
+		example_set_message_buffer(state, data, sizeof(data));
+	}
+	example_set_counter(state, UINT64_C(4799660975768660701));
+	example_set_offset(state, INT64_C(919843791599379793));
+	example_set_raw_big_fixed(state, INT64_C(247586684136261908));
+	CHECK(14, memcmp(state, expected, EXAMPLE_STATE_BYTE_SIZE) == 0);
+	CHECK(14, example_get_state_code(state) == UINT64_C(0));
+	CHECK(14, example_get_3bits_int(state) == INT64_C(-1));
+	CHECK(14, example_get_enabled(state) == false);
+	CHECK(14, example_get_raw_temp(state) == INT64_C(-204));
+	CHECK(14, example_get_temp(state) == -2.04e+01);
+	CHECK(14, example_get_raw_level(state) == UINT64_C(12453));
+	CHECK(14, example_get_level(state) == 1.2453e+02);
+	CHECK(14, example_get_flags_raw(state) == UINT64_C(23));
+	CHECK(14, example_get_ratio(state) == -0x1.de83fcp-49f);
+	CHECK(14, example_get_position(state) == -0x1.7d70a133c9673p-822);
+	{
+		static const uint8_t data[6] = {0xc0, 0xe0, 0x54, 0x47, 0xf4, 0xb8};
+		uint8_t out[EXAMPLE_MESSAGE_BUFFER_BYTE_SIZE];
+		example_get_message_buffer(state, out);
+		CHECK(14, memcmp(out, data, sizeof(out)) == 0);
+	}
+	CHECK(14, example_get_counter(state) == UINT64_C(4799660975768660701));
+	CHECK(14, example_get_offset(state) == INT64_C(919843791599379793));
+	CHECK(14, example_get_raw_big_fixed(state) == INT64_C(247586684136261908));
+}
+
+static void check_vector_15(void) {
+	static const uint8_t expected[EXAMPLE_STATE_BYTE_SIZE] = {0xbd, 0x1f, 0xca, 0x52, 0xd8, 0xc9, 0x11, 0xaa, 0x87, 0x60, 0xd1, 0x6a, 0x94, 0x44, 0xa3, 0x79, 0x1e, 0x9d, 0xce, 0x53, 0xa0, 0x7c, 0x07, 0x4e, 0x5b, 0x49, 0x28, 0x0f, 0x1b, 0x66, 0x0b, 0x0c, 0x8b, 0x3b, 0x84, 0x0d, 0x95, 0xe4, 0x30, 0xef, 0xc7, 0xeb, 0xc3, 0x3d, 0x49, 0x45, 0xc0};
+	uint8_t state[EXAMPLE_STATE_BYTE_SIZE];
+	example_init(state);
+	example_set_state_code(state, UINT64_C(2));
+	example_set_3bits_int(state, INT64_C(-1));
+	example_set_enabled(state, true);
+	example_set_temp(state, 1.151e+02);
+	example_set_level(state, 2.1142e+02);
+	example_set_flags_raw(state, UINT64_C(49));
+	example_set_ratio(state, -0x1.46aa1cp-91f);
+	example_set_position(state, -0x1.2d5288946f23dp+27);
+	{
+		static const uint8_t data[6] = {0x3b, 0x9c, 0xa7, 0x40, 0xf8, 0x08};
+		example_set_message_buffer(state, data, sizeof(data));
+	}
+	example_set_counter(state, UINT64_C(15246604803842169218));
+	example_set_offset(state, INT64_C(-4385715621285496564));
+	example_set_raw_big_fixed(state, INT64_C(269969339362649367));
+	CHECK(15, memcmp(state, expected, EXAMPLE_STATE_BYTE_SIZE) == 0);
+	CHECK(15, example_get_state_code(state) == UINT64_C(2));
+	CHECK(15, example_get_3bits_int(state) == INT64_C(-1));
+	CHECK(15, example_get_enabled(state) == true);
+	CHECK(15, example_get_raw_temp(state) == INT64_C(1151));
+	CHECK(15, example_get_temp(state) == 1.151e+02);
+	CHECK(15, example_get_raw_level(state) == UINT64_C(21142));
+	CHECK(15, example_get_level(state) == 2.1142e+02);
+	CHECK(15, example_get_flags_raw(state) == UINT64_C(49));
+	CHECK(15, example_get_ratio(state) == -0x1.46aa1cp-91f);
+	CHECK(15, example_get_position(state) == -0x1.2d5288946f23dp+27);
+	{
+		static const uint8_t data[6] = {0x3b, 0x9c, 0xa7, 0x40, 0xf8, 0x08};
+		uint8_t out[EXAMPLE_MESSAGE_BUFFER_BYTE_SIZE];
+		example_get_message_buffer(state, out);
+		CHECK(15, memcmp(out, data, sizeof(out)) == 0);
+	}
+	CHECK(15, example_get_counter(state) == UINT64_C(15246604803842169218));
+	CHECK(15, example_get_offset(state) == INT64_C(-4385715621285496564));
+	CHECK(15, example_get_raw_big_fixed(state) == INT64_C(269969339362649367));
+}
+
+static void check_vector_16(void) {
+	static const uint8_t expected[EXAMPLE_STATE_BYTE_SIZE] = {0x63, 0xad, 0x23, 0x23, 0x15, 0xe9, 0xc9, 0x3f, 0xb0, 0xd6, 0x40, 0x6a, 0xc7, 0xf4, 0x5d, 0x47, 0x91, 0x49, 0xb3, 0x1d, 0x02, 0x2a, 0x5a, 0x81, 0x89, 0x31, 0x60, 0xec, 0x29, 0xfc, 0x80, 0x6e, 0xd5, 0x4e, 0x5e, 0xd2, 0x49, 0x90, 0xc5, 0xc2, 0x93, 0xd6, 0xb8, 0x5f, 0x00, 0xb5, 0x40};
+	uint8_t state[EXAMPLE_STATE_BYTE_SIZE];
+	example_init(state);
+	example_set_state_code(state, UINT64_C(1));
+	example_set_3bits_int(state, INT64_C(-4));
+	example_set_enabled(state, false);
+	example_set_temp(state, -3.32e+01);
+	example_set_level(state, 7.196e+02);
+	example_set_flags_raw(state, UINT64_C(43));
+	example_set_ratio(state, -0x1.24fec2p+40f);
+	example_set_position(state, -0x1.0d58fe8ba8f22p-311);
+	{
+		static const uint8_t data[6] = {0x93, 0x66, 0x3a, 0x04, 0x54, 0xb0};
+		example_set_message_buffer(state, data, sizeof(data));
+	}
+	example_set_counter(state, UINT64_C(11556883535617490720));
+	example_set_offset(state, INT64_C(1996593920843342897));
+	example_set_raw_big_fixed(state, INT64_C(507305159781253845));
+	CHECK(16, memcmp(state, expected, EXAMPLE_STATE_BYTE_SIZE) == 0);
+	CHECK(16, example_get_state_code(state) == UINT64_C(1));
+	CHECK(16, example_get_3bits_int(state) == INT64_C(-4));
+	CHECK(16, example_get_enabled(state) == false);
+	CHECK(16, example_get_raw_temp(state) == INT64_C(-332));
+	CHECK(16, example_get_temp(state) == -3.32e+01);
+	CHECK(16, example_get_raw_level(state) == UINT64_C(71960));
+	CHECK(16, example_get_level(state) == 7.196e+02);
+	CHECK(16, example_get_flags_raw(state) == UINT64_C(43));
+	CHECK(16, example_get_ratio(state) == -0x1.24fec2p+40f);
+	CHECK(16, example_get_position(state) == -0x1.0d58fe8ba8f22p-311);
+	{
+		static const uint8_t data[6] = {0x93, 0x66, 0x3a, 0x04, 0x54, 0xb0};
+		uint8_t out[EXAMPLE_MESSAGE_BUFFER_BYTE_SIZE];
+		example_get_message_buffer(state, out);
+		CHECK(16, memcmp(out, data, sizeof(out)) == 0);
+	}
+	CHECK(16, example_get_counter(state) == UINT64_C(11556883535617490720));
+	CHECK(16, example_get_offset(state) == INT64_C(1996593920843342897));
+	CHECK(16, example_get_raw_big_fixed(state) == INT64_C(507305159781253845));
+}
+
+int main(void) {
+	check_default();
+	check_vector_1();
+	check_vector_2();
+	check_vector_3();
+	check_vector_4();
+	check_vector_5();
+	check_vector_6();
+	check_vector_7();
+	check_vector_8();
+	check_vector_9();
+	check_vector_10();
+	check_vector_11();
+	check_vector_12();
+	check_vector_13();
+	check_vector_14();
+	check_vector_15();
+	check_vector_16();
+	printf("%d vectors, %d failures\n", 17, failures);
+	return failures == 0 ? 0 : 1;
+}
@@ -0,0 +1,196 @@
+package cgen
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+
+	"github.com/nayarsystems/bstates"
+)
+
+// CreateRandomStates returns n states of the schema with random values. The same seed always
+// produces the same states.
+func CreateRandomStates(schema *bstates.StateSchema, n int, seed int64) ([]*bstates.State, error) {
+	r := rand.New(rand.NewSource(seed))
+	states := make([]*bstates.State, 0, n)
+	for i := 0; i < n; i++ {
+		state, err := schema.CreateState()
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range schema.GetFields() {
+			var v any
+			switch f.Type {
+			case bstates.T_INT, bstates.T_FIXED:
+				// sign extend random bits
+				shift := 64 - f.Size
+				v = int64(r.Uint64()<<shift) >> shift
+			case bstates.T_UINT, bstates.T_UFIXED:
+				v = r.Uint64() >> (64 - f.Size)
+			case bstates.T_BOOL:
+				v = r.Intn(2) == 1
+			case bstates.T_FLOAT32:
+				f32 := float32(math.NaN())
+				for math.IsNaN(float64(f32)) || math.IsInf(float64(f32), 0) {
+					f32 = math.Float32frombits(r.Uint32())
+				}
+				v = f32
+			case bstates.T_FLOAT64:
+				f64 := math.NaN()
+				for math.IsNaN(f64) || math.IsInf(f64, 0) {
+					f64 = math.Float64frombits(r.Uint64())
+				}
+				v = f64
+			case bstates.T_BUFFER:
+				buf := make([]byte, (f.Size+7)/8)
+				r.Read(buf)
+				v = maskBuffer(buf, f.Size)
+			}
+			// Raw values are set on the frame to skip the conversion of fixed point fields
+			if err := state.Frame.Set(f.Name, v); err != nil {
+				return nil, fmt.Errorf("field \"%s\": %v", f.Name, err)
+			}
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// GenerateTestVectors returns a C program which includes the header generated by [GenerateHeader] and checks,
+// for every state, that the generated setters produce the same bytes as [bstates.State.Encode] and that the
+// generated getters return the values of the state. The program exits with status 0 if every check succeeds.
+//
+// The first vector is always the default state of the schema.
+func GenerateTestVectors(schema *bstates.StateSchema, states []*bstates.State, opts *Options) ([]byte, error) {
+	g, err := newGenerator(schema, opts)
+	if err != nil {
+		return nil, err
+	}
+	p, m := g.prefix, g.macro
+	w := &bytes.Buffer{}
+	fmt.Fprintf(w, "/* Code generated by bstates-cgen. DO NOT EDIT. */\n")
+	fmt.Fprintf(w, "/* Test vectors for schema %s */\n\n", schema.GetHashString())
+	fmt.Fprintf(w, "#include <stdio.h>\n\n#include %s\n\n", strconv.Quote(opts.headerName()))
+	fmt.Fprintf(w, "static int failures = 0;\n\n")
+	fmt.Fprintf(w, "#define CHECK(vector, cond) do { if (!(cond)) { failures++; printf(\"vector %%d: check failed: %%s\\n\", vector, #cond); } } while (0)\n\n")
+
+	fmt.Fprintf(w, "static void check_default(void) {\n")
+	fmt.Fprintf(w, "\tuint8_t state[%s_STATE_BYTE_SIZE];\n", m)
+	fmt.Fprintf(w, "\t%s_init(state);\n", p)
+	fmt.Fprintf(w, "\tCHECK(0, memcmp(state, %s_default_state, %s_STATE_BYTE_SIZE) == 0);\n}\n\n", p, m)
+
+	for i, state := range states {
+		if state.GetSchema().GetHashString() != schema.GetHashString() {
+			return nil, fmt.Errorf("state %d: schema mismatch", i)
+		}
+		encoded, err := state.Encode()
+		if err != nil {
+			return nil, fmt.Errorf("state %d: %v", i, err)
+		}
+		vector := i + 1
+		fmt.Fprintf(w, "static void check_vector_%d(void) {\n", vector)
+		fmt.Fprintf(w, "\tstatic const uint8_t expected[%s_STATE_BYTE_SIZE] = {%s};\n", m, cBytes(encoded))
+		fmt.Fprintf(w, "\tuint8_t state[%s_STATE_BYTE_SIZE];\n", m)
+		fmt.Fprintf(w, "\t%s_init(state);\n", p)
+		checks := &bytes.Buffer{}
+		for _, f := range g.fields {
+			raw, err := rawValue(state, f)
+			if err != nil {
+				return nil, fmt.Errorf("state %d: %v", i, err)
+			}
+			fn := p + "_%s_" + f.ident
+			switch f.Type {
+			case bstates.T_INT, bstates.T_UINT, bstates.T_BOOL, bstates.T_FLOAT32, bstates.T_FLOAT64:
+				lit := cLiteral(raw)
+				fmt.Fprintf(w, "\t"+fn+"(state, %s);\n", "set", lit)
+				fmt.Fprintf(checks, "\tCHECK(%d, "+fn+"(state) == %s);\n", vector, "get", lit)
+			case bstates.T_FIXED, bstates.T_UFIXED:
+				lit := cLiteral(raw)
+				fmt.Fprintf(checks, "\tCHECK(%d, "+fn+"(state) == %s);\n", vector, "get_raw", lit)
+				// Use the double setter when the value can be represented (as Go does it with State.Set)
+				var value float64
+				switch n := raw.(type) {
+				case int64:
+					value = float64(n) / f.factor()
+				case uint64:
+					value = float64(n) / f.factor()
+				}
+				if fixedRoundTrip(value, f) == raw {
+					dbl := cDouble(value)
+					fmt.Fprintf(w, "\t"+fn+"(state, %s);\n", "set", dbl)
+					fmt.Fprintf(checks, "\tCHECK(%d, "+fn+"(state) == %s);\n", vector, "get", dbl)
+				} else {
+					fmt.Fprintf(w, "\t"+fn+"(state, %s);\n", "set_raw", lit)
+				}
+			case bstates.T_BUFFER:
+				buf := maskBuffer(append([]byte{}, raw.([]byte)...), f.Size)
+				fmt.Fprintf(w, "\t{\n\t\tstatic const uint8_t data[%d] = {%s};\n", len(buf), cBytes(buf))
+				fmt.Fprintf(w, "\t\t"+fn+"(state, data, sizeof(data));\n\t}\n", "set")
+				fmt.Fprintf(checks, "\t{\n\t\tstatic const uint8_t data[%d] = {%s};\n", len(buf), cBytes(buf))
+				fmt.Fprintf(checks, "\t\tuint8_t out[%s_%s_BYTE_SIZE];\n", m, f.macro)
+				fmt.Fprintf(checks, "\t\t"+fn+"(state, out);\n", "get")
+				fmt.Fprintf(checks, "\t\tCHECK(%d, memcmp(out, data, sizeof(out)) == 0);\n\t}\n", vector)
+			}
+		}
+		fmt.Fprintf(w, "\tCHECK(%d, memcmp(state, expected, %s_STATE_BYTE_SIZE) == 0);\n", vector, m)
+		w.Write(checks.Bytes())
+		fmt.Fprintf(w, "}\n\n")
+	}
+
+	fmt.Fprintf(w, "int main(void) {\n\tcheck_default();\n")
+	for i := range states {
+		fmt.Fprintf(w, "\tcheck_vector_%d();\n", i+1)
+	}
+	fmt.Fprintf(w, "\tprintf(\"%%d vectors, %%d failures\\n\", %d, failures);\n", len(states)+1)
+	fmt.Fprintf(w, "\treturn failures == 0 ? 0 : 1;\n}\n")
+	return w.Bytes(), nil
+}
+
+// maskBuffer clears the bits of the last byte which are not part of a field of the given bit size.
+func maskBuffer(buf []byte, size int) []byte {
+	byteSize := (size + 7) / 8
+	if len(buf) > byteSize {
+		buf = buf[:byteSize]
+	}
+	for len(buf) < byteSize {
+		buf = append(buf, 0)
+	}
+	if size%8 != 0 {
+		buf[byteSize-1] &= 0xff << (8 - size%8)
+	}
+	return buf
+}
+
+// fixedRoundTrip returns the raw value State.Set stores for the fixed point value v.
+func fixedRoundTrip(v float64, f *cField) any {
+	r := math.Round(v * f.factor())
+	if f.Type == bstates.T_FIXED {
+		if r < math.MinInt64 || r >= math.MaxInt64 {
+			return nil
+		}
+		return int64(r)
+	}
+	if r < 0 || r >= math.MaxUint64 {
+		return nil
+	}
+	return uint64(r)
+}
+
+// cLiteral returns the C literal of a raw field value.
+func cLiteral(v any) string {
+	switch n := v.(type) {
+	case bool:
+		return strconv.FormatBool(n)
+	case int64:
+		return cInt64(n)
+	case uint64:
+		return cUint64(n)
+	case float32:
+		return strconv.FormatFloat(float64(n), 'x', -1, 32) + "f"
+	case float64:
+		return strconv.FormatFloat(n, 'x', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,137 @@
+package bstates
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+
+	"github.com/nayarsystems/buffer/buffer"
+)
+
+// ErrChecksumMismatch is returned when the checksum appended by [MOD_CRC32C] doesn't match the data.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// checksumSize is the size of the checksum appended by [ChecksumEnc].
+const checksumSize = crc32.Size
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ChecksumEnc returns a new buffer with the data of the provided buffer followed by its CRC32C
+// checksum (big endian).
+func ChecksumEnc(b *buffer.Buffer) (*buffer.Buffer, error) {
+	in := b.GetRawBuffer()[:b.GetByteSize()]
+	out := make([]byte, len(in)+checksumSize)
+	copy(out, in)
+	binary.BigEndian.PutUint32(out[len(in):], crc32.Checksum(in, crc32cTable))
+	outb := &buffer.Buffer{}
+	outb.InitFromRawBuffer(out)
+	return outb, nil
+}
+
+// ChecksumDec verifies the checksum appended by [ChecksumEnc] and returns a new buffer with the data
+// without it. It returns [ErrChecksumMismatch] if the data is corrupted.
+func ChecksumDec(b *buffer.Buffer) (*buffer.Buffer, error) {
+	in := b.GetRawBuffer()[:b.GetByteSize()]
+	if len(in) < checksumSize {
+		return nil, fmt.Errorf("%w: truncated data", ErrChecksumMismatch)
+	}
+	data := in[:len(in)-checksumSize]
+	if binary.BigEndian.Uint32(in[len(data):]) != crc32.Checksum(data, crc32cTable) {
+		return nil, ErrChecksumMismatch
+	}
+	out := make([]byte, len(data))
+	copy(out, data)
+	outb := &buffer.Buffer{}
+	outb.InitFromRawBuffer(out)
+	return outb, nil
+}
+
+// checksumWriter applies [ChecksumEnc] to the data written. The checksum is written on close.
+type checksumWriter struct {
+	w    io.Writer
+	hash hash.Hash32
+}
+
+func newChecksumWriter(w io.Writer) *checksumWriter {
+	return &checksumWriter{w: w, hash: crc32.New(crc32cTable)}
+}
+
+func (c *checksumWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.hash.Write(p[:n])
+	return n, err
+}
+
+func (c *checksumWriter) Flush() error {
+	return nil
+}
+
+func (c *checksumWriter) Close() error {
+	_, err := c.w.Write(c.hash.Sum(nil))
+	return err
+}
+
+// checksumReader applies [ChecksumDec] to the data read. The last bytes read are held back until
+// the end of the input, where they are verified as the checksum, so corruption is reported at the end
+// of the data instead of [io.EOF].
+type checksumReader struct {
+	r       io.Reader
+	hash    hash.Hash32
+	chunk   []byte
+	pending []byte // data read and not returned yet
+	checked bool
+	err     error
+}
+
+func newChecksumReader(r io.Reader) *checksumReader {
+	return &checksumReader{r: r, hash: crc32.New(crc32cTable), chunk: make([]byte, 4096)}
+}
+
+func (c *checksumReader) Read(p []byte) (int, error) {
+	for len(c.pending) <= checksumSize {
+		if c.err != nil {
+			if c.err == io.EOF && !c.checked {
+				c.checked = true
+				if len(c.pending) < checksumSize {
+					c.err = fmt.Errorf("%w: truncated data", ErrChecksumMismatch)
+				} else if binary.BigEndian.Uint32(c.pending) != c.hash.Sum32() {
+					c.err = ErrChecksumMismatch
+				}
+			}
+			return 0, c.err
+		}
+		n, err := c.r.Read(c.chunk)
+		c.pending = append(c.pending, c.chunk[:n]...)
+		c.err = err
+	}
+	n := copy(p, c.pending[:len(c.pending)-checksumSize])
+	c.hash.Write(p[:n])
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+// checksumModifier is the [MOD_CRC32C] modifier.
+type checksumModifier struct{}
+
+func (checksumModifier) VerifiesIntegrity() bool {
+	return true
+}
+
+func (checksumModifier) Encode(b *buffer.Buffer, schema *StateSchema, step PipelineStep) (*buffer.Buffer, error) {
+	return ChecksumEnc(b)
+}
+
+func (checksumModifier) Decode(b *buffer.Buffer, schema *StateSchema, step PipelineStep) (*buffer.Buffer, error) {
+	return ChecksumDec(b)
+}
+
+func (checksumModifier) NewWriter(w io.Writer, schema *StateSchema, step PipelineStep) (ModifierWriter, error) {
+	return newChecksumWriter(w), nil
+}
+
+func (checksumModifier) NewReader(r io.Reader, schema *StateSchema, step PipelineStep) (io.Reader, error) {
+	return newChecksumReader(r), nil
+}
@@ -0,0 +1,117 @@
+package bstates
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nayarsystems/buffer/buffer"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Checksum(t *testing.T) {
+	b := &buffer.Buffer{}
+	b.InitFromRawBuffer([]byte("123456789"))
+	eb, err := ChecksumEnc(b)
+	require.NoError(t, err)
+	// CRC32C check value
+	require.Equal(t, []byte{0xe3, 0x06, 0x92, 0x83}, eb.GetRawBuffer()[9:])
+	db, err := ChecksumDec(eb)
+	require.NoError(t, err)
+	require.Equal(t, b, db)
+
+	eb.GetRawBuffer()[0] ^= 0x80
+	_, err = ChecksumDec(eb)
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+}
+
+func Test_Checksum_Pipelines(t *testing.T) {
+	for _, pipeline := range []string{"crc32c", "t:zstd:crc32c", "crc32c:z", "d:crc32c:lz4"} {
+		schema := testPipelineComparativeCreateSchema(t, pipeline)
+		states := testCreateCounterStates(t, schema, 500)
+		q := CreateStateQueue(schema)
+		require.NoError(t, q.PushAll(states))
+		blob, err := q.Encode()
+		require.NoError(t, err)
+
+		dq := CreateStateQueue(schema)
+		require.NoError(t, dq.Decode(blob))
+		decoded, err := dq.GetStates()
+		require.NoError(t, err)
+		testEqualStates(t, states, decoded)
+
+		r, err := CreateQueueReader(schema, bytes.NewReader(blob))
+		require.NoError(t, err)
+		streamed := []*State{}
+		require.NoError(t, r.ForEach(func(state *State) error {
+			streamed = append(streamed, state)
+			return nil
+		}))
+		testEqualStates(t, states, streamed)
+
+		// Bit transposition and lz4 blocks can't be streamed
+		if pipeline == "t:zstd:crc32c" || pipeline == "d:crc32c:lz4" {
+			continue
+		}
+		out := &bytes.Buffer{}
+		w, err := CreateQueueWriter(schema, out)
+		require.NoError(t, err)
+		require.NoError(t, w.PushAll(states))
+		require.NoError(t, w.Close())
+		require.Equal(t, blob, out.Bytes(), pipeline)
+	}
+}
+
+func Test_Checksum_Corrupted(t *testing.T) {
+	schema := testPipelineComparativeCreateSchema(t, "crc32c")
+	states := testCreateCounterStates(t, schema, 50)
+	q := CreateStateQueue(schema)
+	require.NoError(t, q.PushAll(states))
+	blob, err := q.Encode()
+	require.NoError(t, err)
+
+	for _, i := range []int{0, len(blob) / 2, len(blob) - 1} {
+		corrupted := append([]byte{}, blob...)
+		corrupted[i] ^= 0x10
+		err = CreateStateQueue(schema).Decode(corrupted)
+		require.ErrorIs(t, err, ErrChecksumMismatch)
+
+		r, err := CreateQueueReader(schema, bytes.NewReader(corrupted))
+		require.NoError(t, err)
+		err = r.ForEach(func(state *State) error { return nil })
+		require.ErrorIs(t, err, ErrChecksumMismatch)
+	}
+
+	err = CreateStateQueue(schema).Decode(blob[:2])
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+	r, err := CreateQueueReader(schema, bytes.NewReader(blob[:len(blob)-1]))
+	require.NoError(t, err)
+	err = r.ForEach(func(state *State) error { return nil })
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+}
+
+func Test_Checksum_Empty(t *testing.T) {
+	schema := testPipelineComparativeCreateSchema(t, "crc32c")
+
+	// An empty payload has no checksum, so it isn't a valid empty queue
+	for _, data := range [][]byte{nil, {}} {
+		err := CreateStateQueue(schema).Decode(data)
+		require.ErrorIs(t, err, ErrChecksumMismatch)
+
+		r, err := CreateQueueReader(schema, bytes.NewReader(data))
+		require.NoError(t, err)
+		err = r.ForEach(func(state *State) error { return nil })
+		require.ErrorIs(t, err, ErrChecksumMismatch)
+	}
+
+	blob, err := CreateStateQueue(schema).Encode()
+	require.NoError(t, err)
+	q := CreateStateQueue(schema)
+	require.NoError(t, q.Decode(blob))
+	require.Equal(t, 0, q.GetNumStates())
+
+	r, err := CreateQueueReader(schema, bytes.NewReader(blob))
+	require.NoError(t, err)
+	n := 0
+	require.NoError(t, r.ForEach(func(state *State) error { n++; return nil }))
+	require.Equal(t, 0, n)
+}
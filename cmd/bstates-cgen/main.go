@@ -0,0 +1,71 @@
+// Command bstates-cgen generates a C header to encode states of a schema and, optionally, a C program with
+// test vectors generated by the Go implementation.
+//
+//	bstates-cgen -schema schema.json -prefix status -out firmware/ -vectors 32
+//
+// writes firmware/status.h and firmware/status_test.c. Build and run the test program with:
+//
+//	cc -std=c99 -o status_test firmware/status_test.c -lm && ./status_test
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nayarsystems/bstates"
+	"github.com/nayarsystems/bstates/cgen"
+)
+
+func main() {
+	schemaPath := flag.String("schema", "", "path of the schema (JSON)")
+	prefix := flag.String("prefix", cgen.DefaultPrefix, "prefix of the generated identifiers and files (invalid characters are replaced by '_')")
+	outDir := flag.String("out", ".", "output directory")
+	numVectors := flag.Int("vectors", 0, "number of random test vectors to generate (0: don't generate the test program)")
+	seed := flag.Int64("seed", 1, "seed used to generate the test vectors")
+	flag.Parse()
+
+	if err := run(*schemaPath, *prefix, *outDir, *numVectors, *seed); err != nil {
+		fmt.Fprintf(os.Stderr, "bstates-cgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(schemaPath, prefix, outDir string, numVectors int, seed int64) error {
+	if schemaPath == "" {
+		return fmt.Errorf("missing -schema")
+	}
+	raw, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return err
+	}
+	var schema bstates.StateSchema
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return fmt.Errorf("can't parse schema: %v", err)
+	}
+
+	// The file names use the same prefix as the identifiers, which can't escape outDir
+	prefix = cgen.CleanPrefix(prefix)
+	opts := &cgen.Options{Prefix: prefix}
+	header, err := cgen.GenerateHeader(&schema, opts)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(outDir, prefix+".h"), header, 0644); err != nil {
+		return err
+	}
+	if numVectors <= 0 {
+		return nil
+	}
+	states, err := cgen.CreateRandomStates(&schema, numVectors, seed)
+	if err != nil {
+		return err
+	}
+	vectors, err := cgen.GenerateTestVectors(&schema, states, opts)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outDir, prefix+"_test.c"), vectors, 0644)
+}
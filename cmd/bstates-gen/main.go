@@ -0,0 +1,54 @@
+// Command bstates-gen generates a Go file with typed accessors for the states of a schema.
+//
+//	bstates-gen -schema status.json -package status -type Status -out status_gen.go
+//
+// It can be used with go:generate:
+//
+//	//go:generate go run github.com/nayarsystems/bstates/cmd/bstates-gen -schema status.json -package status -type Status -out status_gen.go
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nayarsystems/bstates"
+	"github.com/nayarsystems/bstates/gogen"
+)
+
+func main() {
+	schemaPath := flag.String("schema", "", "path of the schema (JSON)")
+	pkg := flag.String("package", "", "package name of the generated file")
+	typeName := flag.String("type", "", "name of the generated type")
+	out := flag.String("out", "", "output file (default: standard output)")
+	flag.Parse()
+
+	if err := run(*schemaPath, *pkg, *typeName, *out); err != nil {
+		fmt.Fprintf(os.Stderr, "bstates-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(schemaPath, pkg, typeName, out string) error {
+	if schemaPath == "" {
+		return fmt.Errorf("missing -schema")
+	}
+	raw, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return err
+	}
+	var schema bstates.StateSchema
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return fmt.Errorf("can't parse schema: %v", err)
+	}
+	src, err := gogen.Generate(&schema, gogen.Options{Package: pkg, TypeName: typeName})
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0644)
+}
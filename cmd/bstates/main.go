@@ -0,0 +1,161 @@
+// Command bstates is a tool to work with bstates schemas and queues.
+//
+// The tune subcommand evaluates candidate encoder pipelines with a sample of states and recommends the one
+// with the smallest output. The sample is an encoded queue (-blob) or a JSON list of state values (-states):
+//
+//	bstates tune -schema schema.json -blob queue.bin
+//	bstates tune -schema schema.json -states states.json -candidate t:zstd -candidate "d:t:zstd(level=19)"
+//
+// The default candidates are [bstates.DefaultPipelineCandidates].
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nayarsystems/bstates"
+)
+
+const usage = `usage: bstates <command> [flags]
+
+commands:
+  tune    evaluate encoder pipelines with a sample of states
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "tune":
+		err = runTune(os.Args[2:], os.Stdout)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bstates %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+// stringList is a flag which can be repeated.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, " ")
+}
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+func runTune(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("tune", flag.ContinueOnError)
+	schemaPath := fs.String("schema", "", "path of the schema (JSON)")
+	blobPath := fs.String("blob", "", "path of a queue encoded with the schema")
+	statesPath := fs.String("states", "", "path of a JSON list of state values")
+	var candidates stringList
+	fs.Var(&candidates, "candidate", "candidate encoder pipeline (can be repeated)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *schemaPath == "" {
+		return fmt.Errorf("missing -schema")
+	}
+	raw, err := os.ReadFile(*schemaPath)
+	if err != nil {
+		return err
+	}
+	var schema bstates.StateSchema
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return fmt.Errorf("can't parse schema: %v", err)
+	}
+
+	var states []*bstates.State
+	switch {
+	case *blobPath != "" && *statesPath == "":
+		states, err = readBlob(&schema, *blobPath)
+	case *statesPath != "" && *blobPath == "":
+		states, err = readStates(&schema, *statesPath)
+	default:
+		return fmt.Errorf("one of -blob or -states is required")
+	}
+	if err != nil {
+		return err
+	}
+
+	report, err := bstates.EvaluatePipelines(&schema, states, candidates)
+	if report == nil {
+		return err
+	}
+	printReport(out, report)
+	return err
+}
+
+func readBlob(schema *bstates.StateSchema, path string) ([]*bstates.State, error) {
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	q := bstates.CreateStateQueue(schema)
+	if err := q.Decode(blob); err != nil {
+		return nil, fmt.Errorf("can't decode queue: %v", err)
+	}
+	return q.GetStates()
+}
+
+func readStates(schema *bstates.StateSchema, path string) ([]*bstates.State, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var values []map[string]any
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, fmt.Errorf("can't parse states: %v", err)
+	}
+	states := make([]*bstates.State, 0, len(values))
+	for i, v := range values {
+		state, err := schema.CreateState()
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range v {
+			if err := state.Set(name, value); err != nil {
+				return nil, fmt.Errorf("state %d: %v", i, err)
+			}
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+func printReport(out io.Writer, report *bstates.PipelineReport) {
+	fmt.Fprintf(out, "%d states, %d bytes\n\n", report.NumStates, report.RawSize)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PIPELINE\tSIZE\tRATIO\tENCODE\tDECODE")
+	for _, eval := range report.Evaluations {
+		pipeline := eval.Pipeline
+		if pipeline == "" {
+			pipeline = "(none)"
+		}
+		if eval.Err != nil {
+			fmt.Fprintf(w, "%s\terror: %v\n", pipeline, eval.Err)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%d\t%.2f\t%v\t%v\n", pipeline, eval.Size, eval.Ratio, eval.EncodeTime, eval.DecodeTime)
+	}
+	w.Flush()
+	if report.Recommended != "" {
+		fmt.Fprintf(out, "\nrecommended: \"%s\"\n", report.Recommended)
+	}
+}
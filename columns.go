@@ -0,0 +1,92 @@
+package bstates
+
+import (
+	"fmt"
+
+	"github.com/nayarsystems/buffer/buffer"
+)
+
+// ColumnsEnc lays out the states of the provided buffer field by field: the values of the first field of all
+// the states, then the values of the second field and so on, so values of the same field become adjacent.
+// The padding bits at the end of each state are moved as a last column, so the output has the same size as the
+// input and the data is preserved even if a previous modifier (e.g. [MOD_BITTRANS]) used them. The buffer must
+// hold whole states.
+func ColumnsEnc(b *buffer.Buffer, schema *StateSchema) (*buffer.Buffer, error) {
+	return columnsBuffer(b, schema, false)
+}
+
+// ColumnsDec reverts [ColumnsEnc] and returns a new buffer with the original states.
+func ColumnsDec(b *buffer.Buffer, schema *StateSchema) (*buffer.Buffer, error) {
+	return columnsBuffer(b, schema, true)
+}
+
+func columnsBuffer(b *buffer.Buffer, schema *StateSchema, decode bool) (*buffer.Buffer, error) {
+	rowSize := schema.GetByteSize()
+	in := b.GetRawBuffer()[:b.GetByteSize()]
+	if rowSize <= 0 || len(in)%rowSize != 0 {
+		return nil, fmt.Errorf("columns modifier: data size (%d) is not a multiple of the state size (%d)", len(in), rowSize)
+	}
+	numStates := len(in) / rowSize
+	src := &buffer.Buffer{}
+	src.InitFromRawBuffer(in)
+	dst := &buffer.Buffer{}
+	dst.InitFromRawBuffer(make([]byte, len(in)))
+	// Column sizes: the fields and the padding of the row
+	sizes := make([]int, 0, len(schema.fields)+1)
+	for _, f := range schema.fields {
+		sizes = append(sizes, f.Size)
+	}
+	if padding := rowSize*8 - schema.GetBitSize(); padding > 0 {
+		sizes = append(sizes, padding)
+	}
+	offset := 0
+	for _, size := range sizes {
+		for i := 0; i < numStates; i++ {
+			rowOffset := i*rowSize*8 + offset
+			colOffset := numStates*offset + i*size
+			var err error
+			if decode {
+				err = copyBits(dst, rowOffset, src, colOffset, size)
+			} else {
+				err = copyBits(dst, colOffset, src, rowOffset, size)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		offset += size
+	}
+	return dst, nil
+}
+
+// copyBits copies size bits from src at srcOffset to dst at dstOffset.
+func copyBits(dst *buffer.Buffer, dstOffset int, src *buffer.Buffer, srcOffset int, size int) error {
+	for size > 0 {
+		n := size
+		if n > 64 {
+			n = 64
+		}
+		v, err := src.GetBitsToUint64(srcOffset, n)
+		if err != nil {
+			return err
+		}
+		if err = dst.SetBitsFromUint64(dstOffset, v, n); err != nil {
+			return err
+		}
+		srcOffset += n
+		dstOffset += n
+		size -= n
+	}
+	return nil
+}
+
+// columnsModifier is the [MOD_COLUMNS] modifier.
+type columnsModifier struct{}
+
+func (columnsModifier) Encode(b *buffer.Buffer, schema *StateSchema, step PipelineStep) (*buffer.Buffer, error) {
+	return ColumnsEnc(b, schema)
+}
+
+func (columnsModifier) Decode(b *buffer.Buffer, schema *StateSchema, step PipelineStep) (*buffer.Buffer, error) {
+	return ColumnsDec(b, schema)
+}
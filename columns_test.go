@@ -0,0 +1,126 @@
+package bstates
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nayarsystems/buffer/buffer"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Columns(t *testing.T) {
+	schema, err := CreateStateSchema(&StateSchemaParams{
+		Fields: []StateField{
+			{Name: "A", Type: T_UINT, Size: 4},
+			{Name: "B", Type: T_UINT, Size: 8},
+		},
+	})
+	require.NoError(t, err)
+	b := &buffer.Buffer{}
+	b.InitFromRawBuffer([]byte{0x1a, 0xb0, 0x2c, 0xd0})
+	enc, err := ColumnsEnc(b, schema)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x12, 0xab, 0xcd, 0x00}, enc.GetRawBuffer())
+	dec, err := ColumnsDec(enc, schema)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x1a, 0xb0, 0x2c, 0xd0}, dec.GetRawBuffer())
+
+	// Padding bits are kept as the last column
+	b.InitFromRawBuffer([]byte{0x1a, 0xb5, 0x2c, 0xda})
+	enc, err = ColumnsEnc(b, schema)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x12, 0xab, 0xcd, 0x5a}, enc.GetRawBuffer())
+	dec, err = ColumnsDec(enc, schema)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x1a, 0xb5, 0x2c, 0xda}, dec.GetRawBuffer())
+
+	// Fields larger than 64 bits
+	schema = createDeltaTestSchema(t, "")
+	queue := CreateStateQueue(schema)
+	require.NoError(t, queue.PushAll(createDeltaTestStates(t, schema, 101)))
+	data, err := queue.Encode()
+	require.NoError(t, err)
+	b.InitFromRawBuffer(data)
+	enc, err = ColumnsEnc(b, schema)
+	require.NoError(t, err)
+	require.Len(t, enc.GetRawBuffer(), len(data))
+	dec, err = ColumnsDec(enc, schema)
+	require.NoError(t, err)
+	require.Equal(t, data, dec.GetRawBuffer())
+
+	// Data must hold whole states
+	b.InitFromRawBuffer(data[:len(data)-1])
+	_, err = ColumnsEnc(b, schema)
+	require.Error(t, err)
+}
+
+func Test_Columns_Pipelines(t *testing.T) {
+	for _, pipeline := range []string{"col", "col:z", "col:zstd", "d:col:zstd", "col:t:lz4", "t:col", "t:col:z"} {
+		schema := createDeltaTestSchema(t, pipeline)
+		states := createDeltaTestStates(t, schema, 300)
+		queue := CreateStateQueue(schema)
+		require.NoError(t, queue.PushAll(states))
+		data, err := queue.Encode()
+		require.NoError(t, err, pipeline)
+
+		dqueue := CreateStateQueue(schema)
+		require.NoError(t, dqueue.Decode(data), pipeline)
+		dstates, err := dqueue.GetStates()
+		require.NoError(t, err)
+		testEqualStates(t, states, dstates)
+
+		reader, err := CreateQueueReader(schema, bytes.NewReader(data))
+		require.NoError(t, err)
+		rstates := []*State{}
+		require.NoError(t, reader.ForEach(func(s *State) error {
+			rstates = append(rstates, s)
+			return nil
+		}), pipeline)
+		testEqualStates(t, states, rstates)
+	}
+
+	// Transposed data uses the padding bits of the states
+	for _, pipeline := range []string{"t:col", "t:col:z"} {
+		schema, err := CreateStateSchema(&StateSchemaParams{
+			EncoderPipeline: pipeline,
+			Fields:          []StateField{{Name: "A", Type: T_UINT, Size: 12}},
+		})
+		require.NoError(t, err)
+		queue := CreateStateQueue(schema)
+		states := []*State{}
+		for i := 0; i < 16; i++ {
+			state, err := schema.CreateState()
+			require.NoError(t, err)
+			require.NoError(t, state.Set("A", 0xfff-i*37))
+			states = append(states, state)
+		}
+		require.NoError(t, queue.PushAll(states))
+		data, err := queue.Encode()
+		require.NoError(t, err, pipeline)
+		dqueue := CreateStateQueue(schema)
+		require.NoError(t, dqueue.Decode(data), pipeline)
+		dstates, err := dqueue.GetStates()
+		require.NoError(t, err)
+		testEqualStates(t, states, dstates)
+	}
+
+	schema := createDeltaTestSchema(t, "col:zstd")
+	_, err := CreateQueueWriter(schema, &bytes.Buffer{})
+	require.ErrorIs(t, err, ErrStreamingNotSupported)
+}
+
+func Test_Columns_Compression(t *testing.T) {
+	schema := createDeltaTestSchema(t, "")
+	queue := CreateStateQueue(schema)
+	require.NoError(t, queue.PushAll(createDeltaTestStates(t, schema, 5000)))
+	sizes := map[string]int{}
+	for _, pipeline := range []string{"zstd", "col:zstd", "d:zstd", "d:col:zstd"} {
+		require.NoError(t, schema.setPipelines(pipeline))
+		data, err := queue.Encode()
+		require.NoError(t, err)
+		sizes[pipeline] = len(data)
+	}
+	t.Logf("sizes: %v", sizes)
+	require.Less(t, sizes["col:zstd"], sizes["zstd"])
+	require.Less(t, sizes["d:col:zstd"], sizes["d:zstd"])
+}
@@ -0,0 +1,487 @@
+package bstates
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaChangeType defines the kind of difference found between two schemas.
+type SchemaChangeType string
+
+const (
+	FieldAdded          SchemaChangeType = "fieldAdded"          // A field exists only in the new schema
+	FieldRemoved        SchemaChangeType = "fieldRemoved"        // A field exists only in the old schema
+	FieldRenamed        SchemaChangeType = "fieldRenamed"        // A field was matched through its aliases under a different name
+	FieldRetyped        SchemaChangeType = "fieldRetyped"        // The type of a field changed
+	FieldResized        SchemaChangeType = "fieldResized"        // The size (or decimals) of a field changed
+	FieldMoved          SchemaChangeType = "fieldMoved"          // The position of a field in the binary layout changed
+	FieldDefaultChanged SchemaChangeType = "fieldDefaultChanged" // The default value of a field changed
+	FieldAliasesChanged SchemaChangeType = "fieldAliasesChanged" // The aliases of a field changed
+	DecoderAdded        SchemaChangeType = "decoderAdded"        // A decoded field exists only in the new schema
+	DecoderRemoved      SchemaChangeType = "decoderRemoved"      // A decoded field exists only in the old schema
+	DecoderChanged      SchemaChangeType = "decoderChanged"      // The decoder type or params of a decoded field changed
+	IntMapAdded         SchemaChangeType = "intMapAdded"         // An int map exists only in the new schema
+	IntMapRemoved       SchemaChangeType = "intMapRemoved"       // An int map exists only in the old schema
+	IntMapEntryAdded    SchemaChangeType = "intMapEntryAdded"    // An int map entry exists only in the new schema
+	IntMapEntryRemoved  SchemaChangeType = "intMapEntryRemoved"  // An int map entry exists only in the old schema
+	IntMapEntryChanged  SchemaChangeType = "intMapEntryChanged"  // The value of an int map entry changed
+	RangeMapAdded       SchemaChangeType = "rangeMapAdded"       // A range map exists only in the new schema
+	RangeMapRemoved     SchemaChangeType = "rangeMapRemoved"     // A range map exists only in the old schema
+	RangeMapChanged     SchemaChangeType = "rangeMapChanged"     // The entries of a range map changed
+	PipelineChanged     SchemaChangeType = "pipelineChanged"     // The encoder pipeline changed
+	MetaChanged         SchemaChangeType = "metaChanged"         // The meta data changed
+	ZstdDictsChanged    SchemaChangeType = "zstdDictsChanged"    // The zstd dictionaries changed
+)
+
+// SchemaChange describes a single difference between two schemas.
+type SchemaChange struct {
+	Type     SchemaChangeType
+	Name     string // Name of the affected field, decoded field, int map or range map (empty for pipeline, dictionary and meta changes)
+	Old      any    // Old value, if any
+	New      any    // New value, if any
+	Breaking bool   // True if data or consumers built for the old schema are not compatible with the new one
+	Message  string // Human readable description
+}
+
+func (c SchemaChange) String() string {
+	kind := "compatible"
+	if c.Breaking {
+		kind = "breaking"
+	}
+	return fmt.Sprintf("[%s] %s", kind, c.Message)
+}
+
+// SchemaDiff is the result of [CompareSchemas].
+type SchemaDiff struct {
+	Changes []SchemaChange
+}
+
+// IsBreaking reports whether any of the changes is breaking.
+func (d *SchemaDiff) IsBreaking() bool {
+	for _, c := range d.Changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEmpty reports whether both schemas are equivalent.
+func (d *SchemaDiff) IsEmpty() bool {
+	return len(d.Changes) == 0
+}
+
+// GetBreakingChanges returns the list of breaking changes.
+func (d *SchemaDiff) GetBreakingChanges() []SchemaChange {
+	out := []SchemaChange{}
+	for _, c := range d.Changes {
+		if c.Breaking {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// String returns a report with one change per line.
+func (d *SchemaDiff) String() string {
+	lines := make([]string, 0, len(d.Changes))
+	for _, c := range d.Changes {
+		lines = append(lines, c.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CompareSchemas returns the differences between two schemas classifying each of them as
+// backward compatible or breaking.
+//
+// A change is breaking when a consumer reading values by name through the new schema could lose or misread
+// data produced with the old one: removed fields, decoders, int map entries or range maps, narrowing or lossy type
+// changes, renames which don't keep the old name as alias and changed decoders, int map values or range maps. Added
+// fields, decoders, int map entries and range maps, widened fields, moved fields, pipeline and zstd dictionary
+// changes are compatible since every blob is decoded with the schema it was encoded with (see [StateQueue.FromMsi]).
+func CompareSchemas(old, new *StateSchema) *SchemaDiff {
+	diff := &SchemaDiff{}
+	compareFields(diff, old, new)
+	compareDecodedFields(diff, old, new)
+	compareIntMaps(diff, old, new)
+	compareRangeMaps(diff, old, new)
+	if !reflect.DeepEqual(old.encoderPipeline, new.encoderPipeline) {
+		oldPipe := pipelineToString(old.encoderPipeline)
+		newPipe := pipelineToString(new.encoderPipeline)
+		diff.add(SchemaChange{
+			Type:    PipelineChanged,
+			Old:     oldPipe,
+			New:     newPipe,
+			Message: fmt.Sprintf("encoder pipeline changed from \"%s\" to \"%s\"", oldPipe, newPipe),
+		})
+	}
+	if !reflect.DeepEqual(old.zstdDicts, new.zstdDicts) {
+		diff.add(SchemaChange{
+			Type:    ZstdDictsChanged,
+			Message: "zstd dictionaries changed",
+		})
+	}
+	if !reflect.DeepEqual(old.meta, new.meta) {
+		diff.add(SchemaChange{
+			Type:    MetaChanged,
+			Old:     old.meta,
+			New:     new.meta,
+			Message: "meta data changed",
+		})
+	}
+	return diff
+}
+
+func (d *SchemaDiff) add(c SchemaChange) {
+	d.Changes = append(d.Changes, c)
+}
+
+func compareFields(diff *SchemaDiff, old, new *StateSchema) {
+	used := map[string]bool{}
+	oldIndex := map[string]int{}
+	for i, f := range old.fields {
+		oldIndex[f.Name] = i
+	}
+	lastOldIndex := -1
+	for newIndex, nf := range new.fields {
+		nf := nf
+		of := findMigrationSource(old, &nf, used)
+		if of == nil {
+			diff.add(SchemaChange{
+				Type:    FieldAdded,
+				Name:    nf.Name,
+				New:     nf.typeDesc(),
+				Message: fmt.Sprintf("field \"%s\" (%s) added", nf.Name, nf.typeDesc()),
+			})
+			continue
+		}
+		used[of.Name] = true
+		if of.Name != nf.Name {
+			// A rename is compatible as long as the old name is kept as alias
+			breaking := !containsString(nf.Aliases, of.Name)
+			diff.add(SchemaChange{
+				Type:     FieldRenamed,
+				Name:     nf.Name,
+				Old:      of.Name,
+				New:      nf.Name,
+				Breaking: breaking,
+				Message:  fmt.Sprintf("field \"%s\" renamed to \"%s\"", of.Name, nf.Name),
+			})
+		}
+		if of.Type != nf.Type {
+			diff.add(SchemaChange{
+				Type:     FieldRetyped,
+				Name:     nf.Name,
+				Old:      of.typeDesc(),
+				New:      nf.typeDesc(),
+				Breaking: !isWideningChange(of, &nf),
+				Message:  fmt.Sprintf("field \"%s\" type changed from %s to %s", nf.Name, of.typeDesc(), nf.typeDesc()),
+			})
+		} else if of.Size != nf.Size || of.Decimals != nf.Decimals {
+			diff.add(SchemaChange{
+				Type:     FieldResized,
+				Name:     nf.Name,
+				Old:      of.typeDesc(),
+				New:      nf.typeDesc(),
+				Breaking: !isWideningChange(of, &nf),
+				Message:  fmt.Sprintf("field \"%s\" resized from %s to %s", nf.Name, of.typeDesc(), nf.typeDesc()),
+			})
+		}
+		if oldIndex[of.Name] < lastOldIndex {
+			diff.add(SchemaChange{
+				Type:    FieldMoved,
+				Name:    nf.Name,
+				Old:     oldIndex[of.Name],
+				New:     newIndex,
+				Message: fmt.Sprintf("field \"%s\" moved from position %d to %d", nf.Name, oldIndex[of.Name], newIndex),
+			})
+		}
+		if oldIndex[of.Name] > lastOldIndex {
+			lastOldIndex = oldIndex[of.Name]
+		}
+		if of.Type == nf.Type && of.Size == nf.Size && fmt.Sprint(of.DefaultValue) != fmt.Sprint(nf.DefaultValue) {
+			diff.add(SchemaChange{
+				Type:    FieldDefaultChanged,
+				Name:    nf.Name,
+				Old:     of.DefaultValue,
+				New:     nf.DefaultValue,
+				Message: fmt.Sprintf("field \"%s\" default value changed from %v to %v", nf.Name, of.DefaultValue, nf.DefaultValue),
+			})
+		}
+		removedAliases, addedAliases := []string{}, []string{}
+		for _, alias := range of.Aliases {
+			if !containsString(nf.Aliases, alias) && alias != nf.Name {
+				removedAliases = append(removedAliases, alias)
+			}
+		}
+		for _, alias := range nf.Aliases {
+			if !containsString(of.Aliases, alias) && alias != of.Name {
+				addedAliases = append(addedAliases, alias)
+			}
+		}
+		if len(removedAliases) > 0 || len(addedAliases) > 0 {
+			diff.add(SchemaChange{
+				Type:     FieldAliasesChanged,
+				Name:     nf.Name,
+				Old:      of.Aliases,
+				New:      nf.Aliases,
+				Breaking: len(removedAliases) > 0,
+				Message:  fmt.Sprintf("field \"%s\" aliases changed from %v to %v", nf.Name, of.Aliases, nf.Aliases),
+			})
+		}
+	}
+	for _, of := range old.fields {
+		if used[of.Name] {
+			continue
+		}
+		diff.add(SchemaChange{
+			Type:     FieldRemoved,
+			Name:     of.Name,
+			Old:      of.typeDesc(),
+			Breaking: true,
+			Message:  fmt.Sprintf("field \"%s\" (%s) removed", of.Name, of.typeDesc()),
+		})
+	}
+}
+
+func compareDecodedFields(diff *SchemaDiff, old, new *StateSchema) {
+	for _, name := range sortedDecodedFieldNames(new) {
+		nf := new.decodedFields[name]
+		of, ok := old.decodedFields[name]
+		if !ok {
+			diff.add(SchemaChange{
+				Type:    DecoderAdded,
+				Name:    name,
+				New:     string(nf.Decoder.Name()),
+				Message: fmt.Sprintf("decoded field \"%s\" (%s) added", name, nf.Decoder.Name()),
+			})
+			continue
+		}
+		if of.Decoder.Name() != nf.Decoder.Name() || !reflect.DeepEqual(of.Decoder.GetParams(), nf.Decoder.GetParams()) {
+			diff.add(SchemaChange{
+				Type:     DecoderChanged,
+				Name:     name,
+				Old:      decoderDesc(of.Decoder),
+				New:      decoderDesc(nf.Decoder),
+				Breaking: true,
+				Message:  fmt.Sprintf("decoded field \"%s\" changed from %s to %s", name, decoderDesc(of.Decoder), decoderDesc(nf.Decoder)),
+			})
+		}
+	}
+	for _, name := range sortedDecodedFieldNames(old) {
+		if _, ok := new.decodedFields[name]; ok {
+			continue
+		}
+		of := old.decodedFields[name]
+		diff.add(SchemaChange{
+			Type:     DecoderRemoved,
+			Name:     name,
+			Old:      string(of.Decoder.Name()),
+			Breaking: true,
+			Message:  fmt.Sprintf("decoded field \"%s\" (%s) removed", name, of.Decoder.Name()),
+		})
+	}
+}
+
+func compareIntMaps(diff *SchemaDiff, old, new *StateSchema) {
+	for _, mapId := range sortedMapKeys(new.decoderIntMaps) {
+		nm := new.decoderIntMaps[mapId]
+		om, ok := old.decoderIntMaps[mapId]
+		if !ok {
+			diff.add(SchemaChange{
+				Type:    IntMapAdded,
+				Name:    mapId,
+				Message: fmt.Sprintf("int map \"%s\" added", mapId),
+			})
+			continue
+		}
+		for _, k := range sortedIntKeys(nm) {
+			ov, ok := om[k]
+			if !ok {
+				diff.add(SchemaChange{
+					Type:    IntMapEntryAdded,
+					Name:    mapId,
+					New:     k,
+					Message: fmt.Sprintf("int map \"%s\" entry %d (%v) added", mapId, k, nm[k]),
+				})
+			} else if !reflect.DeepEqual(ov, nm[k]) {
+				diff.add(SchemaChange{
+					Type:     IntMapEntryChanged,
+					Name:     mapId,
+					Old:      ov,
+					New:      nm[k],
+					Breaking: true,
+					Message:  fmt.Sprintf("int map \"%s\" entry %d changed from %v to %v", mapId, k, ov, nm[k]),
+				})
+			}
+		}
+		for _, k := range sortedIntKeys(om) {
+			if _, ok := nm[k]; !ok {
+				diff.add(SchemaChange{
+					Type:     IntMapEntryRemoved,
+					Name:     mapId,
+					Old:      k,
+					Breaking: true,
+					Message:  fmt.Sprintf("int map \"%s\" entry %d (%v) removed", mapId, k, om[k]),
+				})
+			}
+		}
+	}
+	for _, mapId := range sortedMapKeys(old.decoderIntMaps) {
+		if _, ok := new.decoderIntMaps[mapId]; !ok {
+			diff.add(SchemaChange{
+				Type:     IntMapRemoved,
+				Name:     mapId,
+				Breaking: true,
+				Message:  fmt.Sprintf("int map \"%s\" removed", mapId),
+			})
+		}
+	}
+}
+
+func compareRangeMaps(diff *SchemaDiff, old, new *StateSchema) {
+	for _, mapId := range sortedMapKeys(new.decoderRangeMaps) {
+		om, ok := old.decoderRangeMaps[mapId]
+		if !ok {
+			diff.add(SchemaChange{
+				Type:    RangeMapAdded,
+				Name:    mapId,
+				Message: fmt.Sprintf("range map \"%s\" added", mapId),
+			})
+		} else if nm := new.decoderRangeMaps[mapId]; !reflect.DeepEqual(om, nm) {
+			diff.add(SchemaChange{
+				Type:     RangeMapChanged,
+				Name:     mapId,
+				Old:      om,
+				New:      nm,
+				Breaking: true,
+				Message:  fmt.Sprintf("range map \"%s\" changed", mapId),
+			})
+		}
+	}
+	for _, mapId := range sortedMapKeys(old.decoderRangeMaps) {
+		if _, ok := new.decoderRangeMaps[mapId]; !ok {
+			diff.add(SchemaChange{
+				Type:     RangeMapRemoved,
+				Name:     mapId,
+				Breaking: true,
+				Message:  fmt.Sprintf("range map \"%s\" removed", mapId),
+			})
+		}
+	}
+}
+
+// isWideningChange reports whether every value of the old field can be represented exactly by the new one.
+func isWideningChange(of, nf *StateField) bool {
+	switch of.Type {
+	case T_INT:
+		switch nf.Type {
+		case T_INT:
+			return nf.Size >= of.Size
+		case T_FIXED:
+			return nf.Size >= of.Size+fixedPointExtraBits(nf.Decimals)
+		case T_FLOAT32:
+			return of.Size <= 24
+		case T_FLOAT64:
+			return of.Size <= 53
+		}
+	case T_UINT:
+		switch nf.Type {
+		case T_UINT:
+			return nf.Size >= of.Size
+		case T_INT:
+			return nf.Size > of.Size
+		case T_UFIXED:
+			return nf.Size >= of.Size+fixedPointExtraBits(nf.Decimals)
+		case T_FIXED:
+			return nf.Size > of.Size+fixedPointExtraBits(nf.Decimals)
+		case T_FLOAT32:
+			return of.Size <= 24
+		case T_FLOAT64:
+			return of.Size <= 53
+		}
+	case T_FIXED, T_UFIXED:
+		switch nf.Type {
+		case T_FIXED, T_UFIXED:
+			if nf.Decimals < of.Decimals || (of.Type == T_FIXED && nf.Type == T_UFIXED) {
+				return false
+			}
+			extra := fixedPointExtraBits(nf.Decimals - of.Decimals)
+			if of.Type == T_UFIXED && nf.Type == T_FIXED {
+				extra++
+			}
+			return nf.Size >= of.Size+extra
+		case T_FLOAT64:
+			return of.Size <= 53
+		}
+	case T_FLOAT32:
+		return nf.Type == T_FLOAT64
+	case T_BOOL:
+		switch nf.Type {
+		case T_INT:
+			return nf.Size >= 2
+		case T_UINT:
+			return true
+		}
+	case T_BUFFER:
+		return nf.Type == T_BUFFER && nf.Size >= of.Size
+	}
+	return false
+}
+
+// fixedPointExtraBits returns the number of extra bits needed to multiply a value by 10^decimals.
+func fixedPointExtraBits(decimals uint) int {
+	return int(math.Ceil(float64(decimals) * math.Log2(10)))
+}
+
+// typeDesc returns a short description of the field type and size, e.g. "uint:12" or "fixed:16.2".
+func (e *StateField) typeDesc() string {
+	switch e.Type {
+	case T_FIXED, T_UFIXED:
+		return fmt.Sprintf("%s:%d.%d", e.typeName(), e.Size, e.Decimals)
+	}
+	return fmt.Sprintf("%s:%d", e.typeName(), e.Size)
+}
+
+func decoderDesc(d Decoder) string {
+	return fmt.Sprintf("%s%v", d.Name(), d.GetParams())
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedDecodedFieldNames(s *StateSchema) []string {
+	names := make([]string, 0, len(s.decodedFields))
+	for name := range s.decodedFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedMapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedIntKeys(m map[int64]any) []int64 {
+	keys := make([]int64, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
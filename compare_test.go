@@ -0,0 +1,179 @@
+package bstates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CompareSchemas_Equal(t *testing.T) {
+	diff := CompareSchemas(createSchema(t), createSchema(t))
+	require.True(t, diff.IsEmpty())
+	require.False(t, diff.IsBreaking())
+	require.Equal(t, "", diff.String())
+}
+
+func Test_CompareSchemas_Compatible(t *testing.T) {
+	oldSchema := createSchema(t)
+
+	params := createSchemaParams(t)
+	params.EncoderPipeline = "t:zstd"
+	// Widen CHAR
+	params.Fields[1].Size = 16
+	// Rename keeping the old name as alias
+	params.Fields[2].Name = "FLAG"
+	params.Fields[2].Aliases = []string{"BOOL"}
+	// New field
+	params.Fields = append(params.Fields, StateField{Name: "NEW", Type: T_UINT, Size: 4})
+	// New int map entry
+	params.DecoderIntMaps["STATE_MAP"][3] = "PAUSED"
+	// New decoded field
+	params.DecodedFields = append(params.DecodedFields, DecodedStateField{
+		Name:    "NEW_FLAGS",
+		Decoder: &FlagsDecoder{From: "NEW", Flags: map[string]uint8{"A": 0}},
+	})
+	newSchema, err := CreateStateSchema(params)
+	require.NoError(t, err)
+
+	diff := CompareSchemas(oldSchema, newSchema)
+	require.False(t, diff.IsBreaking(), diff.String())
+	require.Empty(t, diff.GetBreakingChanges())
+
+	types := map[SchemaChangeType]SchemaChange{}
+	for _, c := range diff.Changes {
+		types[c.Type] = c
+	}
+	require.Len(t, types, 6, diff.String())
+	require.Equal(t, "t:zstd", types[PipelineChanged].New)
+	require.Equal(t, "CHAR", types[FieldResized].Name)
+	require.Equal(t, "int:8", types[FieldResized].Old)
+	require.Equal(t, "int:16", types[FieldResized].New)
+	require.Equal(t, "BOOL", types[FieldRenamed].Old)
+	require.Equal(t, "FLAG", types[FieldRenamed].New)
+	require.Equal(t, "NEW", types[FieldAdded].Name)
+	require.Equal(t, "STATE_MAP", types[IntMapEntryAdded].Name)
+	require.Equal(t, "NEW_FLAGS", types[DecoderAdded].Name)
+}
+
+func Test_CompareSchemas_Breaking(t *testing.T) {
+	oldSchema := createSchema(t)
+
+	params := createSchemaParams(t)
+	// Narrow CHAR
+	params.Fields[1].Size = 4
+	// Retype STATE_CODE
+	params.Fields[0].Type = T_BOOL
+	// Rename without alias
+	params.Fields[2].Name = "FLAG"
+	// Changed int map entry
+	params.DecoderIntMaps["STATE_MAP"][2] = "RUN"
+	// Changed decoder params
+	params.DecodedFields[2].Decoder = &NumberToUnixTsMsDecoder{
+		From:   "48BIT_SECS_FROM_2022",
+		Year:   2023,
+		Factor: 1000,
+	}
+	// Removed decoded field
+	params.DecodedFields = params.DecodedFields[1:]
+	newSchema, err := CreateStateSchema(params)
+	require.NoError(t, err)
+
+	diff := CompareSchemas(oldSchema, newSchema)
+	require.True(t, diff.IsBreaking())
+
+	breaking := map[SchemaChangeType]SchemaChange{}
+	for _, c := range diff.GetBreakingChanges() {
+		breaking[c.Type] = c
+	}
+	require.Equal(t, "CHAR", breaking[FieldResized].Name)
+	require.Equal(t, "STATE_CODE", breaking[FieldRetyped].Name)
+	require.Equal(t, "BOOL", breaking[FieldRemoved].Name)
+	// A field renamed without alias can't be matched, so it is reported as removed + added
+	require.NotContains(t, breaking, FieldRenamed)
+	for _, c := range diff.Changes {
+		if c.Type == FieldAdded {
+			require.Equal(t, "FLAG", c.Name)
+		}
+	}
+	require.Equal(t, "STATE_MAP", breaking[IntMapEntryChanged].Name)
+	require.Equal(t, "TIMESTAMP_MS", breaking[DecoderChanged].Name)
+	require.Equal(t, "MESSAGE", breaking[DecoderRemoved].Name)
+}
+
+func Test_CompareSchemas_Moved(t *testing.T) {
+	oldSchema := createSchema(t)
+
+	params := createSchemaParams(t)
+	params.Fields[0], params.Fields[1] = params.Fields[1], params.Fields[0]
+	delete(params.DecoderIntMaps, "STATE_MAP")
+	params.DecoderIntMaps["OTHER_MAP"] = map[int64]any{0: "A"}
+	params.Meta = map[string]any{"version": 2}
+	newSchema, err := CreateStateSchema(params)
+	require.NoError(t, err)
+
+	diff := CompareSchemas(oldSchema, newSchema)
+	changes := map[SchemaChangeType]SchemaChange{}
+	for _, c := range diff.Changes {
+		changes[c.Type] = c
+	}
+	require.Equal(t, "STATE_CODE", changes[FieldMoved].Name)
+	require.False(t, changes[FieldMoved].Breaking)
+	require.Equal(t, "OTHER_MAP", changes[IntMapAdded].Name)
+	require.Equal(t, "STATE_MAP", changes[IntMapRemoved].Name)
+	require.True(t, changes[IntMapRemoved].Breaking)
+	require.Contains(t, changes, MetaChanged)
+}
+
+func Test_IsWideningChange(t *testing.T) {
+	cases := []struct {
+		of, nf   StateField
+		widening bool
+	}{
+		{StateField{Type: T_INT, Size: 8}, StateField{Type: T_INT, Size: 9}, true},
+		{StateField{Type: T_INT, Size: 8}, StateField{Type: T_UINT, Size: 64}, false},
+		{StateField{Type: T_UINT, Size: 8}, StateField{Type: T_INT, Size: 8}, false},
+		{StateField{Type: T_UINT, Size: 8}, StateField{Type: T_INT, Size: 9}, true},
+		{StateField{Type: T_UINT, Size: 8}, StateField{Type: T_UFIXED, Size: 12, Decimals: 1}, true},
+		{StateField{Type: T_UINT, Size: 8}, StateField{Type: T_UFIXED, Size: 11, Decimals: 1}, false},
+		{StateField{Type: T_FIXED, Size: 8, Decimals: 1}, StateField{Type: T_FIXED, Size: 15, Decimals: 3}, true},
+		{StateField{Type: T_FIXED, Size: 8, Decimals: 2}, StateField{Type: T_FIXED, Size: 16, Decimals: 1}, false},
+		{StateField{Type: T_UINT, Size: 32}, StateField{Type: T_FLOAT64}, true},
+		{StateField{Type: T_UINT, Size: 64}, StateField{Type: T_FLOAT64}, false},
+		{StateField{Type: T_FLOAT32}, StateField{Type: T_FLOAT64}, true},
+		{StateField{Type: T_BUFFER, Size: 8}, StateField{Type: T_BUFFER, Size: 16}, true},
+		{StateField{Type: T_BUFFER, Size: 16}, StateField{Type: T_BUFFER, Size: 8}, false},
+	}
+	for i, c := range cases {
+		require.Equal(t, c.widening, isWideningChange(&c.of, &c.nf), "case %d", i)
+	}
+}
+
+func Test_CompareSchemas_RangeMaps(t *testing.T) {
+	params := createSchemaParams(t)
+	params.DecoderRangeMaps = map[string][]RangeMapEntry{
+		"A_MAP": {{Min: 0, Max: 10, Label: "LOW"}},
+		"B_MAP": {{Min: 0, Max: 10, Label: "LOW"}},
+	}
+	oldSchema, err := CreateStateSchema(params)
+	require.NoError(t, err)
+
+	params.DecoderRangeMaps = map[string][]RangeMapEntry{
+		"A_MAP": {{Min: 0, Max: 5, Label: "LOW"}},
+		"C_MAP": {{Min: 0, Max: 10, Label: "LOW"}},
+	}
+	newSchema, err := CreateStateSchema(params)
+	require.NoError(t, err)
+
+	diff := CompareSchemas(oldSchema, newSchema)
+	require.Len(t, diff.Changes, 3)
+	changes := map[SchemaChangeType]SchemaChange{}
+	for _, c := range diff.Changes {
+		changes[c.Type] = c
+	}
+	require.Equal(t, "A_MAP", changes[RangeMapChanged].Name)
+	require.True(t, changes[RangeMapChanged].Breaking)
+	require.Equal(t, "C_MAP", changes[RangeMapAdded].Name)
+	require.False(t, changes[RangeMapAdded].Breaking)
+	require.Equal(t, "B_MAP", changes[RangeMapRemoved].Name)
+	require.True(t, changes[RangeMapRemoved].Breaking)
+}
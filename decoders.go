@@ -3,6 +3,10 @@ package bstates
 import (
 	"errors"
 	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/jaracil/ei"
@@ -11,12 +15,18 @@ import (
 // FieldDecoderType defines the type for different field decoder names.
 type FieldDecoderType string
 
-// Implemented decoders are: [BufferToStringDecoder], [NumberToUnixTsMsDecoder], [IntMapDecoder] and [FlagsDecoder].
+// Implemented decoders are: [BufferToStringDecoder], [NumberToUnixTsMsDecoder], [IntMapDecoder], [FlagsDecoder],
+// [LinearDecoder], [RangeMapDecoder], [BitSliceDecoder], [ConcatDecoder] and [GeoPointDecoder].
 const (
 	BufferToStringDecoderType   FieldDecoderType = "BufferToString"
 	NumberToUnixTsMsDecoderType FieldDecoderType = "NumberToUnixTsMs"
 	IntMapDecoderType           FieldDecoderType = "IntMap"
 	FlagsDecoderType            FieldDecoderType = "Flags"
+	LinearDecoderType           FieldDecoderType = "Linear"
+	RangeMapDecoderType         FieldDecoderType = "RangeMap"
+	BitSliceDecoderType         FieldDecoderType = "BitSlice"
+	ConcatDecoderType           FieldDecoderType = "Concat"
+	GeoPointDecoderType         FieldDecoderType = "GeoPoint"
 )
 
 // Decoder is an interface that defines how to decode or transform state information. They
@@ -50,6 +60,16 @@ func NewDecoder(dtype string, params map[string]any) (d Decoder, err error) {
 		d, err = NewNumberToUnixTsMsDecoder(params)
 	case FlagsDecoderType:
 		d, err = NewFlagsDecoder(params)
+	case LinearDecoderType:
+		d, err = NewLinearDecoder(params)
+	case RangeMapDecoderType:
+		d, err = NewRangeMapDecoder(params)
+	case BitSliceDecoderType:
+		d, err = NewBitSliceDecoder(params)
+	case ConcatDecoderType:
+		d, err = NewConcatDecoder(params)
+	case GeoPointDecoderType:
+		d, err = NewGeoPointDecoder(params)
 	default:
 		err = fmt.Errorf("unknown decoder \"%s\"", dtype)
 	}
@@ -90,30 +110,36 @@ func (d *BufferToStringDecoder) Decode(s *State) (any, error) {
 	if err != nil {
 		return nil, err
 	}
-	i := 0
-	for ; i < len(fromValue); i++ {
-		if fromValue[i] == 0 {
-			break
-		}
-	}
-	return string(fromValue[:i]), nil
+	return bufferToString(fromValue), nil
 }
 
 func (d *BufferToStringDecoder) Encode(s *State, v any) error {
 	return s.Set(d.From, []byte(v.(string)))
 }
 
+// ErrUnknownLabel is returned by [IntMapDecoder.Encode] when the value is not in the map.
+var ErrUnknownLabel = errors.New("unknown label")
+
+// ErrAmbiguousLabel is returned by [IntMapDecoder.Encode] when the value is mapped from several integers.
+var ErrAmbiguousLabel = errors.New("ambiguous label")
+
 // IntMapDecoder implements a [Decoder] which decodes an integer value into a string based on a mapping defined
 // in the State object.
+//
+// Encoding looks up the integer mapped to the value, which must be unique in the map.
 type IntMapDecoder struct {
-	From  string // "from" parameter: name of the encoded field as defined in StateSchema.Fields
-	MapId string // "mapId" parameter: name of the map as defined in the StateSchema.DecoderIntMaps
+	From         string // "from" parameter: name of the encoded field as defined in StateSchema.Fields
+	MapId        string // "mapId" parameter: name of the map as defined in the StateSchema.DecoderIntMaps
+	UnknownLabel string // "unknownLabel" parameter (optional): value decoded for integers not in the map ("UNKNOWN" by default)
 }
 
 func (d *IntMapDecoder) GetParams() map[string]any {
 	m := map[string]any{}
 	m["from"] = d.From
 	m["mapId"] = d.MapId
+	if d.UnknownLabel != "" {
+		m["unknownLabel"] = d.UnknownLabel
+	}
 	return m
 }
 
@@ -127,6 +153,12 @@ func NewIntMapDecoder(params map[string]any) (d *IntMapDecoder, err error) {
 	if err != nil {
 		return nil, err
 	}
+	if _, ok := params["unknownLabel"]; ok {
+		d.UnknownLabel, err = ei.N(params).M("unknownLabel").String()
+		if err != nil {
+			return nil, fmt.Errorf("\"unknownLabel\" field error: %v", err)
+		}
+	}
 	return
 }
 
@@ -149,15 +181,134 @@ func (d *IntMapDecoder) Decode(s *State) (any, error) {
 	}
 	toValue, ok := intMap[fromValue]
 	if !ok {
-		//return nil, fmt.Errorf("value \"%d\" not in map", fromValue)
-		return "UNKNOWN", nil
+		return d.getUnknownLabel(), nil
 	}
 	return toValue, nil
 }
 
 func (d *IntMapDecoder) Encode(s *State, v any) error {
+	intMap, ok := s.schema.decoderIntMaps[d.MapId]
+	if !ok {
+		return fmt.Errorf("map \"%s\" not found", d.MapId)
+	}
+	keys := []int64{}
+	for k, label := range intMap {
+		if intMapLabelEqual(label, v) {
+			keys = append(keys, k)
+		}
+	}
+	switch len(keys) {
+	case 0:
+		return fmt.Errorf("%w: \"%v\" not in map \"%s\"", ErrUnknownLabel, v, d.MapId)
+	case 1:
+		return s.Set(d.From, keys[0])
+	default:
+		sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+		return fmt.Errorf("%w: \"%v\" is mapped from %v in map \"%s\"", ErrAmbiguousLabel, v, keys, d.MapId)
+	}
+}
+
+func (d *IntMapDecoder) getUnknownLabel() string {
+	return unknownLabel(d.UnknownLabel)
+}
+
+// defaultUnknownLabel is the value decoded by the map decoders for values which are not mapped.
+const defaultUnknownLabel = "UNKNOWN"
+
+// unknownLabel returns the label configured by a map decoder for unmapped values, or
+// [defaultUnknownLabel] if it isn't set.
+func unknownLabel(label string) string {
+	if label != "" {
+		return label
+	}
+	return defaultUnknownLabel
+}
+
+// intMapLabelEqual reports whether a value of an int map is equal to v. Numbers are compared by value,
+// regardless of their type (int maps parsed from JSON hold float64 numbers).
+func intMapLabelEqual(label, v any) bool {
+	if reflect.DeepEqual(label, v) {
+		return true
+	}
+	if !isNumber(label) || !isNumber(v) {
+		return false
+	}
+	return ei.N(label).Float64Z() == ei.N(v).Float64Z()
+}
+
+func isNumber(v any) bool {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return true
+	}
+	return false
+}
+
+// RangeMapDecoder implements a [Decoder] which classifies a numeric value into the label of the range which
+// contains it, based on a range map defined in the State object (see [RangeMapEntry]).
+type RangeMapDecoder struct {
+	From         string // "from" parameter: name of the encoded field as defined in StateSchema.Fields
+	MapId        string // "mapId" parameter: name of the map as defined in the StateSchema.DecoderRangeMaps
+	UnknownLabel string // "unknownLabel" parameter (optional): value decoded for values not in any range ("UNKNOWN" by default)
+}
+
+func NewRangeMapDecoder(params map[string]any) (d *RangeMapDecoder, err error) {
+	d = &RangeMapDecoder{}
+	d.From, err = ei.N(params).M("from").String()
+	if err != nil {
+		return nil, err
+	}
+	d.MapId, err = ei.N(params).M("mapId").String()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := params["unknownLabel"]; ok {
+		d.UnknownLabel, err = ei.N(params).M("unknownLabel").String()
+		if err != nil {
+			return nil, fmt.Errorf("\"unknownLabel\" field error: %v", err)
+		}
+	}
+	return
+}
+
+func (d *RangeMapDecoder) Name() FieldDecoderType {
+	return RangeMapDecoderType
+}
+
+func (d *RangeMapDecoder) GetParams() map[string]any {
+	m := map[string]any{}
+	m["from"] = d.From
+	m["mapId"] = d.MapId
+	if d.UnknownLabel != "" {
+		m["unknownLabel"] = d.UnknownLabel
+	}
+	return m
+}
+
+func (d *RangeMapDecoder) Decode(s *State) (any, error) {
+	fromValueI, err := s.Get(d.From)
+	if err != nil {
+		return nil, err
+	}
+	fromValue, err := ei.N(fromValueI).Float64()
+	if err != nil {
+		return nil, err
+	}
+	entries, ok := s.schema.decoderRangeMaps[d.MapId]
+	if !ok {
+		return nil, fmt.Errorf("range map \"%s\" not found", d.MapId)
+	}
+	// Entries are sorted and don't overlap
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].Max > fromValue })
+	if i < len(entries) && entries[i].Contains(fromValue) {
+		return entries[i].Label, nil
+	}
+	return unknownLabel(d.UnknownLabel), nil
+}
+
+func (d *RangeMapDecoder) Encode(s *State, v any) error {
 	// This is a read-only decoder
-	return errors.New("IntMapDecoder is a read-only decoder (can't encode)")
+	return errors.New("RangeMapDecoder is a read-only decoder (can't encode)")
 }
 
 // NumberToUnixTsMsDecoder implements a [Decoder] which decodes a numeric value using the following formula:
@@ -338,3 +489,510 @@ func (d *FlagsDecoder) Encode(s *State, v any) error {
 	}
 	return s.Set(d.From, fromValue)
 }
+
+// LinearDecoder implements a [Decoder] which converts a raw numeric value (e.g. ADC counts) into engineering units
+// using the following formula:
+//
+// decodedValue = valueToDecode*scale + offset
+//
+// The decoded value is a float64, rounded to the provided number of decimals if the "decimals" parameter is set.
+// Encoding applies the inverse formula (rounding to the nearest integer for integer fields) and checks that the
+// result fits in the field.
+type LinearDecoder struct {
+	From     string  // "from" parameter: name of the encoded field as defined in StateSchema.Fields
+	Scale    float64 // "scale" parameter: must be != 0
+	Offset   float64 // "offset" parameter (optional)
+	Unit     string  // "unit" parameter (optional): unit of the decoded value, informative only
+	Decimals int     // "decimals" parameter (optional): number of decimals of the decoded value, -1 to not round
+}
+
+func NewLinearDecoder(params map[string]any) (d *LinearDecoder, err error) {
+	d = &LinearDecoder{Decimals: -1}
+	d.From, err = ei.N(params).M("from").String()
+	if err != nil {
+		return nil, fmt.Errorf("\"from\" field error: %v", err)
+	}
+	d.Scale, err = ei.N(params).M("scale").Float64()
+	if err != nil {
+		return nil, fmt.Errorf("\"scale\" field error: %v", err)
+	}
+	if d.Scale == 0 || math.IsInf(d.Scale, 0) || math.IsNaN(d.Scale) {
+		return nil, fmt.Errorf("\"scale\" must be a finite number != 0")
+	}
+	if _, ok := params["offset"]; ok {
+		d.Offset, err = ei.N(params).M("offset").Float64()
+		if err != nil {
+			return nil, fmt.Errorf("\"offset\" field error: %v", err)
+		}
+		if math.IsInf(d.Offset, 0) || math.IsNaN(d.Offset) {
+			return nil, fmt.Errorf("\"offset\" must be a finite number")
+		}
+	}
+	if _, ok := params["unit"]; ok {
+		d.Unit, err = ei.N(params).M("unit").String()
+		if err != nil {
+			return nil, fmt.Errorf("\"unit\" field error: %v", err)
+		}
+	}
+	if _, ok := params["decimals"]; ok {
+		d.Decimals, err = ei.N(params).M("decimals").Int()
+		if err != nil {
+			return nil, fmt.Errorf("\"decimals\" field error: %v", err)
+		}
+		if d.Decimals < -1 || d.Decimals > 15 {
+			return nil, fmt.Errorf("\"decimals\" must be between -1 and 15")
+		}
+	}
+	return
+}
+
+func (d *LinearDecoder) Name() FieldDecoderType {
+	return LinearDecoderType
+}
+
+func (d *LinearDecoder) GetParams() map[string]any {
+	m := map[string]any{}
+	m["from"] = d.From
+	m["scale"] = d.Scale
+	m["offset"] = d.Offset
+	if d.Unit != "" {
+		m["unit"] = d.Unit
+	}
+	if d.Decimals >= 0 {
+		m["decimals"] = d.Decimals
+	}
+	return m
+}
+
+func (d *LinearDecoder) Decode(s *State) (any, error) {
+	fromValueI, err := s.Get(d.From)
+	if err != nil {
+		return nil, err
+	}
+	fromValue, err := ei.N(fromValueI).Float64()
+	if err != nil {
+		return nil, err
+	}
+	v := fromValue*d.Scale + d.Offset
+	if d.Decimals >= 0 {
+		factor := math.Pow10(d.Decimals)
+		v = math.Round(v*factor) / factor
+	}
+	return v, nil
+}
+
+func (d *LinearDecoder) Encode(s *State, v any) error {
+	field, exists := s.schema.fieldsMap[d.From]
+	if !exists {
+		return fmt.Errorf("field \"%s\" not found in schema", d.From)
+	}
+	value, err := ei.N(v).Float64()
+	if err != nil {
+		return fmt.Errorf("%w: cannot convert value to number: %v", ErrInvalidType, err)
+	}
+	raw := (value - d.Offset) / d.Scale
+	var fromValue any
+	switch field.Type {
+	case T_INT:
+		raw = math.Round(raw)
+		if !(raw >= math.MinInt64 && raw < math.MaxInt64) {
+			return fmt.Errorf("field \"%s\": %w: value %f out of range for %d-bit signed integer", d.From, ErrOutOfRange, raw, field.Size)
+		}
+		fromValue = int64(raw)
+	case T_UINT:
+		raw = math.Round(raw)
+		if !(raw >= 0 && raw < math.MaxUint64) {
+			return fmt.Errorf("field \"%s\": %w: value %f out of range for %d-bit unsigned integer", d.From, ErrOutOfRange, raw, field.Size)
+		}
+		fromValue = uint64(raw)
+	case T_FIXED, T_UFIXED, T_FLOAT32, T_FLOAT64:
+		fromValue = raw
+	default:
+		return fmt.Errorf("field \"%s\": %w: linear decoder requires a numeric field", d.From, ErrInvalidType)
+	}
+	if err = field.Validate(fromValue); err != nil {
+		return fmt.Errorf("field \"%s\": %w", d.From, err)
+	}
+	return s.Set(d.From, fromValue)
+}
+
+// BitSliceDecoder implements a [Decoder] which exposes a range of bits of an integer field as an integer value.
+// Bits are numbered from the least significant one, as in [FlagsDecoder]. Encoding only modifies the bits of the
+// slice.
+type BitSliceDecoder struct {
+	From   string // "from" parameter: name of the encoded field as defined in StateSchema.Fields
+	Offset int    // "offset" parameter: position of the least significant bit of the slice
+	Length int    // "length" parameter: number of bits of the slice
+	Signed bool   // "signed" parameter (optional): the slice is a two's complement signed integer
+}
+
+func NewBitSliceDecoder(params map[string]any) (d *BitSliceDecoder, err error) {
+	d = &BitSliceDecoder{}
+	d.From, err = ei.N(params).M("from").String()
+	if err != nil {
+		return nil, fmt.Errorf("\"from\" field error: %v", err)
+	}
+	d.Offset, err = ei.N(params).M("offset").Int()
+	if err != nil {
+		return nil, fmt.Errorf("\"offset\" field error: %v", err)
+	}
+	d.Length, err = ei.N(params).M("length").Int()
+	if err != nil {
+		return nil, fmt.Errorf("\"length\" field error: %v", err)
+	}
+	if _, ok := params["signed"]; ok {
+		d.Signed, err = ei.N(params).M("signed").Bool()
+		if err != nil {
+			return nil, fmt.Errorf("\"signed\" field error: %v", err)
+		}
+	}
+	if d.Offset < 0 {
+		return nil, fmt.Errorf("\"offset\" must be >= 0")
+	}
+	if d.Length < 1 || d.Offset+d.Length > 64 {
+		return nil, fmt.Errorf("\"length\" must be > 0 and \"offset\" + \"length\" must be <= 64")
+	}
+	// Note: The slice is validated against the field size at runtime, as in FlagsDecoder
+	return
+}
+
+func (d *BitSliceDecoder) Name() FieldDecoderType {
+	return BitSliceDecoderType
+}
+
+func (d *BitSliceDecoder) GetParams() map[string]any {
+	m := map[string]any{}
+	m["from"] = d.From
+	m["offset"] = d.Offset
+	m["length"] = d.Length
+	if d.Signed {
+		m["signed"] = d.Signed
+	}
+	return m
+}
+
+// getRaw returns the field of the slice and its bits.
+func (d *BitSliceDecoder) getRaw(s *State) (*StateField, uint64, error) {
+	field, exists := s.schema.fieldsMap[d.From]
+	if !exists {
+		return nil, 0, fmt.Errorf("field \"%s\" not found in schema", d.From)
+	}
+	if field.Type != T_UINT && field.Type != T_INT {
+		return nil, 0, fmt.Errorf("field \"%s\": %w: bit slice decoder requires an integer field", d.From, ErrInvalidType)
+	}
+	if d.Offset+d.Length > field.Size {
+		return nil, 0, fmt.Errorf("bit slice [%d, %d) exceeds field size %d bits", d.Offset, d.Offset+d.Length, field.Size)
+	}
+	fromValueI, err := s.Get(d.From)
+	if err != nil {
+		return nil, 0, err
+	}
+	var raw uint64
+	if field.Type == T_INT {
+		fromValue, err := ei.N(fromValueI).Int64()
+		if err != nil {
+			return nil, 0, err
+		}
+		raw = uint64(fromValue) & sizeMask(field.Size)
+	} else if raw, err = ei.N(fromValueI).Uint64(); err != nil {
+		return nil, 0, err
+	}
+	return field, raw, nil
+}
+
+func (d *BitSliceDecoder) Decode(s *State) (any, error) {
+	_, raw, err := d.getRaw(s)
+	if err != nil {
+		return nil, err
+	}
+	v := (raw >> d.Offset) & sizeMask(d.Length)
+	if d.Signed {
+		shift := 64 - d.Length
+		return int64(v<<shift) >> shift, nil
+	}
+	return v, nil
+}
+
+func (d *BitSliceDecoder) Encode(s *State, v any) error {
+	field, raw, err := d.getRaw(s)
+	if err != nil {
+		return err
+	}
+	slice := &StateField{Type: T_UINT, Size: d.Length}
+	if d.Signed {
+		slice.Type = T_INT
+	}
+	if err = slice.Validate(v); err != nil {
+		return fmt.Errorf("bit slice of \"%s\": %w", d.From, err)
+	}
+	var value uint64
+	if d.Signed {
+		signed, _ := ei.N(v).Int64()
+		value = uint64(signed)
+	} else {
+		value, _ = ei.N(v).Uint64()
+	}
+	mask := sizeMask(d.Length) << d.Offset
+	raw = (raw &^ mask) | ((value << d.Offset) & mask)
+	if field.Type == T_INT {
+		shift := 64 - field.Size
+		return s.Set(d.From, int64(raw<<shift)>>shift)
+	}
+	return s.Set(d.From, raw)
+}
+
+// getFromList parses a "from" parameter with a list of field names, which can also be a string with the names
+// separated by '|' (as in struct tags, see [SchemaFromStruct]).
+func getFromList(params map[string]any) ([]string, error) {
+	var raw []any
+	switch v := params["from"].(type) {
+	case []string:
+		return append([]string{}, v...), nil
+	case string:
+		return strings.Split(v, "|"), nil
+	case []any:
+		raw = v
+	default:
+		return nil, fmt.Errorf("\"from\" field error: expected a list of field names")
+	}
+	from := make([]string, 0, len(raw))
+	for _, r := range raw {
+		name, ok := r.(string)
+		if !ok {
+			return nil, fmt.Errorf("\"from\" field error: expected a list of field names")
+		}
+		from = append(from, name)
+	}
+	return from, nil
+}
+
+// getIntegerField returns the integer field with the provided name and its bits.
+func getIntegerField(s *State, name string) (*StateField, uint64, error) {
+	field, exists := s.schema.fieldsMap[name]
+	if !exists {
+		return nil, 0, fmt.Errorf("field \"%s\" not found in schema", name)
+	}
+	if field.Type != T_UINT && field.Type != T_INT {
+		return nil, 0, fmt.Errorf("field \"%s\": %w: integer field required", name, ErrInvalidType)
+	}
+	fromValueI, err := s.Get(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	var raw uint64
+	if field.Type == T_INT {
+		fromValue, err := ei.N(fromValueI).Int64()
+		if err != nil {
+			return nil, 0, err
+		}
+		raw = uint64(fromValue)
+	} else if raw, err = ei.N(fromValueI).Uint64(); err != nil {
+		return nil, 0, err
+	}
+	return field, raw & sizeMask(field.Size), nil
+}
+
+// rawToFieldValue converts the bits of an integer field to the value set in the state.
+func rawToFieldValue(field *StateField, raw uint64) any {
+	if field.Type == T_INT {
+		shift := 64 - field.Size
+		return int64(raw<<shift) >> shift
+	}
+	return raw
+}
+
+// ConcatDecoder implements a [Decoder] which joins the bits of several integer fields into one integer. The first
+// field holds the most significant bits (e.g. "from": ["HI", "LO"]). Encoding splits the value back into the fields.
+type ConcatDecoder struct {
+	From   []string // "from" parameter: names of the encoded fields, most significant first (at most 64 bits in total)
+	Signed bool     // "signed" parameter (optional): the joined value is a two's complement signed integer
+}
+
+func NewConcatDecoder(params map[string]any) (d *ConcatDecoder, err error) {
+	d = &ConcatDecoder{}
+	d.From, err = getFromList(params)
+	if err != nil {
+		return nil, err
+	}
+	if len(d.From) < 2 {
+		return nil, fmt.Errorf("\"from\" must have at least 2 fields")
+	}
+	if _, ok := params["signed"]; ok {
+		d.Signed, err = ei.N(params).M("signed").Bool()
+		if err != nil {
+			return nil, fmt.Errorf("\"signed\" field error: %v", err)
+		}
+	}
+	return
+}
+
+func (d *ConcatDecoder) Name() FieldDecoderType {
+	return ConcatDecoderType
+}
+
+func (d *ConcatDecoder) GetParams() map[string]any {
+	m := map[string]any{}
+	m["from"] = d.From
+	if d.Signed {
+		m["signed"] = d.Signed
+	}
+	return m
+}
+
+// getFields returns the fields to join and their total size in bits.
+func (d *ConcatDecoder) getFields(s *State) ([]*StateField, []uint64, int, error) {
+	fields := make([]*StateField, 0, len(d.From))
+	raws := make([]uint64, 0, len(d.From))
+	size := 0
+	for _, name := range d.From {
+		field, raw, err := getIntegerField(s, name)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		fields = append(fields, field)
+		raws = append(raws, raw)
+		size += field.Size
+	}
+	if size > 64 {
+		return nil, nil, 0, fmt.Errorf("concatenated fields size %d bits exceeds 64 bits", size)
+	}
+	return fields, raws, size, nil
+}
+
+func (d *ConcatDecoder) Decode(s *State) (any, error) {
+	fields, raws, size, err := d.getFields(s)
+	if err != nil {
+		return nil, err
+	}
+	var v uint64
+	for i, field := range fields {
+		v = v<<field.Size | raws[i]
+	}
+	if d.Signed {
+		shift := 64 - size
+		return int64(v<<shift) >> shift, nil
+	}
+	return v, nil
+}
+
+func (d *ConcatDecoder) Encode(s *State, v any) error {
+	fields, _, size, err := d.getFields(s)
+	if err != nil {
+		return err
+	}
+	joined := &StateField{Type: T_UINT, Size: size}
+	if d.Signed {
+		joined.Type = T_INT
+	}
+	if err = joined.Validate(v); err != nil {
+		return fmt.Errorf("concatenation of %v: %w", d.From, err)
+	}
+	var value uint64
+	if d.Signed {
+		signed, _ := ei.N(v).Int64()
+		value = uint64(signed)
+	} else {
+		value, _ = ei.N(v).Uint64()
+	}
+	values := make([]any, len(fields))
+	for i := len(fields) - 1; i >= 0; i-- {
+		values[i] = rawToFieldValue(fields[i], value&sizeMask(fields[i].Size))
+		value >>= fields[i].Size
+	}
+	for i, field := range fields {
+		if err = s.Set(field.Name, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GeoPoint is the value of a [GeoPointDecoder] decoded field.
+type GeoPoint struct {
+	Lat float64 `json:"lat"` // Latitude in degrees
+	Lon float64 `json:"lon"` // Longitude in degrees
+}
+
+// GeoPointDecoder implements a [Decoder] which combines a latitude and a longitude field (usually fixed point
+// fields) into a [GeoPoint]. Encoding accepts a [GeoPoint] or a map with "lat" and "lon" values, which must be
+// valid coordinates that fit in the fields.
+type GeoPointDecoder struct {
+	From []string // "from" parameter: names of the latitude and longitude fields, in this order
+}
+
+func NewGeoPointDecoder(params map[string]any) (d *GeoPointDecoder, err error) {
+	d = &GeoPointDecoder{}
+	d.From, err = getFromList(params)
+	if err != nil {
+		return nil, err
+	}
+	if len(d.From) != 2 {
+		return nil, fmt.Errorf("\"from\" must have 2 fields (latitude and longitude)")
+	}
+	return
+}
+
+func (d *GeoPointDecoder) Name() FieldDecoderType {
+	return GeoPointDecoderType
+}
+
+func (d *GeoPointDecoder) GetParams() map[string]any {
+	m := map[string]any{}
+	m["from"] = d.From
+	return m
+}
+
+func (d *GeoPointDecoder) Decode(s *State) (any, error) {
+	coords := [2]float64{}
+	for i, name := range d.From {
+		fromValueI, err := s.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		coords[i], err = ei.N(fromValueI).Float64()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return GeoPoint{Lat: coords[0], Lon: coords[1]}, nil
+}
+
+func (d *GeoPointDecoder) Encode(s *State, v any) error {
+	var point GeoPoint
+	switch p := v.(type) {
+	case GeoPoint:
+		point = p
+	case *GeoPoint:
+		point = *p
+	case map[string]any:
+		var err error
+		if point.Lat, err = ei.N(p).M("lat").Float64(); err != nil {
+			return fmt.Errorf("%w: \"lat\": %v", ErrInvalidType, err)
+		}
+		if point.Lon, err = ei.N(p).M("lon").Float64(); err != nil {
+			return fmt.Errorf("%w: \"lon\": %v", ErrInvalidType, err)
+		}
+	default:
+		return fmt.Errorf("%w: expected GeoPoint or map with \"lat\" and \"lon\", got %T", ErrInvalidType, v)
+	}
+	if !(point.Lat >= -90 && point.Lat <= 90) || !(point.Lon >= -180 && point.Lon <= 180) {
+		return fmt.Errorf("%w: invalid coordinates (%v, %v)", ErrOutOfRange, point.Lat, point.Lon)
+	}
+	coords := [2]float64{point.Lat, point.Lon}
+	// Validate both fields before setting any of them
+	for i, name := range d.From {
+		field, exists := s.schema.fieldsMap[name]
+		if !exists {
+			return fmt.Errorf("field \"%s\" not found in schema", name)
+		}
+		if err := field.Validate(coords[i]); err != nil {
+			return fmt.Errorf("field \"%s\": %w", name, err)
+		}
+	}
+	for i, name := range d.From {
+		if err := s.Set(name, coords[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package bstates
 
 import (
+	"encoding/json"
 	"github.com/stretchr/testify/require"
+	"math"
 	"testing"
 	"time"
 )
@@ -45,11 +47,124 @@ func Test_IntMapDecoder(t *testing.T) {
 	require.Equal(t, "TYPE A", v)
 
 	err = state.Set("TYPE", "TYPE D")
-	require.Error(t, err)
+	require.Nil(t, err)
+	v, err = state.Get("F_INT32")
+	require.Nil(t, err)
+	require.EqualValues(t, 1, v)
+
+	err = state.Set("TYPE", "TYPE Z")
+	require.ErrorIs(t, err, ErrUnknownLabel)
 
 	v, err = state.Get("TYPE")
 	require.Nil(t, err)
-	require.Equal(t, "TYPE A", v) // unchanged
+	require.Equal(t, "TYPE D", v) // unchanged
+
+	state.Set("F_INT32", 3)
+	v, err = state.Get("TYPE")
+	require.Nil(t, err)
+	require.Equal(t, "UNKNOWN", v)
+}
+
+func Test_IntMapDecoder_Encode(t *testing.T) {
+	decoder, err := NewDecoder("IntMap", map[string]any{
+		"from":         "CODE",
+		"mapId":        "CODE_MAP",
+		"unknownLabel": "OTHER",
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"from": "CODE", "mapId": "CODE_MAP", "unknownLabel": "OTHER"}, decoder.GetParams())
+
+	schema, err := CreateStateSchema(&StateSchemaParams{
+		Fields: []StateField{
+			{Name: "CODE", Type: T_UINT, Size: 2},
+			{Name: "LEVEL", Type: T_UINT, Size: 4},
+		},
+		DecodedFields: []DecodedStateField{
+			{Name: "STATE", Decoder: decoder},
+			{Name: "LEVEL_VALUE", Decoder: &IntMapDecoder{From: "LEVEL", MapId: "LEVEL_MAP"}},
+		},
+		DecoderIntMaps: map[string]map[int64]any{
+			"CODE_MAP": {
+				0: "IDLE",
+				1: "RUNNING",
+				2: "ERROR",
+				3: "ERROR",
+				7: "OUT_OF_RANGE",
+			},
+			"LEVEL_MAP": {
+				1: 10,
+				2: 20.5,
+			},
+		},
+	})
+	require.NoError(t, err)
+	state, err := schema.CreateState()
+	require.NoError(t, err)
+
+	require.NoError(t, state.Set("STATE", "RUNNING"))
+	v, err := state.Get("CODE")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), v)
+
+	err = state.Set("STATE", "ERROR")
+	require.ErrorIs(t, err, ErrAmbiguousLabel)
+	require.ErrorContains(t, err, "[2 3]")
+	require.ErrorIs(t, state.Set("STATE", "STOPPED"), ErrUnknownLabel)
+	require.ErrorIs(t, state.Set("STATE", "OTHER"), ErrUnknownLabel)
+	require.ErrorContains(t, state.Set("STATE", "OUT_OF_RANGE"), "out of range")
+	v, err = state.Get("STATE")
+	require.NoError(t, err)
+	require.Equal(t, "RUNNING", v) // unchanged
+
+	// Numbers are compared by value
+	require.NoError(t, state.Set("LEVEL_VALUE", 20.5))
+	v, err = state.Get("LEVEL")
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), v)
+	require.NoError(t, state.Set("LEVEL_VALUE", uint8(10)))
+	v, err = state.Get("LEVEL")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), v)
+	require.ErrorIs(t, state.Set("LEVEL_VALUE", "10"), ErrUnknownLabel)
+
+	// Unknown label
+	require.NoError(t, state.Set("LEVEL", 5))
+	v, err = state.Get("LEVEL_VALUE")
+	require.NoError(t, err)
+	require.Equal(t, "UNKNOWN", v)
+
+	// The unknown label is kept when the schema is serialized
+	raw, err := json.Marshal(schema)
+	require.NoError(t, err)
+	schema2 := &StateSchema{}
+	require.NoError(t, json.Unmarshal(raw, schema2))
+	state2, err := schema2.CreateState()
+	require.NoError(t, err)
+	require.NoError(t, state2.Set("CODE", 2))
+	require.NoError(t, state2.Set("STATE", "IDLE"))
+	v, err = state2.Get("CODE")
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), v)
+	require.NoError(t, state2.Set("LEVEL", 3))
+	v, err = state2.Get("LEVEL_VALUE")
+	require.NoError(t, err)
+	require.Equal(t, "UNKNOWN", v)
+	require.NoError(t, state2.Set("LEVEL_VALUE", 20.5))
+
+	schema3, err := CreateStateSchema(&StateSchemaParams{
+		Fields:        []StateField{{Name: "CODE", Type: T_UINT, Size: 2}},
+		DecodedFields: []DecodedStateField{{Name: "STATE", Decoder: decoder}},
+		DecoderIntMaps: map[string]map[int64]any{
+			"CODE_MAP": {0: "IDLE"},
+		},
+	})
+	require.NoError(t, err)
+	state3, err := schema3.CreateState()
+	require.NoError(t, err)
+	require.NoError(t, state3.Set("CODE", 1))
+	v, err = state3.Get("STATE")
+	require.NoError(t, err)
+	require.Equal(t, "OTHER", v)
 }
 
 func Test_NumberToUnixTsMsDecoder(t *testing.T) {
@@ -419,3 +534,544 @@ func Test_FlagsDecoder_BitPositionValidation(t *testing.T) {
 	require.Contains(t, flags, "flag0")
 	require.Contains(t, flags, "flag3")
 }
+
+func Test_LinearDecoder(t *testing.T) {
+	decoder, err := NewDecoder("Linear", map[string]any{
+		"from":     "ADC",
+		"scale":    0.0125,
+		"offset":   -40,
+		"unit":     "°C",
+		"decimals": 2,
+	})
+	require.NoError(t, err)
+	linear := decoder.(*LinearDecoder)
+	require.Equal(t, LinearDecoderType, linear.Name())
+	require.Equal(t, map[string]any{"from": "ADC", "scale": 0.0125, "offset": -40.0, "unit": "°C", "decimals": 2}, linear.GetParams())
+
+	schema, err := CreateStateSchema(&StateSchemaParams{
+		Fields: []StateField{
+			{Name: "ADC", Type: T_UINT, Size: 13},
+			{Name: "RAW_LEVEL", Type: T_INT, Size: 8},
+			{Name: "RAW_GAIN", Type: T_UFIXED, Size: 16, Decimals: 2},
+		},
+		DecodedFields: []DecodedStateField{
+			{Name: "TEMP", Decoder: linear},
+			{Name: "LEVEL", Decoder: &LinearDecoder{From: "RAW_LEVEL", Scale: -0.5, Offset: 10, Decimals: -1}},
+			{Name: "GAIN", Decoder: &LinearDecoder{From: "RAW_GAIN", Scale: 2, Decimals: -1}},
+		},
+	})
+	require.NoError(t, err)
+	state, err := schema.CreateState()
+	require.NoError(t, err)
+
+	require.NoError(t, state.Set("ADC", 2000))
+	v, err := state.Get("TEMP")
+	require.NoError(t, err)
+	require.Equal(t, -15.0, v)
+
+	// Encode applies the inverse, rounding to the nearest raw value
+	require.NoError(t, state.Set("TEMP", 21.33))
+	v, err = state.Get("ADC")
+	require.NoError(t, err)
+	require.Equal(t, uint64(4906), v)
+	v, err = state.Get("TEMP")
+	require.NoError(t, err)
+	require.Equal(t, 21.33, v)
+
+	require.NoError(t, state.Set("LEVEL", 30))
+	v, err = state.Get("RAW_LEVEL")
+	require.NoError(t, err)
+	require.Equal(t, int64(-40), v)
+	v, err = state.Get("LEVEL")
+	require.NoError(t, err)
+	require.Equal(t, 30.0, v)
+
+	require.NoError(t, state.Set("GAIN", 12.5))
+	v, err = state.Get("RAW_GAIN")
+	require.NoError(t, err)
+	require.Equal(t, 6.25, v)
+
+	// Values out of the range of the field are rejected
+	err = state.Set("TEMP", 20000)
+	require.ErrorIs(t, err, ErrOutOfRange)
+	err = state.Set("TEMP", -50)
+	require.ErrorIs(t, err, ErrOutOfRange)
+	err = state.Set("LEVEL", 100)
+	require.ErrorIs(t, err, ErrOutOfRange)
+	err = state.Set("TEMP", "hot")
+	require.ErrorIs(t, err, ErrInvalidType)
+	v, err = state.Get("ADC")
+	require.NoError(t, err)
+	require.Equal(t, uint64(4906), v) // unchanged
+
+	// The decoder is kept when the schema is serialized
+	raw, err := json.Marshal(schema)
+	require.NoError(t, err)
+	schema2 := &StateSchema{}
+	require.NoError(t, json.Unmarshal(raw, schema2))
+	require.Equal(t, schema.GetSHA256(), schema2.GetSHA256())
+	state2, err := schema2.CreateState()
+	require.NoError(t, err)
+	require.NoError(t, state2.Set("ADC", 2000))
+	v, err = state2.Get("TEMP")
+	require.NoError(t, err)
+	require.Equal(t, -15.0, v)
+}
+
+func Test_LinearDecoder_Errors(t *testing.T) {
+	for _, params := range []map[string]any{
+		{"scale": 1},
+		{"from": "A"},
+		{"from": "A", "scale": 0},
+		{"from": "A", "scale": "x"},
+		{"from": "A", "scale": 1, "offset": "x"},
+		{"from": "A", "scale": 1, "decimals": 16},
+	} {
+		_, err := NewLinearDecoder(params)
+		require.Error(t, err, params)
+	}
+
+	schema, err := CreateStateSchema(&StateSchemaParams{
+		Fields: []StateField{
+			{Name: "FLAG", Type: T_BOOL},
+		},
+		DecodedFields: []DecodedStateField{
+			{Name: "LINEAR", Decoder: &LinearDecoder{From: "FLAG", Scale: 1}},
+			{Name: "MISSING", Decoder: &LinearDecoder{From: "NONE", Scale: 1}},
+		},
+	})
+	require.NoError(t, err)
+	state, err := schema.CreateState()
+	require.NoError(t, err)
+	require.ErrorIs(t, state.Set("LINEAR", 1), ErrInvalidType)
+	require.Error(t, state.Set("MISSING", 1))
+	_, err = state.Get("MISSING")
+	require.Error(t, err)
+}
+
+func Test_RangeMapDecoder(t *testing.T) {
+	decoder, err := NewDecoder("RangeMap", map[string]any{
+		"from":  "BATTERY",
+		"mapId": "BATTERY_MAP",
+	})
+	require.NoError(t, err)
+	require.Equal(t, RangeMapDecoderType, decoder.Name())
+	require.Equal(t, map[string]any{"from": "BATTERY", "mapId": "BATTERY_MAP"}, decoder.GetParams())
+
+	schema, err := CreateStateSchema(&StateSchemaParams{
+		Fields: []StateField{
+			{Name: "BATTERY", Type: T_UFIXED, Size: 12, Decimals: 2},
+			{Name: "RSSI", Type: T_INT, Size: 8},
+		},
+		DecodedFields: []DecodedStateField{
+			{Name: "BATTERY_LEVEL", Decoder: decoder},
+			{Name: "SIGNAL", Decoder: &RangeMapDecoder{From: "RSSI", MapId: "RSSI_MAP", UnknownLabel: "NO_SIGNAL"}},
+		},
+		DecoderRangeMaps: map[string][]RangeMapEntry{
+			// Entries don't need to be sorted
+			"BATTERY_MAP": {
+				{Min: 3.6, Max: 4.3, Label: "HIGH"},
+				{Min: math.Inf(-1), Max: 3.3, Label: "LOW"},
+				{Min: 3.3, Max: 3.6, Label: "OK"},
+			},
+			"RSSI_MAP": {
+				{Min: -100, Max: -85, Label: "POOR"},
+				{Min: -85, Max: -70, Label: "FAIR"},
+				{Min: -70, Max: math.Inf(1), Label: "GOOD"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	state, err := schema.CreateState()
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		battery float64
+		label   string
+	}{
+		{0, "LOW"},
+		{3.29, "LOW"},
+		{3.3, "OK"},
+		{3.59, "OK"},
+		{3.6, "HIGH"},
+		{4.29, "HIGH"},
+		{4.3, "UNKNOWN"},
+		{12, "UNKNOWN"},
+	} {
+		require.NoError(t, state.Set("BATTERY", tc.battery))
+		v, err := state.Get("BATTERY_LEVEL")
+		require.NoError(t, err)
+		require.Equal(t, tc.label, v, tc.battery)
+	}
+	for _, tc := range []struct {
+		rssi  int
+		label string
+	}{
+		{-128, "NO_SIGNAL"},
+		{-100, "POOR"},
+		{-71, "FAIR"},
+		{-70, "GOOD"},
+		{127, "GOOD"},
+	} {
+		require.NoError(t, state.Set("RSSI", tc.rssi))
+		v, err := state.Get("SIGNAL")
+		require.NoError(t, err)
+		require.Equal(t, tc.label, v, tc.rssi)
+	}
+	require.Error(t, state.Set("SIGNAL", "GOOD"))
+
+	// Range maps are kept when the schema is serialized, omitting unbounded limits
+	raw, err := json.Marshal(schema)
+	require.NoError(t, err)
+	require.Contains(t, string(raw), `"decoderRangeMaps":{"BATTERY_MAP":[{"label":"LOW","max":3.3},`)
+	schema2 := &StateSchema{}
+	require.NoError(t, json.Unmarshal(raw, schema2))
+	require.Equal(t, schema, schema2)
+	require.Equal(t, schema.GetSHA256(), schema2.GetSHA256())
+
+	// Schemas without range maps don't include the section
+	require.NotContains(t, createSchema(t).ToMsi(), "decoderRangeMaps")
+
+	// A missing map is reported when decoding
+	schema3, err := CreateStateSchema(&StateSchemaParams{
+		Fields:        []StateField{{Name: "RSSI", Type: T_INT, Size: 8}},
+		DecodedFields: []DecodedStateField{{Name: "SIGNAL", Decoder: &RangeMapDecoder{From: "RSSI", MapId: "RSSI_MAP"}}},
+	})
+	require.NoError(t, err)
+	state3, err := schema3.CreateState()
+	require.NoError(t, err)
+	_, err = state3.Get("SIGNAL")
+	require.Error(t, err)
+}
+
+func Test_RangeMapDecoder_Errors(t *testing.T) {
+	for _, entries := range [][]RangeMapEntry{
+		{{Min: 0, Max: 10, Label: "A"}, {Min: 5, Max: 20, Label: "B"}},
+		{{Min: 5, Max: 20, Label: "B"}, {Min: math.Inf(-1), Max: 6, Label: "A"}},
+		{{Min: 0, Max: 10, Label: "A"}, {Min: 0, Max: 10, Label: "B"}},
+		{{Min: 10, Max: 10, Label: "A"}},
+		{{Min: 10, Max: 0, Label: "A"}},
+		{{Min: math.NaN(), Max: 0, Label: "A"}},
+	} {
+		_, err := CreateStateSchema(&StateSchemaParams{
+			DecoderRangeMaps: map[string][]RangeMapEntry{"MAP": entries},
+		})
+		require.Error(t, err, entries)
+	}
+
+	for _, rangeMaps := range []string{
+		`{"MAP":[{"min":0,"max":10,"label":"A"},{"min":9,"label":"B"}]}`,
+		`{"MAP":[{"min":"a","label":"A"}]}`,
+		`{"MAP":[{"min":0}]}`,
+		`{"MAP":{"min":0,"label":"A"}}`,
+	} {
+		raw := `{"version":"2.0","fields":[],"decodedFields":[],"decoderIntMaps":{},"encoderPipeline":"","decoderRangeMaps":` + rangeMaps + `}`
+		require.Error(t, json.Unmarshal([]byte(raw), &StateSchema{}), rangeMaps)
+	}
+
+	_, err := NewRangeMapDecoder(map[string]any{"from": "A"})
+	require.Error(t, err)
+	_, err = NewRangeMapDecoder(map[string]any{"mapId": "A"})
+	require.Error(t, err)
+}
+
+func Test_BitSliceDecoder(t *testing.T) {
+	decoder, err := NewDecoder("BitSlice", map[string]any{
+		"from":   "PACKED",
+		"offset": 4,
+		"length": 6,
+		"signed": true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, BitSliceDecoderType, decoder.Name())
+	require.Equal(t, map[string]any{"from": "PACKED", "offset": 4, "length": 6, "signed": true}, decoder.GetParams())
+
+	schema, err := CreateStateSchema(&StateSchemaParams{
+		Fields: []StateField{
+			{Name: "PACKED", Type: T_UINT, Size: 16},
+			{Name: "SPACKED", Type: T_INT, Size: 8},
+		},
+		DecodedFields: []DecodedStateField{
+			{Name: "MODE", Decoder: &BitSliceDecoder{From: "PACKED", Offset: 0, Length: 4}},
+			{Name: "TEMP_OFFSET", Decoder: decoder},
+			{Name: "COUNT", Decoder: &BitSliceDecoder{From: "PACKED", Offset: 10, Length: 6}},
+			{Name: "LOW", Decoder: &BitSliceDecoder{From: "SPACKED", Offset: 0, Length: 4}},
+			{Name: "HIGH", Decoder: &BitSliceDecoder{From: "SPACKED", Offset: 4, Length: 4, Signed: true}},
+		},
+	})
+	require.NoError(t, err)
+	state, err := schema.CreateState()
+	require.NoError(t, err)
+
+	// COUNT=45, TEMP_OFFSET=-3 (0b111101), MODE=9
+	require.NoError(t, state.Set("PACKED", 45<<10|0b111101<<4|9))
+	v, err := state.Get("MODE")
+	require.NoError(t, err)
+	require.Equal(t, uint64(9), v)
+	v, err = state.Get("TEMP_OFFSET")
+	require.NoError(t, err)
+	require.Equal(t, int64(-3), v)
+	v, err = state.Get("COUNT")
+	require.NoError(t, err)
+	require.Equal(t, uint64(45), v)
+
+	// Encoding doesn't modify the neighbouring bits
+	require.NoError(t, state.Set("TEMP_OFFSET", 17))
+	v, err = state.Get("PACKED")
+	require.NoError(t, err)
+	require.Equal(t, uint64(45<<10|17<<4|9), v)
+	require.NoError(t, state.Set("MODE", 0))
+	require.NoError(t, state.Set("COUNT", 63))
+	v, err = state.Get("PACKED")
+	require.NoError(t, err)
+	require.Equal(t, uint64(63<<10|17<<4), v)
+
+	// Values must fit in the slice
+	require.ErrorIs(t, state.Set("MODE", 16), ErrOutOfRange)
+	require.ErrorIs(t, state.Set("MODE", -1), ErrOutOfRange)
+	require.ErrorIs(t, state.Set("TEMP_OFFSET", 32), ErrOutOfRange)
+	require.ErrorIs(t, state.Set("TEMP_OFFSET", -33), ErrOutOfRange)
+	require.ErrorIs(t, state.Set("MODE", "a"), ErrInvalidType)
+	v, err = state.Get("PACKED")
+	require.NoError(t, err)
+	require.Equal(t, uint64(63<<10|17<<4), v) // unchanged
+
+	// Slices of signed fields
+	require.NoError(t, state.Set("SPACKED", -2)) // 0b11111110
+	v, err = state.Get("LOW")
+	require.NoError(t, err)
+	require.Equal(t, uint64(14), v)
+	v, err = state.Get("HIGH")
+	require.NoError(t, err)
+	require.Equal(t, int64(-1), v)
+	require.NoError(t, state.Set("HIGH", 3))
+	v, err = state.Get("SPACKED")
+	require.NoError(t, err)
+	require.EqualValues(t, 0b00111110, v)
+	require.NoError(t, state.Set("HIGH", -8))
+	v, err = state.Get("SPACKED")
+	require.NoError(t, err)
+	require.EqualValues(t, -128+14, v)
+}
+
+func Test_BitSliceDecoder_Errors(t *testing.T) {
+	for _, params := range []map[string]any{
+		{"offset": 0, "length": 4},
+		{"from": "A", "length": 4},
+		{"from": "A", "offset": 0},
+		{"from": "A", "offset": -1, "length": 4},
+		{"from": "A", "offset": 0, "length": 0},
+		{"from": "A", "offset": 60, "length": 5},
+		{"from": "A", "offset": 0, "length": 4, "signed": "maybe"},
+	} {
+		_, err := NewBitSliceDecoder(params)
+		require.Error(t, err, params)
+	}
+
+	schema, err := CreateStateSchema(&StateSchemaParams{
+		Fields: []StateField{
+			{Name: "SMALL", Type: T_UINT, Size: 4},
+			{Name: "FLOAT", Type: T_FLOAT32},
+		},
+		DecodedFields: []DecodedStateField{
+			{Name: "WIDE", Decoder: &BitSliceDecoder{From: "SMALL", Offset: 2, Length: 4}},
+			{Name: "FSLICE", Decoder: &BitSliceDecoder{From: "FLOAT", Offset: 0, Length: 4}},
+			{Name: "MISSING", Decoder: &BitSliceDecoder{From: "NONE", Offset: 0, Length: 4}},
+		},
+	})
+	require.NoError(t, err)
+	state, err := schema.CreateState()
+	require.NoError(t, err)
+	for _, name := range []string{"WIDE", "FSLICE", "MISSING"} {
+		_, err = state.Get(name)
+		require.Error(t, err, name)
+		require.Error(t, state.Set(name, 1), name)
+	}
+}
+
+func Test_ConcatDecoder(t *testing.T) {
+	decoder, err := NewDecoder("Concat", map[string]any{
+		"from": []any{"HI", "LO"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, ConcatDecoderType, decoder.Name())
+	require.Equal(t, map[string]any{"from": []string{"HI", "LO"}}, decoder.GetParams())
+
+	schema, err := CreateStateSchema(&StateSchemaParams{
+		Fields: []StateField{
+			{Name: "HI", Type: T_UINT, Size: 16},
+			{Name: "LO", Type: T_UINT, Size: 16},
+			{Name: "S_HI", Type: T_INT, Size: 4},
+			{Name: "S_MID", Type: T_UINT, Size: 8},
+			{Name: "S_LO", Type: T_INT, Size: 4},
+		},
+		DecodedFields: []DecodedStateField{
+			{Name: "COUNTER", Decoder: decoder},
+			{Name: "OFFSET", Decoder: &ConcatDecoder{From: []string{"S_HI", "S_MID", "S_LO"}, Signed: true}},
+		},
+	})
+	require.NoError(t, err)
+	state, err := schema.CreateState()
+	require.NoError(t, err)
+
+	require.NoError(t, state.Set("HI", 0x1234))
+	require.NoError(t, state.Set("LO", 0xabcd))
+	v, err := state.Get("COUNTER")
+	require.NoError(t, err)
+	require.Equal(t, uint64(0x1234abcd), v)
+
+	require.NoError(t, state.Set("COUNTER", 0xfedc0001))
+	v, err = state.Get("HI")
+	require.NoError(t, err)
+	require.Equal(t, uint64(0xfedc), v)
+	v, err = state.Get("LO")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), v)
+	require.ErrorIs(t, state.Set("COUNTER", uint64(1)<<32), ErrOutOfRange)
+	require.ErrorIs(t, state.Set("COUNTER", -1), ErrOutOfRange)
+
+	// Signed values are split into the two's complement bits of every field
+	require.NoError(t, state.Set("OFFSET", -2))
+	v, err = state.Get("S_HI")
+	require.NoError(t, err)
+	require.EqualValues(t, -1, v)
+	v, err = state.Get("S_MID")
+	require.NoError(t, err)
+	require.Equal(t, uint64(0xff), v)
+	v, err = state.Get("S_LO")
+	require.NoError(t, err)
+	require.EqualValues(t, -2, v)
+	v, err = state.Get("OFFSET")
+	require.NoError(t, err)
+	require.Equal(t, int64(-2), v)
+	require.NoError(t, state.Set("OFFSET", 0x7ff))
+	v, err = state.Get("S_HI")
+	require.NoError(t, err)
+	require.EqualValues(t, 0, v)
+	v, err = state.Get("OFFSET")
+	require.NoError(t, err)
+	require.Equal(t, int64(0x7ff), v)
+	require.ErrorIs(t, state.Set("OFFSET", 1<<15), ErrOutOfRange)
+
+	// Negative values decoded from JSON are float64
+	require.NoError(t, state.Set("OFFSET", float64(-300)))
+	v, err = state.Get("S_HI")
+	require.NoError(t, err)
+	require.EqualValues(t, -1, v)
+	v, err = state.Get("S_MID")
+	require.NoError(t, err)
+	require.Equal(t, uint64(0xed), v)
+	v, err = state.Get("S_LO")
+	require.NoError(t, err)
+	require.EqualValues(t, 4, v)
+	v, err = state.Get("OFFSET")
+	require.NoError(t, err)
+	require.Equal(t, int64(-300), v)
+
+	// The decoder is kept when the schema is serialized
+	raw, err := json.Marshal(schema)
+	require.NoError(t, err)
+	schema2 := &StateSchema{}
+	require.NoError(t, json.Unmarshal(raw, schema2))
+	require.Equal(t, schema, schema2)
+}
+
+func Test_ConcatDecoder_Errors(t *testing.T) {
+	for _, params := range []map[string]any{
+		{},
+		{"from": []any{"A"}},
+		{"from": []any{"A", 1}},
+		{"from": 1},
+		{"from": []any{"A", "B"}, "signed": "maybe"},
+	} {
+		_, err := NewConcatDecoder(params)
+		require.Error(t, err, params)
+	}
+	d, err := NewConcatDecoder(map[string]any{"from": "A|B"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"A", "B"}, d.From)
+
+	schema, err := CreateStateSchema(&StateSchemaParams{
+		Fields: []StateField{
+			{Name: "A", Type: T_UINT, Size: 40},
+			{Name: "B", Type: T_UINT, Size: 40},
+			{Name: "F", Type: T_FLOAT32},
+		},
+		DecodedFields: []DecodedStateField{
+			{Name: "WIDE", Decoder: &ConcatDecoder{From: []string{"A", "B"}}},
+			{Name: "FLOAT", Decoder: &ConcatDecoder{From: []string{"A", "F"}}},
+			{Name: "MISSING", Decoder: &ConcatDecoder{From: []string{"A", "NONE"}}},
+		},
+	})
+	require.NoError(t, err)
+	state, err := schema.CreateState()
+	require.NoError(t, err)
+	for _, name := range []string{"WIDE", "FLOAT", "MISSING"} {
+		_, err = state.Get(name)
+		require.Error(t, err, name)
+		require.Error(t, state.Set(name, 1), name)
+	}
+}
+
+func Test_GeoPointDecoder(t *testing.T) {
+	decoder, err := NewDecoder("GeoPoint", map[string]any{
+		"from": []any{"LAT", "LON"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, GeoPointDecoderType, decoder.Name())
+	require.Equal(t, map[string]any{"from": []string{"LAT", "LON"}}, decoder.GetParams())
+
+	schema, err := CreateStateSchema(&StateSchemaParams{
+		Fields: []StateField{
+			{Name: "LAT", Type: T_FIXED, Size: 32, Decimals: 6},
+			{Name: "LON", Type: T_FIXED, Size: 32, Decimals: 6},
+			{Name: "SMALL_LON", Type: T_FIXED, Size: 12, Decimals: 2},
+		},
+		DecodedFields: []DecodedStateField{
+			{Name: "POSITION", Decoder: decoder},
+			{Name: "SMALL_POSITION", Decoder: &GeoPointDecoder{From: []string{"LAT", "SMALL_LON"}}},
+		},
+	})
+	require.NoError(t, err)
+	state, err := schema.CreateState()
+	require.NoError(t, err)
+
+	require.NoError(t, state.Set("LAT", 41.387917))
+	require.NoError(t, state.Set("LON", 2.169919))
+	v, err := state.Get("POSITION")
+	require.NoError(t, err)
+	require.Equal(t, GeoPoint{Lat: 41.387917, Lon: 2.169919}, v)
+
+	require.NoError(t, state.Set("POSITION", GeoPoint{Lat: -33.856784, Lon: 151.215297}))
+	v, err = state.Get("LAT")
+	require.NoError(t, err)
+	require.Equal(t, -33.856784, v)
+	v, err = state.Get("LON")
+	require.NoError(t, err)
+	require.Equal(t, 151.215297, v)
+
+	require.NoError(t, state.Set("POSITION", map[string]any{"lat": 1.5, "lon": -2.25}))
+	v, err = state.Get("POSITION")
+	require.NoError(t, err)
+	require.Equal(t, GeoPoint{Lat: 1.5, Lon: -2.25}, v)
+
+	// Invalid coordinates or values which don't fit in the fields are rejected without modifying the state
+	require.ErrorIs(t, state.Set("POSITION", GeoPoint{Lat: 91, Lon: 0}), ErrOutOfRange)
+	require.ErrorIs(t, state.Set("POSITION", &GeoPoint{Lat: 0, Lon: -180.5}), ErrOutOfRange)
+	require.ErrorIs(t, state.Set("SMALL_POSITION", GeoPoint{Lat: 10, Lon: 179}), ErrOutOfRange)
+	require.ErrorIs(t, state.Set("POSITION", map[string]any{"lat": 1}), ErrInvalidType)
+	require.ErrorIs(t, state.Set("POSITION", "41.38,2.16"), ErrInvalidType)
+	v, err = state.Get("POSITION")
+	require.NoError(t, err)
+	require.Equal(t, GeoPoint{Lat: 1.5, Lon: -2.25}, v) // unchanged
+
+	// Decoded values are serialized as {"lat": ..., "lon": ...}
+	raw, err := json.Marshal(v)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"lat":1.5,"lon":-2.25}`, string(raw))
+
+	_, err = NewGeoPointDecoder(map[string]any{"from": []any{"LAT"}})
+	require.Error(t, err)
+	_, err = NewGeoPointDecoder(map[string]any{"from": "LAT"})
+	require.Error(t, err)
+}
@@ -0,0 +1,235 @@
+package bstates
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/nayarsystems/buffer/buffer"
+)
+
+// deltaField is a field encoded by the delta modifier.
+type deltaField struct {
+	offset int
+	size   int
+	xor    bool // XOR the bit pattern with the previous one instead of subtracting
+}
+
+// getDeltaFields returns the fields of the schema encoded by the delta modifier: integer, fixed point
+// and float fields. Bools and buffers are kept as is.
+func getDeltaFields(schema *StateSchema) []deltaField {
+	fields := []deltaField{}
+	offset := 0
+	for _, f := range schema.fields {
+		switch f.Type {
+		case T_INT, T_UINT, T_FIXED, T_UFIXED:
+			fields = append(fields, deltaField{offset: offset, size: f.Size})
+		case T_FLOAT32, T_FLOAT64:
+			fields = append(fields, deltaField{offset: offset, size: f.Size, xor: true})
+		}
+		offset += f.Size
+	}
+	return fields
+}
+
+// zigZag maps the size bits two's complement value v to an unsigned value (0, -1, 1, -2, 2... are mapped to
+// 0, 1, 2, 3, 4...) so small negative steps are encoded as small values.
+func zigZag(v uint64, size int) uint64 {
+	shift := 64 - size
+	signed := int64(v<<shift) >> shift
+	return uint64((signed<<1)^(signed>>63)) & sizeMask(size)
+}
+
+func unZigZag(v uint64, size int) uint64 {
+	return ((v >> 1) ^ -(v & 1)) & sizeMask(size)
+}
+
+func sizeMask(size int) uint64 {
+	if size >= 64 {
+		return ^uint64(0)
+	}
+	return (uint64(1) << size) - 1
+}
+
+// deltaRow encodes (or decodes) a state row in place. prev holds the previous raw (decoded) row.
+func deltaRow(fields []deltaField, row, prev []byte, decode bool) error {
+	cur := &buffer.Buffer{}
+	cur.InitFromRawBuffer(row)
+	prv := &buffer.Buffer{}
+	prv.InitFromRawBuffer(prev)
+	for _, f := range fields {
+		v, err := cur.GetBitsToUint64(f.offset, f.size)
+		if err != nil {
+			return err
+		}
+		p, err := prv.GetBitsToUint64(f.offset, f.size)
+		if err != nil {
+			return err
+		}
+		if f.xor {
+			v ^= p
+		} else if decode {
+			v = (p + unZigZag(v, f.size)) & sizeMask(f.size)
+		} else {
+			v = zigZag(v-p, f.size)
+		}
+		if err = cur.SetBitsFromUint64(f.offset, v, f.size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeltaEnc replaces the value of every integer and fixed point field of the states in the provided buffer by the
+// zig-zag encoded difference with the value of the same field in the previous state (the first state is encoded as
+// the difference with zero). The bit pattern of float fields is XORed with the one of the previous state instead,
+// since float differences are not exact. Bool and buffer fields are kept as is. The buffer must hold whole states.
+func DeltaEnc(b *buffer.Buffer, schema *StateSchema) (*buffer.Buffer, error) {
+	return deltaBuffer(b, schema, false)
+}
+
+// DeltaDec reverts [DeltaEnc] and returns a new buffer with the original states.
+func DeltaDec(b *buffer.Buffer, schema *StateSchema) (*buffer.Buffer, error) {
+	return deltaBuffer(b, schema, true)
+}
+
+func deltaBuffer(b *buffer.Buffer, schema *StateSchema, decode bool) (*buffer.Buffer, error) {
+	rowSize := schema.GetByteSize()
+	in := b.GetRawBuffer()[:b.GetByteSize()]
+	if rowSize <= 0 || len(in)%rowSize != 0 {
+		return nil, fmt.Errorf("delta modifier: data size (%d) is not a multiple of the state size (%d)", len(in), rowSize)
+	}
+	fields := getDeltaFields(schema)
+	out := make([]byte, len(in))
+	copy(out, in)
+	prev := make([]byte, rowSize)
+	for i := 0; i < len(out); i += rowSize {
+		row := out[i : i+rowSize]
+		raw := in[i : i+rowSize]
+		if err := deltaRow(fields, row, prev, decode); err != nil {
+			return nil, err
+		}
+		if decode {
+			raw = row
+		}
+		copy(prev, raw)
+	}
+	outb := &buffer.Buffer{}
+	outb.InitFromRawBuffer(out)
+	return outb, nil
+}
+
+// deltaWriter applies [DeltaEnc] to the states written.
+type deltaWriter struct {
+	w      io.Writer
+	fields []deltaField
+	prev   []byte
+	row    []byte
+	n      int // bytes of the current row
+}
+
+func newDeltaWriter(w io.Writer, schema *StateSchema) *deltaWriter {
+	return &deltaWriter{
+		w:      w,
+		fields: getDeltaFields(schema),
+		prev:   make([]byte, schema.GetByteSize()),
+		row:    make([]byte, schema.GetByteSize()),
+	}
+}
+
+func (d *deltaWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		c := copy(d.row[d.n:], p)
+		d.n += c
+		p = p[c:]
+		written += c
+		if d.n < len(d.row) {
+			break
+		}
+		raw := append([]byte{}, d.row...)
+		if err := deltaRow(d.fields, d.row, d.prev, false); err != nil {
+			return written, err
+		}
+		copy(d.prev, raw)
+		d.n = 0
+		if _, err := d.w.Write(d.row); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+func (d *deltaWriter) Flush() error {
+	return nil
+}
+
+func (d *deltaWriter) Close() error {
+	if d.n != 0 {
+		return fmt.Errorf("delta modifier: incomplete state (%d bytes)", d.n)
+	}
+	return nil
+}
+
+// deltaReader applies [DeltaDec] to the states read.
+type deltaReader struct {
+	r      io.Reader
+	fields []deltaField
+	prev   []byte
+	row    []byte
+	pos    int // position of the next byte to return from row
+	err    error
+}
+
+func newDeltaReader(r io.Reader, schema *StateSchema) *deltaReader {
+	rowSize := schema.GetByteSize()
+	return &deltaReader{
+		r:      r,
+		fields: getDeltaFields(schema),
+		prev:   make([]byte, rowSize),
+		row:    make([]byte, rowSize),
+		pos:    rowSize,
+	}
+}
+
+func (d *deltaReader) Read(p []byte) (int, error) {
+	if d.pos == len(d.row) {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if _, err := io.ReadFull(d.r, d.row); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				err = fmt.Errorf("delta modifier: incomplete state: %w", err)
+			}
+			d.err = err
+			return 0, err
+		}
+		if err := deltaRow(d.fields, d.row, d.prev, true); err != nil {
+			d.err = err
+			return 0, err
+		}
+		copy(d.prev, d.row)
+		d.pos = 0
+	}
+	n := copy(p, d.row[d.pos:])
+	d.pos += n
+	return n, nil
+}
+
+// deltaModifier is the [MOD_DELTA] modifier.
+type deltaModifier struct{}
+
+func (deltaModifier) Encode(b *buffer.Buffer, schema *StateSchema, step PipelineStep) (*buffer.Buffer, error) {
+	return DeltaEnc(b, schema)
+}
+
+func (deltaModifier) Decode(b *buffer.Buffer, schema *StateSchema, step PipelineStep) (*buffer.Buffer, error) {
+	return DeltaDec(b, schema)
+}
+
+func (deltaModifier) NewWriter(w io.Writer, schema *StateSchema, step PipelineStep) (ModifierWriter, error) {
+	return newDeltaWriter(w, schema), nil
+}
+
+func (deltaModifier) NewReader(r io.Reader, schema *StateSchema, step PipelineStep) (io.Reader, error) {
+	return newDeltaReader(r, schema), nil
+}
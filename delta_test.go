@@ -0,0 +1,185 @@
+package bstates
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/nayarsystems/buffer/buffer"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ZigZag(t *testing.T) {
+	for _, size := range []int{1, 3, 8, 17, 63, 64} {
+		mask := sizeMask(size)
+		for _, v := range []uint64{0, 1, 2, 3, mask, mask - 1, mask >> 1, (mask >> 1) + 1} {
+			v &= mask
+			zz := zigZag(v, size)
+			require.LessOrEqual(t, zz, mask)
+			require.Equal(t, v, unZigZag(zz, size), "size %d value %d", size, v)
+		}
+	}
+	require.Equal(t, uint64(0), zigZag(0, 8))
+	require.Equal(t, uint64(1), zigZag(0xff, 8)) // -1
+	require.Equal(t, uint64(2), zigZag(1, 8))
+	require.Equal(t, uint64(3), zigZag(0xfe, 8)) // -2
+}
+
+func createDeltaTestSchema(t *testing.T, pipeline string) *StateSchema {
+	schema, err := CreateStateSchema(&StateSchemaParams{
+		Fields: []StateField{
+			{Name: "COUNTER", Type: T_UINT, Size: 20},
+			{Name: "TS", Type: T_UINT, Size: 48},
+			{Name: "TEMP", Type: T_FIXED, Size: 12, Decimals: 1},
+			{Name: "LEVEL", Type: T_INT, Size: 7},
+			{Name: "FLAG", Type: T_BOOL},
+			{Name: "RATIO", Type: T_FLOAT32},
+			{Name: "ENERGY", Type: T_FLOAT64},
+			{Name: "TAG", Type: T_BUFFER, Size: 12},
+		},
+		EncoderPipeline: pipeline,
+	})
+	require.NoError(t, err)
+	return schema
+}
+
+func createDeltaTestStates(t *testing.T, schema *StateSchema, n int) []*State {
+	states := []*State{}
+	for i := 0; i < n; i++ {
+		state, err := schema.CreateState()
+		require.NoError(t, err)
+		require.NoError(t, state.Set("COUNTER", (i*3)%(1<<20)))
+		require.NoError(t, state.Set("TS", 1700000000+i*60))
+		require.NoError(t, state.Set("TEMP", float64(i%50)/2-10))
+		require.NoError(t, state.Set("LEVEL", 60-(i%120)))
+		require.NoError(t, state.Set("FLAG", i%3 == 0))
+		require.NoError(t, state.Set("RATIO", float32(i)/7))
+		require.NoError(t, state.Set("ENERGY", float64(i)*1.25-100))
+		require.NoError(t, state.Set("TAG", []byte{byte(i), 0xf0}))
+		states = append(states, state)
+	}
+	return states
+}
+
+func Test_Delta(t *testing.T) {
+	schema := createDeltaTestSchema(t, "")
+	states := createDeltaTestStates(t, schema, 200)
+	queue := CreateStateQueue(schema)
+	require.NoError(t, queue.PushAll(states))
+	data, err := queue.Encode()
+	require.NoError(t, err)
+
+	b := &buffer.Buffer{}
+	b.InitFromRawBuffer(data)
+	enc, err := DeltaEnc(b, schema)
+	require.NoError(t, err)
+
+	// Constant steps are encoded as constant values
+	encQueue := CreateStateQueue(schema)
+	require.NoError(t, encQueue.Decode(enc.GetRawBuffer()))
+	for i := 1; i < 10; i++ {
+		s, err := encQueue.GetStateAt(i)
+		require.NoError(t, err)
+		v, err := s.Get("COUNTER")
+		require.NoError(t, err)
+		require.Equal(t, uint64(6), v) // zig-zag(3)
+		v, err = s.Get("TS")
+		require.NoError(t, err)
+		require.Equal(t, uint64(120), v) // zig-zag(60)
+		v, err = s.Get("LEVEL")
+		require.NoError(t, err)
+		require.Equal(t, int64(1), v) // zig-zag(-1)
+	}
+	// Floats are XORed with the previous value
+	s, err := encQueue.GetStateAt(5)
+	require.NoError(t, err)
+	v, err := s.Get("RATIO")
+	require.NoError(t, err)
+	require.Equal(t, math.Float32frombits(math.Float32bits(float32(5)/7)^math.Float32bits(float32(4)/7)), v)
+	// Bools and buffers are kept as is
+	v, err = s.Get("FLAG")
+	require.NoError(t, err)
+	require.Equal(t, false, v)
+	v, err = s.Get("TAG")
+	require.NoError(t, err)
+	require.Equal(t, []byte{5, 0xf0}, v)
+
+	dec, err := DeltaDec(enc, schema)
+	require.NoError(t, err)
+	require.Equal(t, data, dec.GetRawBuffer())
+
+	// Data must hold whole states
+	b.InitFromRawBuffer(data[:len(data)-1])
+	_, err = DeltaEnc(b, schema)
+	require.Error(t, err)
+}
+
+func Test_Delta_Pipelines(t *testing.T) {
+	for _, pipeline := range []string{"d", "d:z", "d:t:zstd", "d:x:zstd", "x:d", "t:d:z"} {
+		schema := createDeltaTestSchema(t, pipeline)
+		states := createDeltaTestStates(t, schema, 300)
+		queue := CreateStateQueue(schema)
+		require.NoError(t, queue.PushAll(states))
+		data, err := queue.Encode()
+		require.NoError(t, err, pipeline)
+
+		dqueue := CreateStateQueue(schema)
+		require.NoError(t, dqueue.Decode(data), pipeline)
+		dstates, err := dqueue.GetStates()
+		require.NoError(t, err)
+		testEqualStates(t, states, dstates)
+
+		reader, err := CreateQueueReader(schema, bytes.NewReader(data))
+		require.NoError(t, err)
+		rstates := []*State{}
+		require.NoError(t, reader.ForEach(func(s *State) error {
+			rstates = append(rstates, s)
+			return nil
+		}), pipeline)
+		testEqualStates(t, states, rstates)
+	}
+
+	// Streaming writer
+	schema := createDeltaTestSchema(t, "d:zstd")
+	states := createDeltaTestStates(t, schema, 100)
+	out := &bytes.Buffer{}
+	writer, err := CreateQueueWriter(schema, out)
+	require.NoError(t, err)
+	require.NoError(t, writer.PushAll(states))
+	require.NoError(t, writer.Close())
+	dqueue := CreateStateQueue(schema)
+	require.NoError(t, dqueue.Decode(out.Bytes()))
+	dstates, err := dqueue.GetStates()
+	require.NoError(t, err)
+	testEqualStates(t, states, dstates)
+}
+
+func Test_Delta_Compression(t *testing.T) {
+	schema, err := CreateStateSchema(&StateSchemaParams{
+		Fields: []StateField{
+			{Name: "COUNTER", Type: T_UINT, Size: 32},
+			{Name: "TS", Type: T_UINT, Size: 48},
+			{Name: "ENERGY", Type: T_UINT, Size: 24},
+		},
+	})
+	require.NoError(t, err)
+	queue := CreateStateQueue(schema)
+	for i := 0; i < 5000; i++ {
+		state, err := schema.CreateState()
+		require.NoError(t, err)
+		require.NoError(t, state.Set("COUNTER", i))
+		require.NoError(t, state.Set("TS", 1700000000000+i*1000+i%3))
+		require.NoError(t, state.Set("ENERGY", i*17+i%5))
+		require.NoError(t, queue.Push(state))
+	}
+	sizes := map[string]int{}
+	for _, pipeline := range []string{"t:zstd", "x:t:zstd", "d:t:zstd"} {
+		require.NoError(t, schema.setPipelines(pipeline))
+		data, err := queue.Encode()
+		require.NoError(t, err)
+		sizes[pipeline] = len(data)
+	}
+	t.Logf("sizes: %v", sizes)
+	require.Less(t, sizes["d:t:zstd"], sizes["t:zstd"])
+	require.Less(t, sizes["d:t:zstd"], sizes["x:t:zstd"])
+}
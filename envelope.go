@@ -0,0 +1,150 @@
+package bstates
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Envelope layout (all integers are big endian):
+//
+//	magic (4 bytes) | version (1 byte) | schema hash prefix (8 bytes) | number of states (4 bytes) | payload length (4 bytes) | payload
+//
+// The payload is the output of [StateQueue.Encode].
+const (
+	ENVELOPE_VERSION_1 = 1
+
+	EnvelopeHashPrefixSize = 8                                      // Number of bytes of [StateSchema.GetSHA256] stored in the envelope
+	EnvelopeHeaderSize     = 4 + 1 + EnvelopeHashPrefixSize + 4 + 4 // Size in bytes of the envelope header
+)
+
+var envelopeMagic = []byte("BSTQ")
+
+// ErrInvalidEnvelope represents a malformed envelope error
+var ErrInvalidEnvelope = errors.New("invalid envelope")
+
+// ErrSchemaMismatch represents an error caused by an envelope encoded with an unexpected schema
+var ErrSchemaMismatch = errors.New("schema mismatch")
+
+// SchemaMismatchError is returned when the schema hash stored in an envelope does not match
+// the schema (or any of the schemas) used to decode it. It matches [ErrSchemaMismatch] with errors.Is.
+type SchemaMismatchError struct {
+	HashPrefix []byte // Schema hash prefix found in the envelope
+}
+
+func (e *SchemaMismatchError) Error() string {
+	return fmt.Sprintf("%v: no schema found for hash prefix %x", ErrSchemaMismatch, e.HashPrefix)
+}
+
+func (e *SchemaMismatchError) Is(target error) bool {
+	return target == ErrSchemaMismatch
+}
+
+// EnvelopeHeader holds the information stored in the header of an envelope.
+type EnvelopeHeader struct {
+	Version       uint8  // Envelope format version
+	HashPrefix    []byte // First [EnvelopeHashPrefixSize] bytes of the schema SHA256
+	NumStates     uint32 // Number of states stored in the payload
+	PayloadLength uint32 // Length of the payload in bytes
+}
+
+// Matches reports whether the header was written using the provided schema.
+func (h *EnvelopeHeader) Matches(schema *StateSchema) bool {
+	hash := schema.GetSHA256()
+	return bytes.Equal(h.HashPrefix, hash[:EnvelopeHashPrefixSize])
+}
+
+// ParseEnvelopeHeader parses the header of an envelope and returns it along with the payload.
+func ParseEnvelopeHeader(data []byte) (header *EnvelopeHeader, payload []byte, err error) {
+	if len(data) < EnvelopeHeaderSize {
+		return nil, nil, fmt.Errorf("%w: %d bytes is less than the header size", ErrInvalidEnvelope, len(data))
+	}
+	if !bytes.Equal(data[:len(envelopeMagic)], envelopeMagic) {
+		return nil, nil, fmt.Errorf("%w: wrong magic", ErrInvalidEnvelope)
+	}
+	idx := len(envelopeMagic)
+	header = &EnvelopeHeader{}
+	header.Version = data[idx]
+	idx++
+	if header.Version != ENVELOPE_VERSION_1 {
+		return nil, nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidEnvelope, header.Version)
+	}
+	header.HashPrefix = make([]byte, EnvelopeHashPrefixSize)
+	copy(header.HashPrefix, data[idx:idx+EnvelopeHashPrefixSize])
+	idx += EnvelopeHashPrefixSize
+	header.NumStates = binary.BigEndian.Uint32(data[idx:])
+	idx += 4
+	header.PayloadLength = binary.BigEndian.Uint32(data[idx:])
+	idx += 4
+	if uint64(len(data)-idx) != uint64(header.PayloadLength) {
+		return nil, nil, fmt.Errorf("%w: payload length is %d but header says %d", ErrInvalidEnvelope, len(data)-idx, header.PayloadLength)
+	}
+	return header, data[idx:], nil
+}
+
+// EncodeEnvelope encodes the queue as [StateQueue.Encode] does and wraps the result in a self-describing envelope
+// which stores the schema hash prefix, the number of states and the payload length.
+func (s *StateQueue) EncodeEnvelope() ([]byte, error) {
+	payload, err := s.Encode()
+	if err != nil {
+		return nil, err
+	}
+	hash := s.StateSchema.GetSHA256()
+	out := make([]byte, 0, EnvelopeHeaderSize+len(payload))
+	out = append(out, envelopeMagic...)
+	out = append(out, ENVELOPE_VERSION_1)
+	out = append(out, hash[:EnvelopeHashPrefixSize]...)
+	lengths := make([]byte, 8)
+	binary.BigEndian.PutUint32(lengths[0:], uint32(s.GetNumStates()))
+	binary.BigEndian.PutUint32(lengths[4:], uint32(len(payload)))
+	out = append(out, lengths...)
+	out = append(out, payload...)
+	return out, nil
+}
+
+// DecodeEnvelope [Clear] the queue and populates it from an envelope created with [StateQueue.EncodeEnvelope].
+//
+// Returns a [SchemaMismatchError] if the envelope was not created with the schema of the queue.
+func (s *StateQueue) DecodeEnvelope(data []byte) error {
+	header, payload, err := ParseEnvelopeHeader(data)
+	if err != nil {
+		return err
+	}
+	if !header.Matches(s.StateSchema) {
+		return &SchemaMismatchError{HashPrefix: header.HashPrefix}
+	}
+	return s.decodeEnvelopePayload(header, payload)
+}
+
+func (s *StateQueue) decodeEnvelopePayload(header *EnvelopeHeader, payload []byte) error {
+	if err := s.Decode(payload); err != nil {
+		return err
+	}
+	if numStates := s.GetNumStates(); numStates != int(header.NumStates) {
+		return fmt.Errorf("%w: decoded %d states but header says %d", ErrInvalidEnvelope, numStates, header.NumStates)
+	}
+	return nil
+}
+
+// DecodeEnvelope decodes an envelope created with [StateQueue.EncodeEnvelope] picking the schema
+// whose hash matches the one stored in the envelope.
+//
+// Returns a [SchemaMismatchError] if none of the schemas provided matches.
+func DecodeEnvelope(data []byte, schemas []*StateSchema) (*StateQueue, error) {
+	header, payload, err := ParseEnvelopeHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, schema := range schemas {
+		if !header.Matches(schema) {
+			continue
+		}
+		queue := CreateStateQueue(schema)
+		if err = queue.decodeEnvelopePayload(header, payload); err != nil {
+			return nil, err
+		}
+		return queue, nil
+	}
+	return nil, &SchemaMismatchError{HashPrefix: header.HashPrefix}
+}
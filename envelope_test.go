@@ -0,0 +1,109 @@
+package bstates
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Envelope(t *testing.T) {
+	schema := testPipelineComparativeCreateSchema(t, "t:z")
+	otherSchema := testPipelineComparativeCreateSchema(t, "z")
+
+	queue := CreateStateQueue(schema)
+	states := []*State{}
+	for i := 0; i < 100; i++ {
+		state, err := schema.CreateState()
+		require.NoError(t, err)
+		err = state.Set("F_COUNTER", i)
+		require.NoError(t, err)
+		states = append(states, state)
+	}
+	err := queue.PushAll(states)
+	require.NoError(t, err)
+
+	data, err := queue.EncodeEnvelope()
+	require.NoError(t, err)
+
+	header, payload, err := ParseEnvelopeHeader(data)
+	require.NoError(t, err)
+	require.Equal(t, uint8(ENVELOPE_VERSION_1), header.Version)
+	require.Equal(t, uint32(100), header.NumStates)
+	require.Equal(t, int(header.PayloadLength), len(payload))
+	require.True(t, header.Matches(schema))
+	require.False(t, header.Matches(otherSchema))
+
+	// Decode using the queue method
+	dqueue := CreateStateQueue(schema)
+	err = dqueue.DecodeEnvelope(data)
+	require.NoError(t, err)
+	dstates, err := dqueue.GetStates()
+	require.NoError(t, err)
+	testEqualStates(t, states, dstates)
+
+	// Decode picking the schema from a set
+	dqueue, err = DecodeEnvelope(data, []*StateSchema{otherSchema, schema})
+	require.NoError(t, err)
+	require.Equal(t, schema, dqueue.StateSchema)
+	dstates, err = dqueue.GetStates()
+	require.NoError(t, err)
+	testEqualStates(t, states, dstates)
+
+	// Schema mismatch
+	err = CreateStateQueue(otherSchema).DecodeEnvelope(data)
+	require.True(t, errors.Is(err, ErrSchemaMismatch))
+	var mismatchErr *SchemaMismatchError
+	require.True(t, errors.As(err, &mismatchErr))
+	require.Equal(t, header.HashPrefix, mismatchErr.HashPrefix)
+
+	_, err = DecodeEnvelope(data, []*StateSchema{otherSchema})
+	require.True(t, errors.Is(err, ErrSchemaMismatch))
+}
+
+func Test_Envelope_Empty(t *testing.T) {
+	schema := testPipelineComparativeCreateSchema(t, "t:z")
+	data, err := CreateStateQueue(schema).EncodeEnvelope()
+	require.NoError(t, err)
+
+	queue, err := DecodeEnvelope(data, []*StateSchema{schema})
+	require.NoError(t, err)
+	require.Equal(t, 0, queue.GetNumStates())
+}
+
+func Test_Envelope_Invalid(t *testing.T) {
+	schema := testPipelineComparativeCreateSchema(t, "")
+	queue := CreateStateQueue(schema)
+	state, err := schema.CreateState()
+	require.NoError(t, err)
+	err = queue.Push(state)
+	require.NoError(t, err)
+	data, err := queue.EncodeEnvelope()
+	require.NoError(t, err)
+
+	// Too short
+	_, err = DecodeEnvelope(data[:EnvelopeHeaderSize-1], []*StateSchema{schema})
+	require.True(t, errors.Is(err, ErrInvalidEnvelope))
+
+	// Wrong magic
+	corrupted := append([]byte{}, data...)
+	corrupted[0] = 'X'
+	_, err = DecodeEnvelope(corrupted, []*StateSchema{schema})
+	require.True(t, errors.Is(err, ErrInvalidEnvelope))
+
+	// Unsupported version
+	corrupted = append([]byte{}, data...)
+	corrupted[4] = 99
+	_, err = DecodeEnvelope(corrupted, []*StateSchema{schema})
+	require.True(t, errors.Is(err, ErrInvalidEnvelope))
+
+	// Truncated payload
+	_, err = DecodeEnvelope(data[:len(data)-1], []*StateSchema{schema})
+	require.True(t, errors.Is(err, ErrInvalidEnvelope))
+
+	// Wrong number of states
+	corrupted = append([]byte{}, data...)
+	corrupted[4+1+EnvelopeHashPrefixSize+3] = 2
+	_, err = DecodeEnvelope(corrupted, []*StateSchema{schema})
+	require.True(t, errors.Is(err, ErrInvalidEnvelope))
+}
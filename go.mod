@@ -6,6 +6,7 @@ require (
 	github.com/jaracil/ei v0.0.0-20170808175009-4f519a480ebd
 	github.com/klauspost/compress v1.16.7
 	github.com/nayarsystems/buffer v0.1.1
+	github.com/pierrec/lz4/v4 v4.1.21
 	github.com/stretchr/testify v1.8.2
 )
 
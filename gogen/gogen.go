@@ -0,0 +1,378 @@
+/*
+Package gogen generates typed Go code for a [bstates.StateSchema].
+
+For a schema with the fields TEMP (fixed) and STATE_CODE (uint) and the IntMap decoded field STATE, [Generate] with
+the type name "Status" outputs:
+
+  - StatusSchemaHash and NewStatusSchema, which returns the schema embedded in the generated file.
+  - Status, a struct with one field per schema field (bound using the "bstates" struct tags, see [bstates.StructTagName]).
+  - StatusState, a wrapper over [bstates.State] with typed accessors like GetTemp() float64 and SetState(v string) error.
+  - NewStatusState and WrapStatusState to create and wrap states.
+
+Referencing a field which is not defined in the schema becomes a compile error.
+*/
+package gogen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/nayarsystems/bstates"
+)
+
+// Options of the code generator.
+type Options struct {
+	Package  string // Package name of the generated file
+	TypeName string // Name of the generated struct type (other identifiers are prefixed with it)
+}
+
+// genField describes the accessors generated for a schema field.
+type genField struct {
+	Name     string // Name of the field in the schema
+	GoName   string // Go identifier
+	GoType   string // Type returned by the getter and used in the struct
+	Comment  string
+	Decoded  bool
+	ReadOnly bool              // No setter is generated
+	IntMap   map[string]string // Values of IntMap decoded fields (value -> Go literal of the raw value)
+	From     string            // Field encoded by IntMap setters
+}
+
+// Zero returns the zero value of the field type.
+func (f *genField) Zero() string {
+	switch f.GoType {
+	case "bool":
+		return "false"
+	case "string":
+		return `""`
+	case "int64", "uint64", "float32", "float64":
+		return "0"
+	case "bstates.GeoPoint":
+		return "bstates.GeoPoint{}"
+	}
+	return "nil"
+}
+
+// IntMapKeys returns the IntMap values in order.
+func (f *genField) IntMapKeys() []string {
+	keys := make([]string, 0, len(f.IntMap))
+	for k := range f.IntMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type templateData struct {
+	Package    string
+	Type       string
+	Hash       string
+	SchemaJSON string
+	Fields     []*genField
+}
+
+// Generate returns the formatted source of a Go file with typed accessors for the schema.
+func Generate(schema *bstates.StateSchema, opts Options) ([]byte, error) {
+	if !token.IsIdentifier(opts.Package) {
+		return nil, fmt.Errorf("invalid package name \"%s\"", opts.Package)
+	}
+	if !token.IsIdentifier(opts.TypeName) || !token.IsExported(opts.TypeName) {
+		return nil, fmt.Errorf("invalid type name \"%s\" (must be an exported identifier)", opts.TypeName)
+	}
+	schemaJSON, err := schema.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	data := &templateData{
+		Package:    opts.Package,
+		Type:       opts.TypeName,
+		Hash:       schema.GetHashString(),
+		SchemaJSON: goString(string(schemaJSON)),
+	}
+
+	// "Values" is reserved by the Values and SetValues methods
+	goNames := map[string]string{"Values": "(reserved)"}
+	addField := func(f *genField) error {
+		if prev, ok := goNames[f.GoName]; ok {
+			return fmt.Errorf("fields \"%s\" and \"%s\" have the same Go name %s", prev, f.Name, f.GoName)
+		}
+		goNames[f.GoName] = f.Name
+		data.Fields = append(data.Fields, f)
+		return nil
+	}
+	for _, field := range schema.GetFields() {
+		f := &genField{Name: field.Name, GoName: GoName(field.Name)}
+		switch field.Type {
+		case bstates.T_INT:
+			f.GoType = "int64"
+		case bstates.T_UINT:
+			f.GoType = "uint64"
+		case bstates.T_FIXED, bstates.T_UFIXED, bstates.T_FLOAT64:
+			f.GoType = "float64"
+		case bstates.T_FLOAT32:
+			f.GoType = "float32"
+		case bstates.T_BOOL:
+			f.GoType = "bool"
+		case bstates.T_BUFFER:
+			f.GoType = "[]byte"
+		default:
+			return nil, fmt.Errorf("field \"%s\": unsupported type %v", field.Name, field.Type)
+		}
+		f.Comment = fieldComment(field)
+		if err := addField(f); err != nil {
+			return nil, err
+		}
+	}
+
+	decodedFields := schema.GetDecodedFields()
+	sort.Slice(decodedFields, func(i, j int) bool { return decodedFields[i].Name < decodedFields[j].Name })
+	for _, df := range decodedFields {
+		f := &genField{Name: df.Name, GoName: GoName(df.Name), Decoded: true}
+		f.Comment = fmt.Sprintf("%s decoder", df.Decoder.Name())
+		switch d := df.Decoder.(type) {
+		case *bstates.BufferToStringDecoder:
+			f.GoType = "string"
+			f.Comment += fmt.Sprintf(" from %s", d.From)
+		case *bstates.NumberToUnixTsMsDecoder:
+			f.GoType = "uint64"
+			f.Comment += fmt.Sprintf(" from %s (unix time in milliseconds)", d.From)
+		case *bstates.FlagsDecoder:
+			f.GoType = "[]string"
+			f.Comment += fmt.Sprintf(" from %s", d.From)
+		case *bstates.RangeMapDecoder:
+			f.GoType = "string"
+			f.Comment += fmt.Sprintf(" from %s (range map %s)", d.From, d.MapId)
+			f.ReadOnly = true
+		case *bstates.BitSliceDecoder:
+			f.GoType = "uint64"
+			if d.Signed {
+				f.GoType = "int64"
+			}
+			f.Comment += fmt.Sprintf(" from %s (bits %d-%d)", d.From, d.Offset, d.Offset+d.Length-1)
+		case *bstates.ConcatDecoder:
+			f.GoType = "uint64"
+			if d.Signed {
+				f.GoType = "int64"
+			}
+			f.Comment += fmt.Sprintf(" from %s", strings.Join(d.From, ", "))
+		case *bstates.GeoPointDecoder:
+			f.GoType = "bstates.GeoPoint"
+			f.Comment += fmt.Sprintf(" from %s", strings.Join(d.From, ", "))
+		case *bstates.LinearDecoder:
+			f.GoType = "float64"
+			f.Comment += fmt.Sprintf(" from %s", d.From)
+			if d.Unit != "" {
+				f.Comment += fmt.Sprintf(" (%s)", d.Unit)
+			}
+		case *bstates.IntMapDecoder:
+			f.Comment += fmt.Sprintf(" from %s (map %s)", d.From, d.MapId)
+			intMap, ok := schema.GetDecoderIntMap(d.MapId)
+			if !ok {
+				return nil, fmt.Errorf("field \"%s\": int map \"%s\" not found", df.Name, d.MapId)
+			}
+			f.GoType, f.IntMap = intMapValues(intMap)
+			f.From = d.From
+			f.ReadOnly = f.IntMap == nil
+		default:
+			f.GoType = "any"
+		}
+		if err := addField(f); err != nil {
+			return nil, err
+		}
+	}
+
+	w := &bytes.Buffer{}
+	if err := fileTemplate.Execute(w, data); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(w.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("can't format generated code: %v", err)
+	}
+	return src, nil
+}
+
+// intMapValues returns the Go type of the values of an int map and, if every value is a string, a map
+// from value to raw value used to generate the setter.
+func intMapValues(m map[int64]any) (goType string, values map[string]string) {
+	if len(m) == 0 {
+		return "any", nil
+	}
+	values = map[string]string{}
+	for k, v := range m {
+		s, ok := v.(string)
+		if !ok {
+			return "any", nil
+		}
+		if _, dup := values[s]; dup {
+			// Ambiguous value, it can't be encoded
+			return "string", nil
+		}
+		values[s] = strconv.FormatInt(k, 10)
+	}
+	return "string", values
+}
+
+func fieldComment(f *bstates.StateField) string {
+	msi, err := f.ToMsi()
+	if err != nil {
+		return ""
+	}
+	c := fmt.Sprintf("%v field of %d bits", msi["type"], f.Size)
+	if f.Type == bstates.T_FIXED || f.Type == bstates.T_UFIXED {
+		c += fmt.Sprintf(" (%d decimals)", f.Decimals)
+	}
+	if len(f.Aliases) > 0 {
+		c += fmt.Sprintf(", aliases: %s", strings.Join(f.Aliases, ", "))
+	}
+	return c
+}
+
+// GoName converts a schema field name (usually in upper snake case) into an exported Go identifier,
+// for example "STATE_CODE" is converted into "StateCode". Names not starting with a letter are prefixed with "F".
+func GoName(name string) string {
+	out := []rune{}
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			out = append(out, unicode.ToUpper(r))
+		} else {
+			out = append(out, unicode.ToLower(r))
+		}
+		upper = unicode.IsDigit(r)
+	}
+	if len(out) == 0 || !unicode.IsLetter(out[0]) {
+		out = append([]rune{'F'}, out...)
+	}
+	return string(out)
+}
+
+// goString returns a Go literal of s, using a raw string when possible.
+func goString(s string) string {
+	if strings.Contains(s, "`") || strings.Contains(s, "\r") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+var fileTemplate = template.Must(template.New("file").Parse(`// Code generated by bstates-gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/nayarsystems/bstates"
+)
+
+// {{.Type}}SchemaHash is the hash of the schema used to generate this file.
+const {{.Type}}SchemaHash = "{{.Hash}}"
+
+const {{.Type}}SchemaJSON = {{.SchemaJSON}}
+
+// New{{.Type}}Schema returns the schema used to generate this file.
+func New{{.Type}}Schema() (*bstates.StateSchema, error) {
+	schema := &bstates.StateSchema{}
+	if err := json.Unmarshal([]byte({{.Type}}SchemaJSON), schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// {{.Type}} holds the values of a state. Use [bstates.State.Unmarshal] and [bstates.State.Marshal] to
+// copy the values from and to a state (decoded fields are read-only).
+type {{.Type}} struct {
+{{- range .Fields}}
+	{{.GoName}} {{.GoType}} ` + "`" + `bstates:"{{.Name}}{{if .Decoded}},readonly{{end}}"` + "`" + ` // {{.Comment}}
+{{- end}}
+}
+
+// {{.Type}}State provides typed access to the fields of a state of the schema.
+type {{.Type}}State struct {
+	state *bstates.State
+}
+
+// New{{.Type}}State creates a state with the default values of the schema.
+func New{{.Type}}State() (*{{.Type}}State, error) {
+	schema, err := New{{.Type}}Schema()
+	if err != nil {
+		return nil, err
+	}
+	state, err := schema.CreateState()
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Type}}State{state: state}, nil
+}
+
+// Wrap{{.Type}}State returns typed accessors for the state, which must have been created with the same schema.
+func Wrap{{.Type}}State(state *bstates.State) (*{{.Type}}State, error) {
+	if hash := state.GetSchema().GetHashString(); hash != {{.Type}}SchemaHash {
+		return nil, fmt.Errorf("schema mismatch: got %s, expected %s", hash, {{.Type}}SchemaHash)
+	}
+	return &{{.Type}}State{state: state}, nil
+}
+
+// State returns the wrapped state.
+func (s *{{.Type}}State) State() *bstates.State {
+	return s.state
+}
+
+// Values returns the values of the state.
+func (s *{{.Type}}State) Values() (*{{.Type}}, error) {
+	v := &{{.Type}}{}
+	if err := s.state.Unmarshal(v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// SetValues sets the fields of the state (decoded fields are ignored).
+func (s *{{.Type}}State) SetValues(v *{{.Type}}) error {
+	return s.state.Marshal(v)
+}
+{{range .Fields}}{{$f := .}}
+// Get{{.GoName}} returns the value of {{.Name}} ({{.Comment}}).
+func (s *{{$.Type}}State) Get{{.GoName}}() {{.GoType}} {
+	v, err := s.state.Get("{{.Name}}")
+	if err != nil {
+		return {{.Zero}}
+	}
+{{- if eq .GoType "any"}}
+	return v
+{{- else}}
+	typed, _ := v.({{.GoType}})
+	return typed
+{{- end}}
+}
+{{if not .ReadOnly}}
+// Set{{.GoName}} sets the value of {{.Name}}.
+func (s *{{$.Type}}State) Set{{.GoName}}(v {{.GoType}}) error {
+{{- if .IntMap}}
+	var raw int64
+	switch v {
+{{- range .IntMapKeys}}
+	case {{printf "%q" .}}:
+		raw = {{index $f.IntMap .}}
+{{- end}}
+	default:
+		return fmt.Errorf("unknown {{.Name}} value %q", v)
+	}
+	return s.state.Set("{{.From}}", raw)
+{{- else}}
+	return s.state.Set("{{.Name}}", v)
+{{- end}}
+}
+{{end}}{{end}}`))
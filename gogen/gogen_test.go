@@ -0,0 +1,73 @@
+package gogen
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nayarsystems/bstates"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Generate_Golden(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", "status.json"))
+	require.NoError(t, err)
+	var schema bstates.StateSchema
+	require.NoError(t, json.Unmarshal(raw, &schema))
+
+	src, err := Generate(&schema, Options{Package: "example", TypeName: "Status"})
+	require.NoError(t, err)
+	// internal/example/status_gen.go is generated with go:generate and tested in its package
+	expected, err := os.ReadFile(filepath.Join("internal", "example", "status_gen.go"))
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(src))
+}
+
+func Test_GoName(t *testing.T) {
+	require.Equal(t, "StateCode", GoName("STATE_CODE"))
+	require.Equal(t, "F48BitSecs", GoName("48BIT_SECS"))
+	require.Equal(t, "TempC", GoName("temp.c"))
+	require.Equal(t, "Level2Max", GoName("LEVEL2_MAX"))
+	require.Equal(t, "F", GoName("_"))
+}
+
+func Test_Generate_Errors(t *testing.T) {
+	schema, err := bstates.CreateStateSchema(&bstates.StateSchemaParams{
+		Fields: []bstates.StateField{{Name: "A", Type: bstates.T_UINT, Size: 4}},
+	})
+	require.NoError(t, err)
+	_, err = Generate(schema, Options{Package: "", TypeName: "Status"})
+	require.Error(t, err)
+	_, err = Generate(schema, Options{Package: "status", TypeName: "status"})
+	require.Error(t, err)
+	_, err = Generate(schema, Options{Package: "status", TypeName: "Status"})
+	require.NoError(t, err)
+
+	collision, err := bstates.CreateStateSchema(&bstates.StateSchemaParams{
+		Fields: []bstates.StateField{
+			{Name: "STATE_CODE", Type: bstates.T_UINT, Size: 4},
+			{Name: "state.code", Type: bstates.T_UINT, Size: 4},
+		},
+	})
+	require.NoError(t, err)
+	_, err = Generate(collision, Options{Package: "status", TypeName: "Status"})
+	require.Error(t, err)
+
+	reserved, err := bstates.CreateStateSchema(&bstates.StateSchemaParams{
+		Fields: []bstates.StateField{{Name: "VALUES", Type: bstates.T_UINT, Size: 4}},
+	})
+	require.NoError(t, err)
+	_, err = Generate(reserved, Options{Package: "status", TypeName: "Status"})
+	require.Error(t, err)
+
+	missingMap, err := bstates.CreateStateSchema(&bstates.StateSchemaParams{
+		Fields: []bstates.StateField{{Name: "A", Type: bstates.T_UINT, Size: 4}},
+		DecodedFields: []bstates.DecodedStateField{
+			{Name: "A_LABEL", Decoder: &bstates.IntMapDecoder{From: "A", MapId: "MISSING"}},
+		},
+	})
+	require.NoError(t, err)
+	_, err = Generate(missingMap, Options{Package: "status", TypeName: "Status"})
+	require.ErrorContains(t, err, "MISSING")
+}
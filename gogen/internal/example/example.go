@@ -0,0 +1,4 @@
+// Package example contains the code generated by bstates-gen for testdata/status.json.
+package example
+
+//go:generate go run ../../../cmd/bstates-gen -schema ../../testdata/status.json -package example -type Status -out status_gen.go
@@ -0,0 +1,76 @@
+package example
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/nayarsystems/bstates"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GeneratedCode(t *testing.T) {
+	s, err := NewStatusState()
+	require.NoError(t, err)
+
+	require.NoError(t, s.SetTemp(-12.3))
+	require.NoError(t, s.SetEnabled(true))
+	require.NoError(t, s.SetOffset(-7))
+	require.NoError(t, s.SetRatio(0.25))
+	require.NoError(t, s.SetMessage("hello"))
+	require.NoError(t, s.SetFlags([]string{"LOW_BATTERY"}))
+	require.NoError(t, s.SetState("RUNNING"))
+	require.Error(t, s.SetState("FLYING"))
+	ts := uint64(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli())
+	require.NoError(t, s.SetTimestampMs(ts))
+	require.Error(t, s.SetOffset(100))
+
+	require.Equal(t, -12.3, s.GetTemp())
+	require.True(t, s.GetEnabled())
+	require.Equal(t, int64(-7), s.GetOffset())
+	require.Equal(t, float32(0.25), s.GetRatio())
+	require.Equal(t, "hello", s.GetMessage())
+	require.Equal(t, []string{"LOW_BATTERY"}, s.GetFlags())
+	require.Equal(t, uint64(2), s.GetStateCode())
+	require.Equal(t, "RUNNING", s.GetState())
+	require.Equal(t, ts, s.GetTimestampMs())
+	require.Equal(t, uint64(2), s.GetFlagsRaw())
+
+	v, err := s.Values()
+	require.NoError(t, err)
+	require.Equal(t, "RUNNING", v.State)
+	require.Equal(t, -12.3, v.Temp)
+	v.StateCode = 1
+	v.Temp = 4.5
+	require.NoError(t, s.SetValues(v))
+	require.Equal(t, "STOPPED", s.GetState())
+	require.Equal(t, 4.5, s.GetTemp())
+}
+
+func Test_GeneratedSchema(t *testing.T) {
+	raw, err := os.ReadFile("../../testdata/status.json")
+	require.NoError(t, err)
+	var schema bstates.StateSchema
+	require.NoError(t, json.Unmarshal(raw, &schema))
+	require.Equal(t, StatusSchemaHash, schema.GetHashString())
+
+	embedded, err := NewStatusSchema()
+	require.NoError(t, err)
+	require.Equal(t, StatusSchemaHash, embedded.GetHashString())
+
+	state, err := schema.CreateState()
+	require.NoError(t, err)
+	s, err := WrapStatusState(state)
+	require.NoError(t, err)
+	require.Same(t, state, s.State())
+
+	other, err := bstates.CreateStateSchema(&bstates.StateSchemaParams{
+		Fields: []bstates.StateField{{Name: "TEMP", Type: bstates.T_INT, Size: 8}},
+	})
+	require.NoError(t, err)
+	otherState, err := other.CreateState()
+	require.NoError(t, err)
+	_, err = WrapStatusState(otherState)
+	require.Error(t, err)
+}
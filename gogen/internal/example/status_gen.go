@@ -0,0 +1,277 @@
+// Code generated by bstates-gen. DO NOT EDIT.
+
+package example
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/nayarsystems/bstates"
+)
+
+// StatusSchemaHash is the hash of the schema used to generate this file.
+const StatusSchemaHash = "oYS6/HLHfffXATswEhePePJ1xYr3p+WLASlDK9+Qpdc="
+
+const StatusSchemaJSON = `{"decodedFields":[{"decoder":"Flags","name":"FLAGS","params":{"flags":{"DOOR_OPEN":0,"LOW_BATTERY":1},"from":"FLAGS_RAW"}},{"decoder":"BufferToString","name":"MESSAGE","params":{"from":"MESSAGE_BUFFER"}},{"decoder":"IntMap","name":"STATE","params":{"from":"STATE_CODE","mapId":"STATE_MAP"}},{"decoder":"NumberToUnixTsMs","name":"TIMESTAMP_MS","params":{"factor":1000,"from":"48BIT_SECS_FROM_2022","year":2022}}],"decoderIntMaps":{"STATE_MAP":{"0":"IDLE","1":"STOPPED","2":"RUNNING"}},"encoderPipeline":"t:z","fields":[{"defaultValue":0,"name":"STATE_CODE","size":2,"type":"uint"},{"decimals":1,"defaultValue":0,"name":"TEMP","size":12,"type":"fixed"},{"defaultValue":false,"name":"ENABLED","size":1,"type":"bool"},{"defaultValue":0,"name":"OFFSET","size":5,"type":"int"},{"defaultValue":0,"name":"RATIO","size":32,"type":"float32"},{"defaultValue":0,"name":"FLAGS_RAW","size":2,"type":"uint"},{"defaultValue":"AAAAAAAAAAA=","name":"MESSAGE_BUFFER","size":64,"type":"buffer"},{"defaultValue":0,"name":"48BIT_SECS_FROM_2022","size":48,"type":"uint"}],"version":"2.0"}`
+
+// NewStatusSchema returns the schema used to generate this file.
+func NewStatusSchema() (*bstates.StateSchema, error) {
+	schema := &bstates.StateSchema{}
+	if err := json.Unmarshal([]byte(StatusSchemaJSON), schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// Status holds the values of a state. Use [bstates.State.Unmarshal] and [bstates.State.Marshal] to
+// copy the values from and to a state (decoded fields are read-only).
+type Status struct {
+	StateCode          uint64   `bstates:"STATE_CODE"`            // uint field of 2 bits
+	Temp               float64  `bstates:"TEMP"`                  // fixed field of 12 bits (1 decimals)
+	Enabled            bool     `bstates:"ENABLED"`               // bool field of 1 bits
+	Offset             int64    `bstates:"OFFSET"`                // int field of 5 bits
+	Ratio              float32  `bstates:"RATIO"`                 // float32 field of 32 bits
+	FlagsRaw           uint64   `bstates:"FLAGS_RAW"`             // uint field of 2 bits
+	MessageBuffer      []byte   `bstates:"MESSAGE_BUFFER"`        // buffer field of 64 bits
+	F48BitSecsFrom2022 uint64   `bstates:"48BIT_SECS_FROM_2022"`  // uint field of 48 bits
+	Flags              []string `bstates:"FLAGS,readonly"`        // Flags decoder from FLAGS_RAW
+	Message            string   `bstates:"MESSAGE,readonly"`      // BufferToString decoder from MESSAGE_BUFFER
+	State              string   `bstates:"STATE,readonly"`        // IntMap decoder from STATE_CODE (map STATE_MAP)
+	TimestampMs        uint64   `bstates:"TIMESTAMP_MS,readonly"` // NumberToUnixTsMs decoder from 48BIT_SECS_FROM_2022 (unix time in milliseconds)
+}
+
+// StatusState provides typed access to the fields of a state of the schema.
+type StatusState struct {
+	state *bstates.State
+}
+
+// NewStatusState creates a state with the default values of the schema.
+func NewStatusState() (*StatusState, error) {
+	schema, err := NewStatusSchema()
+	if err != nil {
+		return nil, err
+	}
+	state, err := schema.CreateState()
+	if err != nil {
+		return nil, err
+	}
+	return &StatusState{state: state}, nil
+}
+
+// WrapStatusState returns typed accessors for the state, which must have been created with the same schema.
+func WrapStatusState(state *bstates.State) (*StatusState, error) {
+	if hash := state.GetSchema().GetHashString(); hash != StatusSchemaHash {
+		return nil, fmt.Errorf("schema mismatch: got %s, expected %s", hash, StatusSchemaHash)
+	}
+	return &StatusState{state: state}, nil
+}
+
+// State returns the wrapped state.
+func (s *StatusState) State() *bstates.State {
+	return s.state
+}
+
+// Values returns the values of the state.
+func (s *StatusState) Values() (*Status, error) {
+	v := &Status{}
+	if err := s.state.Unmarshal(v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// SetValues sets the fields of the state (decoded fields are ignored).
+func (s *StatusState) SetValues(v *Status) error {
+	return s.state.Marshal(v)
+}
+
+// GetStateCode returns the value of STATE_CODE (uint field of 2 bits).
+func (s *StatusState) GetStateCode() uint64 {
+	v, err := s.state.Get("STATE_CODE")
+	if err != nil {
+		return 0
+	}
+	typed, _ := v.(uint64)
+	return typed
+}
+
+// SetStateCode sets the value of STATE_CODE.
+func (s *StatusState) SetStateCode(v uint64) error {
+	return s.state.Set("STATE_CODE", v)
+}
+
+// GetTemp returns the value of TEMP (fixed field of 12 bits (1 decimals)).
+func (s *StatusState) GetTemp() float64 {
+	v, err := s.state.Get("TEMP")
+	if err != nil {
+		return 0
+	}
+	typed, _ := v.(float64)
+	return typed
+}
+
+// SetTemp sets the value of TEMP.
+func (s *StatusState) SetTemp(v float64) error {
+	return s.state.Set("TEMP", v)
+}
+
+// GetEnabled returns the value of ENABLED (bool field of 1 bits).
+func (s *StatusState) GetEnabled() bool {
+	v, err := s.state.Get("ENABLED")
+	if err != nil {
+		return false
+	}
+	typed, _ := v.(bool)
+	return typed
+}
+
+// SetEnabled sets the value of ENABLED.
+func (s *StatusState) SetEnabled(v bool) error {
+	return s.state.Set("ENABLED", v)
+}
+
+// GetOffset returns the value of OFFSET (int field of 5 bits).
+func (s *StatusState) GetOffset() int64 {
+	v, err := s.state.Get("OFFSET")
+	if err != nil {
+		return 0
+	}
+	typed, _ := v.(int64)
+	return typed
+}
+
+// SetOffset sets the value of OFFSET.
+func (s *StatusState) SetOffset(v int64) error {
+	return s.state.Set("OFFSET", v)
+}
+
+// GetRatio returns the value of RATIO (float32 field of 32 bits).
+func (s *StatusState) GetRatio() float32 {
+	v, err := s.state.Get("RATIO")
+	if err != nil {
+		return 0
+	}
+	typed, _ := v.(float32)
+	return typed
+}
+
+// SetRatio sets the value of RATIO.
+func (s *StatusState) SetRatio(v float32) error {
+	return s.state.Set("RATIO", v)
+}
+
+// GetFlagsRaw returns the value of FLAGS_RAW (uint field of 2 bits).
+func (s *StatusState) GetFlagsRaw() uint64 {
+	v, err := s.state.Get("FLAGS_RAW")
+	if err != nil {
+		return 0
+	}
+	typed, _ := v.(uint64)
+	return typed
+}
+
+// SetFlagsRaw sets the value of FLAGS_RAW.
+func (s *StatusState) SetFlagsRaw(v uint64) error {
+	return s.state.Set("FLAGS_RAW", v)
+}
+
+// GetMessageBuffer returns the value of MESSAGE_BUFFER (buffer field of 64 bits).
+func (s *StatusState) GetMessageBuffer() []byte {
+	v, err := s.state.Get("MESSAGE_BUFFER")
+	if err != nil {
+		return nil
+	}
+	typed, _ := v.([]byte)
+	return typed
+}
+
+// SetMessageBuffer sets the value of MESSAGE_BUFFER.
+func (s *StatusState) SetMessageBuffer(v []byte) error {
+	return s.state.Set("MESSAGE_BUFFER", v)
+}
+
+// GetF48BitSecsFrom2022 returns the value of 48BIT_SECS_FROM_2022 (uint field of 48 bits).
+func (s *StatusState) GetF48BitSecsFrom2022() uint64 {
+	v, err := s.state.Get("48BIT_SECS_FROM_2022")
+	if err != nil {
+		return 0
+	}
+	typed, _ := v.(uint64)
+	return typed
+}
+
+// SetF48BitSecsFrom2022 sets the value of 48BIT_SECS_FROM_2022.
+func (s *StatusState) SetF48BitSecsFrom2022(v uint64) error {
+	return s.state.Set("48BIT_SECS_FROM_2022", v)
+}
+
+// GetFlags returns the value of FLAGS (Flags decoder from FLAGS_RAW).
+func (s *StatusState) GetFlags() []string {
+	v, err := s.state.Get("FLAGS")
+	if err != nil {
+		return nil
+	}
+	typed, _ := v.([]string)
+	return typed
+}
+
+// SetFlags sets the value of FLAGS.
+func (s *StatusState) SetFlags(v []string) error {
+	return s.state.Set("FLAGS", v)
+}
+
+// GetMessage returns the value of MESSAGE (BufferToString decoder from MESSAGE_BUFFER).
+func (s *StatusState) GetMessage() string {
+	v, err := s.state.Get("MESSAGE")
+	if err != nil {
+		return ""
+	}
+	typed, _ := v.(string)
+	return typed
+}
+
+// SetMessage sets the value of MESSAGE.
+func (s *StatusState) SetMessage(v string) error {
+	return s.state.Set("MESSAGE", v)
+}
+
+// GetState returns the value of STATE (IntMap decoder from STATE_CODE (map STATE_MAP)).
+func (s *StatusState) GetState() string {
+	v, err := s.state.Get("STATE")
+	if err != nil {
+		return ""
+	}
+	typed, _ := v.(string)
+	return typed
+}
+
+// SetState sets the value of STATE.
+func (s *StatusState) SetState(v string) error {
+	var raw int64
+	switch v {
+	case "IDLE":
+		raw = 0
+	case "RUNNING":
+		raw = 2
+	case "STOPPED":
+		raw = 1
+	default:
+		return fmt.Errorf("unknown STATE value %q", v)
+	}
+	return s.state.Set("STATE_CODE", raw)
+}
+
+// GetTimestampMs returns the value of TIMESTAMP_MS (NumberToUnixTsMs decoder from 48BIT_SECS_FROM_2022 (unix time in milliseconds)).
+func (s *StatusState) GetTimestampMs() uint64 {
+	v, err := s.state.Get("TIMESTAMP_MS")
+	if err != nil {
+		return 0
+	}
+	typed, _ := v.(uint64)
+	return typed
+}
+
+// SetTimestampMs sets the value of TIMESTAMP_MS.
+func (s *StatusState) SetTimestampMs(v uint64) error {
+	return s.state.Set("TIMESTAMP_MS", v)
+}
@@ -3,6 +3,7 @@ package bstates
 import (
 	"bytes"
 	"compress/gzip"
+	"fmt"
 	"io"
 
 	"github.com/nayarsystems/buffer/buffer"
@@ -11,8 +12,13 @@ import (
 // GzipEnc compresses the provided buffer using Gzip compression and
 // returns a new buffer containing the compressed data.
 func GzipEnc(b *buffer.Buffer) (*buffer.Buffer, error) {
+	return GzipEncLevel(b, gzip.BestCompression)
+}
+
+// GzipEncLevel is like [GzipEnc] but using the provided compression level (see [gzip.NewWriterLevel]).
+func GzipEncLevel(b *buffer.Buffer, level int) (*buffer.Buffer, error) {
 	buf := new(bytes.Buffer)
-	wr, err := gzip.NewWriterLevel(buf, gzip.BestCompression)
+	wr, err := gzip.NewWriterLevel(buf, level)
 	if err != nil {
 		return nil, err
 	}
@@ -50,3 +56,41 @@ func GzipDec(b *buffer.Buffer) (*buffer.Buffer, error) {
 	outb.InitFromRawBuffer(out)
 	return outb, nil
 }
+
+// gzipModifier is the [MOD_GZIP] modifier. The compression level can be set with the level parameter.
+type gzipModifier struct{}
+
+func (gzipModifier) ValidateParams(step PipelineStep) error {
+	if err := checkParams(step, "level"); err != nil {
+		return err
+	}
+	level, err := step.GetIntParam("level", gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		return fmt.Errorf("modifier \"%s\": level must be between %d and %d", step.Modifier, gzip.HuffmanOnly, gzip.BestCompression)
+	}
+	return nil
+}
+
+func (gzipModifier) level(step PipelineStep) int {
+	level, _ := step.GetIntParam("level", gzip.BestCompression)
+	return level
+}
+
+func (m gzipModifier) Encode(b *buffer.Buffer, schema *StateSchema, step PipelineStep) (*buffer.Buffer, error) {
+	return GzipEncLevel(b, m.level(step))
+}
+
+func (gzipModifier) Decode(b *buffer.Buffer, schema *StateSchema, step PipelineStep) (*buffer.Buffer, error) {
+	return GzipDec(b)
+}
+
+func (m gzipModifier) NewWriter(w io.Writer, schema *StateSchema, step PipelineStep) (ModifierWriter, error) {
+	return gzip.NewWriterLevel(w, m.level(step))
+}
+
+func (gzipModifier) NewReader(r io.Reader, schema *StateSchema, step PipelineStep) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
@@ -0,0 +1,73 @@
+package bstates
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/nayarsystems/buffer/buffer"
+	"github.com/nayarsystems/buffer/shuffling"
+	"github.com/pierrec/lz4/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Lz4BufferTransposeCompression(t *testing.T) {
+	b := &buffer.Buffer{}
+	b.InitFromRawBuffer(make([]byte, 10000))
+
+	for i := 0; i < b.GetByteSize(); i++ {
+		b.GetRawBuffer()[i] = uint8(i)
+	}
+
+	bt, err := shuffling.TransposeBits(b, 8)
+	require.Nil(t, err)
+	require.Equal(t, b.GetByteSize(), bt.GetByteSize())
+
+	eb, err := Lz4Enc(b)
+	require.Nil(t, err)
+	db, err := Lz4Dec(eb)
+	require.Nil(t, err)
+	require.Equal(t, b, db)
+
+	ebt, err := Lz4Enc(bt)
+	require.Nil(t, err)
+	dbt, err := Lz4Dec(ebt)
+	require.Nil(t, err)
+	require.Equal(t, bt, dbt)
+
+	require.Less(t, ebt.GetByteSize(), eb.GetByteSize())
+}
+
+func Test_Lz4Block(t *testing.T) {
+	b := &buffer.Buffer{}
+	b.InitFromRawBuffer(make([]byte, 1000))
+	eb, err := Lz4Enc(b)
+	require.NoError(t, err)
+	raw := eb.GetRawBuffer()
+	// Uncompressed size prefix followed by a single block
+	require.Equal(t, uint32(1000), binary.LittleEndian.Uint32(raw))
+	out := make([]byte, 1000)
+	n, err := lz4.UncompressBlock(raw[4:], out)
+	require.NoError(t, err)
+	require.Equal(t, 1000, n)
+
+	// Empty and incompressible data
+	for _, data := range [][]byte{{}, {0x3a}, []byte("0123456789abcdefghijklmnopqrstuvwxyz")} {
+		b.InitFromRawBuffer(data)
+		eb, err = Lz4Enc(b)
+		require.NoError(t, err)
+		db, err := Lz4Dec(eb)
+		require.NoError(t, err)
+		require.Equal(t, data, db.GetRawBuffer())
+	}
+
+	// Corrupt data
+	for _, data := range [][]byte{{}, {1, 0, 0}, {0xff, 0xff, 0xff, 0xff, 0x10}, raw[:len(raw)-1]} {
+		b.InitFromRawBuffer(data)
+		_, err = Lz4Dec(b)
+		require.Error(t, err, data)
+	}
+	b.InitFromRawBuffer(append([]byte{}, raw...))
+	binary.LittleEndian.PutUint32(b.GetRawBuffer(), 999)
+	_, err = Lz4Dec(b)
+	require.Error(t, err)
+}
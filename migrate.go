@@ -0,0 +1,333 @@
+package bstates
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/jaracil/ei"
+)
+
+// ErrPrecisionLoss represents a conversion error where the original value can't be represented exactly
+var ErrPrecisionLoss = errors.New("precision loss")
+
+// MigrationIssue describes a field whose value could not be migrated exactly.
+type MigrationIssue struct {
+	From string // Name of the field in the source schema
+	To   string // Name of the field in the target schema
+	Err  error  // Cause of the issue: wraps [ErrPrecisionLoss], [ErrOutOfRange] or [ErrInvalidType]
+}
+
+func (i MigrationIssue) Error() string {
+	return fmt.Sprintf("field \"%s\" -> \"%s\": %v", i.From, i.To, i.Err)
+}
+
+// MigrationReport describes the changes performed while migrating a [State] between two schemas.
+type MigrationReport struct {
+	Dropped   []string         // Source fields with no counterpart in the target schema
+	Defaulted []string         // Target fields with no counterpart in the source schema (filled with their DefaultValue)
+	Issues    []MigrationIssue // Fields migrated with loss of precision, clamped or left with their default value
+}
+
+// IsLossless reports whether the migration kept every value of the source state.
+func (r *MigrationReport) IsLossless() bool {
+	return len(r.Dropped) == 0 && len(r.Issues) == 0
+}
+
+// StateMigrator converts [State] objects from one [StateSchema] into another.
+//
+// Fields are matched by name first and then by [StateField.Aliases] (in both directions), so a field
+// renamed in the target schema which keeps its old name as alias is migrated transparently.
+// Decoded fields are not migrated since they are views over the regular fields.
+type StateMigrator struct {
+	from      *StateSchema
+	to        *StateSchema
+	mapping   map[string]string // target field name -> source field name
+	dropped   []string
+	defaulted []string
+}
+
+// CreateStateMigrator builds the field mapping between the two schemas provided.
+func CreateStateMigrator(from, to *StateSchema) (*StateMigrator, error) {
+	if from == nil || to == nil {
+		return nil, fmt.Errorf("both schemas are required")
+	}
+	m := &StateMigrator{
+		from:    from,
+		to:      to,
+		mapping: map[string]string{},
+	}
+	used := map[string]bool{}
+	for _, dst := range to.fields {
+		src := findMigrationSource(from, &dst, used)
+		if src == nil {
+			m.defaulted = append(m.defaulted, dst.Name)
+			continue
+		}
+		used[src.Name] = true
+		m.mapping[dst.Name] = src.Name
+	}
+	for _, src := range from.fields {
+		if !used[src.Name] {
+			m.dropped = append(m.dropped, src.Name)
+		}
+	}
+	return m, nil
+}
+
+// findMigrationSource looks for the source field of dst: same name, one of dst's aliases or
+// a source field which has dst's name as alias.
+func findMigrationSource(from *StateSchema, dst *StateField, used map[string]bool) *StateField {
+	if src, ok := from.fieldsMap[dst.Name]; ok && !used[src.Name] {
+		return src
+	}
+	for _, alias := range dst.Aliases {
+		if src, ok := from.fieldsMap[alias]; ok && !used[src.Name] {
+			return src
+		}
+	}
+	for _, src := range from.fields {
+		if used[src.Name] {
+			continue
+		}
+		for _, alias := range src.Aliases {
+			if alias == dst.Name {
+				return from.fieldsMap[src.Name]
+			}
+		}
+	}
+	return nil
+}
+
+// GetMapping returns a map of target field names to source field names.
+func (m *StateMigrator) GetMapping() map[string]string {
+	mapping := map[string]string{}
+	for k, v := range m.mapping {
+		mapping[k] = v
+	}
+	return mapping
+}
+
+// Migrate creates a new [State] using the target schema with the values of the state provided.
+//
+// Integer, fixed point and float values are converted between types and sizes: values out of the target
+// range are clamped and values which can't be represented exactly are rounded. Both cases are reported
+// in the returned [MigrationReport] and are not considered errors.
+func (m *StateMigrator) Migrate(state *State) (*State, *MigrationReport, error) {
+	if state.GetSchema() != m.from && state.GetSchema().GetSHA256() != m.from.GetSHA256() {
+		return nil, nil, fmt.Errorf("state schema does not match the migration source schema")
+	}
+	out, err := m.to.CreateState()
+	if err != nil {
+		return nil, nil, err
+	}
+	report := &MigrationReport{
+		Dropped:   append([]string{}, m.dropped...),
+		Defaulted: append([]string{}, m.defaulted...),
+	}
+	for _, dst := range m.to.fields {
+		srcName, ok := m.mapping[dst.Name]
+		if !ok {
+			continue
+		}
+		src := m.from.fieldsMap[srcName]
+		v, err := state.Get(srcName)
+		if err != nil {
+			return nil, nil, err
+		}
+		newValue, issue := migrateValue(src, m.to.fieldsMap[dst.Name], v)
+		if issue != nil {
+			report.Issues = append(report.Issues, MigrationIssue{From: srcName, To: dst.Name, Err: issue})
+			if errors.Is(issue, ErrInvalidType) {
+				continue
+			}
+		}
+		if err = out.Set(dst.Name, newValue); err != nil {
+			return nil, nil, fmt.Errorf("can't set migrated field \"%s\": %v", dst.Name, err)
+		}
+	}
+	return out, report, nil
+}
+
+// MigrateStates migrates every state provided. See [StateMigrator.Migrate].
+func (m *StateMigrator) MigrateStates(states []*State) ([]*State, []*MigrationReport, error) {
+	out := make([]*State, 0, len(states))
+	reports := make([]*MigrationReport, 0, len(states))
+	for i, state := range states {
+		migrated, report, err := m.Migrate(state)
+		if err != nil {
+			return nil, nil, fmt.Errorf("state %d: %v", i, err)
+		}
+		out = append(out, migrated)
+		reports = append(reports, report)
+	}
+	return out, reports, nil
+}
+
+// MigrateState is a shortcut for migrating a single [State] into the target schema. See [StateMigrator.Migrate].
+func MigrateState(state *State, to *StateSchema) (*State, *MigrationReport, error) {
+	m, err := CreateStateMigrator(state.GetSchema(), to)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m.Migrate(state)
+}
+
+// migrateValue converts the value v of the field src into a value valid for the field dst.
+// If the value can't be converted exactly, the closest valid value is returned along with an error
+// describing the loss.
+func migrateValue(src, dst *StateField, v any) (any, error) {
+	switch dst.Type {
+	case T_INT, T_UINT:
+		return migrateToInteger(src, dst, v)
+	case T_FIXED, T_UFIXED, T_FLOAT32, T_FLOAT64:
+		return migrateToReal(src, dst, v)
+	case T_BOOL:
+		switch src.Type {
+		case T_BOOL:
+			return v, nil
+		case T_BUFFER:
+		default:
+			f := ei.N(v).Float64Z()
+			if f != 0 && f != 1 {
+				return f != 0, fmt.Errorf("%w: %v converted to bool", ErrPrecisionLoss, v)
+			}
+			return f != 0, nil
+		}
+	case T_BUFFER:
+		if src.Type == T_BUFFER {
+			return migrateBuffer(dst, v.([]byte))
+		}
+	}
+	return nil, fmt.Errorf("%w: can't convert %s field into %s field", ErrInvalidType, src.typeName(), dst.typeName())
+}
+
+func migrateToInteger(src, dst *StateField, v any) (any, error) {
+	var issue error
+	i := new(big.Int)
+	switch src.Type {
+	case T_INT:
+		i.SetInt64(ei.N(v).Int64Z())
+	case T_UINT:
+		i.SetUint64(ei.N(v).Uint64Z())
+	case T_BOOL:
+		if v.(bool) {
+			i.SetInt64(1)
+		}
+	case T_FIXED, T_UFIXED, T_FLOAT32, T_FLOAT64:
+		f := ei.N(v).Float64Z()
+		if math.IsNaN(f) {
+			return dst.DefaultValue, fmt.Errorf("%w: NaN can't be converted to integer", ErrOutOfRange)
+		}
+		r := math.Round(f)
+		if r != f {
+			issue = fmt.Errorf("%w: %v rounded to %v", ErrPrecisionLoss, f, r)
+		}
+		if math.IsInf(r, 0) {
+			r = math.Copysign(math.MaxFloat64, r)
+		}
+		big.NewFloat(r).Int(i)
+	default:
+		return nil, fmt.Errorf("%w: can't convert %s field into %s field", ErrInvalidType, src.typeName(), dst.typeName())
+	}
+	minV, maxV, _ := dst.GetRange()
+	min, max := new(big.Int), new(big.Int)
+	if dst.Type == T_INT {
+		min.SetInt64(minV.(int64))
+		max.SetInt64(maxV.(int64))
+	} else {
+		min.SetUint64(minV.(uint64))
+		max.SetUint64(maxV.(uint64))
+	}
+	if i.Cmp(min) < 0 {
+		issue = fmt.Errorf("%w: %v clamped to %v", ErrOutOfRange, i, min)
+		i = min
+	} else if i.Cmp(max) > 0 {
+		issue = fmt.Errorf("%w: %v clamped to %v", ErrOutOfRange, i, max)
+		i = max
+	}
+	if dst.Type == T_INT {
+		return i.Int64(), issue
+	}
+	return i.Uint64(), issue
+}
+
+func migrateToReal(src, dst *StateField, v any) (any, error) {
+	var issue error
+	var f float64
+	switch src.Type {
+	case T_INT:
+		i := ei.N(v).Int64Z()
+		f = float64(i)
+		if f >= math.MaxInt64 || int64(f) != i {
+			issue = fmt.Errorf("%w: %v converted to %v", ErrPrecisionLoss, i, f)
+		}
+	case T_UINT:
+		u := ei.N(v).Uint64Z()
+		f = float64(u)
+		if f >= math.MaxUint64 || uint64(f) != u {
+			issue = fmt.Errorf("%w: %v converted to %v", ErrPrecisionLoss, u, f)
+		}
+	case T_BOOL:
+		if v.(bool) {
+			f = 1
+		}
+	case T_FIXED, T_UFIXED, T_FLOAT32, T_FLOAT64:
+		f = ei.N(v).Float64Z()
+	default:
+		return nil, fmt.Errorf("%w: can't convert %s field into %s field", ErrInvalidType, src.typeName(), dst.typeName())
+	}
+	if math.IsNaN(f) {
+		return dst.DefaultValue, fmt.Errorf("%w: NaN replaced by default value", ErrOutOfRange)
+	}
+	minV, maxV, _ := dst.GetRange()
+	min, max := ei.N(minV).Float64Z(), ei.N(maxV).Float64Z()
+	if f < min {
+		issue = fmt.Errorf("%w: %v clamped to %v", ErrOutOfRange, f, min)
+		f = min
+	} else if f > max {
+		issue = fmt.Errorf("%w: %v clamped to %v", ErrOutOfRange, f, max)
+		f = max
+	}
+	switch dst.Type {
+	case T_FIXED, T_UFIXED:
+		scaled := f * dst.fixedPointCachedFactor
+		rounded := math.Round(scaled)
+		if math.Abs(rounded-scaled) > 1e-6 && issue == nil {
+			issue = fmt.Errorf("%w: %v rounded to %d decimals", ErrPrecisionLoss, f, dst.Decimals)
+		}
+		return rounded / dst.fixedPointCachedFactor, issue
+	case T_FLOAT32:
+		f32 := float32(f)
+		if float64(f32) != f && issue == nil {
+			issue = fmt.Errorf("%w: %v converted to float32 %v", ErrPrecisionLoss, f, f32)
+		}
+		return f32, issue
+	}
+	return f, issue
+}
+
+func migrateBuffer(dst *StateField, b []byte) (any, error) {
+	byteSize := (dst.Size + 7) / 8
+	if len(b) <= byteSize && (len(b) < byteSize || dst.Size%8 == 0) {
+		return b, nil
+	}
+	out := make([]byte, byteSize)
+	copy(out, b)
+	if dst.Size%8 != 0 {
+		out[byteSize-1] &= byte(0xff << (8 - dst.Size%8))
+	}
+	for i := range b {
+		if (i >= byteSize && b[i] != 0) || (i < byteSize && b[i] != out[i]) {
+			return out, fmt.Errorf("%w: buffer truncated to %d bits", ErrPrecisionLoss, dst.Size)
+		}
+	}
+	return out, nil
+}
+
+// typeName returns the name of the field type as used in the JSON representation of the schema.
+func (e *StateField) typeName() string {
+	m, _ := e.ToMsi()
+	return m["type"].(string)
+}
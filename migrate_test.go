@@ -0,0 +1,192 @@
+package bstates
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MigrateState(t *testing.T) {
+	from, err := CreateStateSchema(&StateSchemaParams{
+		Fields: []StateField{
+			{Name: "COUNTER", Type: T_UINT, Size: 16},
+			{Name: "TEMP", Type: T_FIXED, Size: 16, Decimals: 2},
+			{Name: "OLD_NAME", Type: T_INT, Size: 8},
+			{Name: "REMOVED", Type: T_BOOL},
+			{Name: "MSG", Type: T_BUFFER, Size: 64},
+			{Name: "LEVEL", Type: T_FLOAT64},
+		},
+	})
+	require.NoError(t, err)
+
+	to, err := CreateStateSchema(&StateSchemaParams{
+		Fields: []StateField{
+			{Name: "COUNTER", Type: T_UINT, Size: 32},
+			{Name: "TEMP", Type: T_FIXED, Size: 16, Decimals: 1},
+			{Name: "NEW_NAME", Aliases: []string{"OLD_NAME"}, Type: T_INT, Size: 16},
+			{Name: "ADDED", Type: T_UINT, Size: 8, DefaultValue: 7},
+			{Name: "MSG", Type: T_BUFFER, Size: 32},
+			{Name: "LEVEL", Type: T_INT, Size: 4},
+		},
+	})
+	require.NoError(t, err)
+
+	state, err := from.CreateState()
+	require.NoError(t, err)
+	require.NoError(t, state.Set("COUNTER", 1000))
+	require.NoError(t, state.Set("TEMP", 12.34))
+	require.NoError(t, state.Set("OLD_NAME", -5))
+	require.NoError(t, state.Set("REMOVED", true))
+	require.NoError(t, state.Set("MSG", "abcdef"))
+	require.NoError(t, state.Set("LEVEL", 100.0))
+
+	migrator, err := CreateStateMigrator(from, to)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"COUNTER":  "COUNTER",
+		"TEMP":     "TEMP",
+		"NEW_NAME": "OLD_NAME",
+		"MSG":      "MSG",
+		"LEVEL":    "LEVEL",
+	}, migrator.GetMapping())
+
+	out, report, err := migrator.Migrate(state)
+	require.NoError(t, err)
+	require.Equal(t, to, out.GetSchema())
+	require.False(t, report.IsLossless())
+	require.Equal(t, []string{"REMOVED"}, report.Dropped)
+	require.Equal(t, []string{"ADDED"}, report.Defaulted)
+
+	issues := map[string]error{}
+	for _, issue := range report.Issues {
+		issues[issue.To] = issue.Err
+	}
+	require.Len(t, issues, 3)
+	require.True(t, errors.Is(issues["TEMP"], ErrPrecisionLoss))
+	require.True(t, errors.Is(issues["MSG"], ErrPrecisionLoss))
+	require.True(t, errors.Is(issues["LEVEL"], ErrOutOfRange))
+
+	v, err := out.Get("COUNTER")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1000), v)
+	v, err = out.Get("TEMP")
+	require.NoError(t, err)
+	require.Equal(t, 12.3, v)
+	v, err = out.Get("NEW_NAME")
+	require.NoError(t, err)
+	require.Equal(t, int64(-5), v)
+	v, err = out.Get("ADDED")
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), v)
+	v, err = out.Get("MSG")
+	require.NoError(t, err)
+	require.Equal(t, []byte("abcd"), v)
+	v, err = out.Get("LEVEL")
+	require.NoError(t, err)
+	require.Equal(t, int64(7), v)
+}
+
+func Test_MigrateState_Lossless(t *testing.T) {
+	from, err := CreateStateSchema(&StateSchemaParams{
+		Fields: []StateField{
+			{Name: "A", Aliases: []string{"B"}, Type: T_INT, Size: 16},
+			{Name: "F", Type: T_UFIXED, Size: 16, Decimals: 1},
+			{Name: "BIG", Type: T_UINT, Size: 64},
+		},
+	})
+	require.NoError(t, err)
+
+	to, err := CreateStateSchema(&StateSchemaParams{
+		Fields: []StateField{
+			{Name: "B", Type: T_INT, Size: 8},
+			{Name: "F", Type: T_UFIXED, Size: 32, Decimals: 3},
+			{Name: "BIG", Type: T_UINT, Size: 64},
+		},
+	})
+	require.NoError(t, err)
+
+	state, err := from.CreateState()
+	require.NoError(t, err)
+	require.NoError(t, state.Set("A", -100))
+	require.NoError(t, state.Set("F", 123.4))
+	require.NoError(t, state.Set("BIG", uint64(1<<63+1)))
+
+	out, report, err := MigrateState(state, to)
+	require.NoError(t, err)
+	require.True(t, report.IsLossless(), report.Issues)
+	require.Empty(t, report.Defaulted)
+
+	v, err := out.Get("B")
+	require.NoError(t, err)
+	require.Equal(t, int64(-100), v)
+	v, err = out.Get("F")
+	require.NoError(t, err)
+	require.Equal(t, 123.4, v)
+	v, err = out.Get("BIG")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1<<63+1), v)
+}
+
+func Test_MigrateState_Narrowing(t *testing.T) {
+	from, err := CreateStateSchema(&StateSchemaParams{
+		Fields: []StateField{
+			{Name: "I", Type: T_INT, Size: 16},
+			{Name: "U", Type: T_UINT, Size: 16},
+			{Name: "F", Type: T_FLOAT64},
+			{Name: "B", Type: T_BUFFER, Size: 8},
+		},
+	})
+	require.NoError(t, err)
+
+	to, err := CreateStateSchema(&StateSchemaParams{
+		Fields: []StateField{
+			{Name: "I", Type: T_UINT, Size: 4},
+			{Name: "U", Type: T_INT, Size: 4},
+			{Name: "F", Type: T_FLOAT32},
+			{Name: "B", Type: T_INT, Size: 8},
+		},
+	})
+	require.NoError(t, err)
+
+	state, err := from.CreateState()
+	require.NoError(t, err)
+	require.NoError(t, state.Set("I", -3))
+	require.NoError(t, state.Set("U", 300))
+	require.NoError(t, state.Set("F", 0.1))
+
+	migrator, err := CreateStateMigrator(from, to)
+	require.NoError(t, err)
+	outs, reports, err := migrator.MigrateStates([]*State{state})
+	require.NoError(t, err)
+	require.Len(t, outs, 1)
+	out, report := outs[0], reports[0]
+
+	issues := map[string]error{}
+	for _, issue := range report.Issues {
+		issues[issue.To] = issue.Err
+	}
+	require.True(t, errors.Is(issues["I"], ErrOutOfRange))
+	require.True(t, errors.Is(issues["U"], ErrOutOfRange))
+	require.True(t, errors.Is(issues["F"], ErrPrecisionLoss))
+	require.True(t, errors.Is(issues["B"], ErrInvalidType))
+
+	v, err := out.Get("I")
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), v)
+	v, err = out.Get("U")
+	require.NoError(t, err)
+	require.Equal(t, int64(7), v)
+	v, err = out.Get("F")
+	require.NoError(t, err)
+	require.Equal(t, float32(0.1), v)
+	v, err = out.Get("B")
+	require.NoError(t, err)
+	require.Equal(t, int64(0), v)
+
+	// Wrong source schema
+	other, err := to.CreateState()
+	require.NoError(t, err)
+	_, _, err = migrator.Migrate(other)
+	require.Error(t, err)
+}
@@ -0,0 +1,143 @@
+package bstates
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+
+	"github.com/nayarsystems/buffer/buffer"
+)
+
+// ErrModifierExists is returned by [RegisterModifier] when there is already a modifier with the same name.
+var ErrModifierExists = errors.New("modifier already registered")
+
+// Modifier is a transform of the encoder pipeline (see [RegisterModifier]). Encode runs when a [StateQueue]
+// is encoded and Decode reverts it. Both get the schema of the queue and the pipeline step, with the
+// parameters of the modifier, as context.
+type Modifier interface {
+	Encode(b *buffer.Buffer, schema *StateSchema, step PipelineStep) (*buffer.Buffer, error)
+	Decode(b *buffer.Buffer, schema *StateSchema, step PipelineStep) (*buffer.Buffer, error)
+}
+
+// ModifierParamsValidator can be implemented by a [Modifier] which accepts parameters. ValidateParams is
+// called when the pipeline of a schema is parsed. Steps with parameters of modifiers which don't implement
+// it are rejected.
+type ModifierParamsValidator interface {
+	ValidateParams(step PipelineStep) error
+}
+
+// ModifierWriter is a stage of the streaming encoder. Flush writes any buffered data and Close finishes
+// the stage, without closing the underlying writer.
+type ModifierWriter interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// ModifierStreamEncoder can be implemented by a [Modifier] which can encode data progressively. Only
+// pipelines made of these modifiers can be used by a [QueueWriter].
+type ModifierStreamEncoder interface {
+	NewWriter(w io.Writer, schema *StateSchema, step PipelineStep) (ModifierWriter, error)
+}
+
+// ModifierStreamDecoder can be implemented by a [Modifier] which can decode data progressively. If the
+// returned reader implements [io.Closer] it's closed by [QueueReader.Close]. The [QueueReader] runs the
+// Decode method of other modifiers once all their input has been read.
+type ModifierStreamDecoder interface {
+	NewReader(r io.Reader, schema *StateSchema, step PipelineStep) (io.Reader, error)
+}
+
+// ModifierIntegrityVerifier can be implemented by a [Modifier] which verifies the integrity of its input when
+// decoding (e.g. checksums or authenticated encryption). An empty input is only accepted as an empty queue if the
+// pipeline has no such modifier, so truncated data is not mistaken for a valid empty queue.
+type ModifierIntegrityVerifier interface {
+	VerifiesIntegrity() bool
+}
+
+var (
+	modifiersMutex    sync.RWMutex
+	modifiers         = map[string]Modifier{}
+	modifierNameRegex = regexp.MustCompile(`^[^:(),=]+$`)
+)
+
+func init() {
+	for name, mod := range map[string]Modifier{
+		MOD_GZIP:     gzipModifier{},
+		MOD_ZSTD:     zstdModifier{},
+		MOD_BITTRANS: bitTransModifier{},
+		MOD_XOR:      xorModifier{},
+		MOD_DELTA:    deltaModifier{},
+		MOD_S2:       s2Modifier{},
+		MOD_SNAPPY:   s2Modifier{snappy: true},
+		MOD_LZ4:      lz4Modifier{},
+		MOD_AESGCM:   aesGcmModifier{},
+		MOD_CRC32C:   checksumModifier{},
+		MOD_COLUMNS:  columnsModifier{},
+	} {
+		if err := RegisterModifier(name, mod); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterModifier registers a [Modifier] so it can be used in encoder pipelines with the provided name.
+// Modifiers must be registered before the schemas which use them are created. Names can't contain
+// ':', '(', ')', ',' or '=', and can't be registered twice.
+func RegisterModifier(name string, mod Modifier) error {
+	if !modifierNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid modifier name \"%s\"", name)
+	}
+	if mod == nil {
+		return fmt.Errorf("nil modifier \"%s\"", name)
+	}
+	modifiersMutex.Lock()
+	defer modifiersMutex.Unlock()
+	if _, exists := modifiers[name]; exists {
+		return fmt.Errorf("%w: \"%s\"", ErrModifierExists, name)
+	}
+	modifiers[name] = mod
+	return nil
+}
+
+// GetModifier returns the [Modifier] registered with the provided name.
+func GetModifier(name string) (Modifier, bool) {
+	modifiersMutex.RLock()
+	defer modifiersMutex.RUnlock()
+	mod, ok := modifiers[name]
+	return mod, ok
+}
+
+// getStepModifier returns the modifier of a pipeline step.
+func getStepModifier(step PipelineStep) (Modifier, error) {
+	mod, ok := GetModifier(step.Modifier)
+	if !ok {
+		return nil, fmt.Errorf("\"%s\" is not a modifier", step.Modifier)
+	}
+	return mod, nil
+}
+
+// verifiesIntegrity reports whether any of the pipeline steps verifies the integrity of its input
+// (see [ModifierIntegrityVerifier]).
+func verifiesIntegrity(steps []PipelineStep) bool {
+	for _, step := range steps {
+		mod, ok := GetModifier(step.Modifier)
+		if !ok {
+			continue
+		}
+		if v, ok := mod.(ModifierIntegrityVerifier); ok && v.VerifiesIntegrity() {
+			return true
+		}
+	}
+	return false
+}
+
+// checkParams returns an error if the step has a parameter which is not in the allowed list.
+func checkParams(step PipelineStep, allowed ...string) error {
+	for name := range step.Params {
+		if !containsString(allowed, name) {
+			return fmt.Errorf("modifier \"%s\": unknown parameter \"%s\"", step.Modifier, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package bstates
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/nayarsystems/buffer/buffer"
+	"github.com/stretchr/testify/require"
+)
+
+// testNotModifier inverts the bits of the data, or only the bits of the first state bytes if the
+// bytes parameter is set.
+type testNotModifier struct{}
+
+func (testNotModifier) ValidateParams(step PipelineStep) error {
+	if err := checkParams(step, "bytes"); err != nil {
+		return err
+	}
+	_, err := step.GetIntParam("bytes", 0)
+	return err
+}
+
+func (testNotModifier) Encode(b *buffer.Buffer, schema *StateSchema, step PipelineStep) (*buffer.Buffer, error) {
+	n, _ := step.GetIntParam("bytes", schema.GetByteSize())
+	in := b.GetRawBuffer()[:b.GetByteSize()]
+	out := make([]byte, len(in))
+	for i, c := range in {
+		if i%schema.GetByteSize() < n {
+			c = ^c
+		}
+		out[i] = c
+	}
+	outb := &buffer.Buffer{}
+	outb.InitFromRawBuffer(out)
+	return outb, nil
+}
+
+func (m testNotModifier) Decode(b *buffer.Buffer, schema *StateSchema, step PipelineStep) (*buffer.Buffer, error) {
+	return m.Encode(b, schema, step)
+}
+
+// testStreamNotModifier is a streaming version of testNotModifier without parameters.
+type testStreamNotModifier struct {
+	testNotModifier
+}
+
+func (testStreamNotModifier) ValidateParams(step PipelineStep) error {
+	return checkParams(step)
+}
+
+func (testStreamNotModifier) NewWriter(w io.Writer, schema *StateSchema, step PipelineStep) (ModifierWriter, error) {
+	return &testNotWriter{w: w}, nil
+}
+
+type testNotWriter struct {
+	w io.Writer
+}
+
+func (n *testNotWriter) Write(p []byte) (int, error) {
+	out := make([]byte, len(p))
+	for i, c := range p {
+		out[i] = ^c
+	}
+	return n.w.Write(out)
+}
+
+func (n *testNotWriter) Flush() error { return nil }
+func (n *testNotWriter) Close() error { return nil }
+
+func init() {
+	if err := RegisterModifier("test_not", testNotModifier{}); err != nil {
+		panic(err)
+	}
+	if err := RegisterModifier("test_snot", testStreamNotModifier{}); err != nil {
+		panic(err)
+	}
+}
+
+func Test_RegisterModifier(t *testing.T) {
+	for _, name := range []string{MOD_GZIP, MOD_ZSTD, MOD_BITTRANS, MOD_XOR, MOD_DELTA, MOD_S2, MOD_SNAPPY, MOD_LZ4, MOD_AESGCM, MOD_CRC32C, MOD_COLUMNS, "test_not"} {
+		_, ok := GetModifier(name)
+		require.True(t, ok, name)
+	}
+	_, ok := GetModifier("test_unknown")
+	require.False(t, ok)
+
+	err := RegisterModifier("test_not", testNotModifier{})
+	require.ErrorIs(t, err, ErrModifierExists)
+	err = RegisterModifier(MOD_GZIP, testNotModifier{})
+	require.ErrorIs(t, err, ErrModifierExists)
+	for _, name := range []string{"", "a:b", "a(b)", "a=b", "a,b"} {
+		require.Error(t, RegisterModifier(name, testNotModifier{}), name)
+	}
+	require.Error(t, RegisterModifier("test_nil", nil))
+
+	// Parameters are validated by the modifier
+	_, err = ParsePipeline("test_not(bytes=2):z")
+	require.NoError(t, err)
+	_, err = ParsePipeline("test_not(bytes=a)")
+	require.Error(t, err)
+	_, err = ParsePipeline("test_not(other=1)")
+	require.Error(t, err)
+	_, err = ParsePipeline("test_snot(bytes=1)")
+	require.Error(t, err)
+	_, err = ParsePipeline("test_unknown")
+	require.Error(t, err)
+}
+
+func Test_RegisterModifier_Pipelines(t *testing.T) {
+	for _, pipeline := range []string{"test_not", "test_not(bytes=2):t:z", "d:test_snot:zstd"} {
+		schema := testPipelineComparativeCreateSchema(t, pipeline)
+		states := testCreateCounterStates(t, schema, 300)
+		q := CreateStateQueue(schema)
+		require.NoError(t, q.PushAll(states))
+		blob, err := q.Encode()
+		require.NoError(t, err)
+
+		noModSchema := testPipelineComparativeCreateSchema(t, "")
+		noModQueue := CreateStateQueue(noModSchema)
+		require.NoError(t, noModQueue.PushAll(testCreateCounterStates(t, noModSchema, 300)))
+		noModBlob, err := noModQueue.Encode()
+		require.NoError(t, err)
+		require.NotEqual(t, noModBlob, blob)
+
+		dq := CreateStateQueue(schema)
+		require.NoError(t, dq.Decode(blob))
+		decoded, err := dq.GetStates()
+		require.NoError(t, err)
+		testEqualStates(t, states, decoded)
+
+		// Modifiers without streaming decoder are decoded once all their input is read
+		r, err := CreateQueueReader(schema, bytes.NewReader(blob))
+		require.NoError(t, err)
+		streamed := []*State{}
+		require.NoError(t, r.ForEach(func(state *State) error {
+			streamed = append(streamed, state)
+			return nil
+		}))
+		testEqualStates(t, states, streamed)
+	}
+
+	// Streaming encoder
+	schema := testPipelineComparativeCreateSchema(t, "test_not")
+	_, err := CreateQueueWriter(schema, &bytes.Buffer{})
+	require.ErrorIs(t, err, ErrStreamingNotSupported)
+
+	schema = testPipelineComparativeCreateSchema(t, "test_snot:z")
+	states := testCreateCounterStates(t, schema, 300)
+	out := &bytes.Buffer{}
+	w, err := CreateQueueWriter(schema, out)
+	require.NoError(t, err)
+	require.NoError(t, w.PushAll(states))
+	require.NoError(t, w.Close())
+	dq := CreateStateQueue(schema)
+	require.NoError(t, dq.Decode(out.Bytes()))
+	decoded, err := dq.GetStates()
+	require.NoError(t, err)
+	testEqualStates(t, states, decoded)
+}
@@ -0,0 +1,110 @@
+package bstates
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PipelineStep is a modifier of an encoder pipeline with its parameters. In the encoderPipeline string
+// of a schema parameters are written in parentheses after the modifier name, for example "t:zstd(level=19)".
+type PipelineStep struct {
+	Modifier string            // Modifier name, for example [MOD_ZSTD]
+	Params   map[string]string // Modifier parameters (nil if none)
+}
+
+// String returns the step as written in an encoderPipeline, with the parameters sorted by name.
+func (p PipelineStep) String() string {
+	if len(p.Params) == 0 {
+		return p.Modifier
+	}
+	keys := make([]string, 0, len(p.Params))
+	for k := range p.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	params := make([]string, 0, len(keys))
+	for _, k := range keys {
+		params = append(params, k+"="+p.Params[k])
+	}
+	return p.Modifier + "(" + strings.Join(params, ",") + ")"
+}
+
+// GetIntParam returns the value of an integer parameter or def if the parameter is not set.
+func (p PipelineStep) GetIntParam(name string, def int) (int, error) {
+	v, ok := p.Params[name]
+	if !ok {
+		return def, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("modifier \"%s\": parameter \"%s\" is not an integer", p.Modifier, name)
+	}
+	return i, nil
+}
+
+var (
+	pipelineStepRegex  = regexp.MustCompile(`^([^:(),=]+)(?:\(([^:()]*)\))?$`)
+	pipelineParamRegex = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_]*)=([^:(),=]+)$`)
+)
+
+// ParsePipeline parses an encoder pipeline, a list of modifiers with optional parameters separated by ':',
+// for example "t:zstd(level=19)". Modifiers and parameters are validated.
+func ParsePipeline(pipelineRaw string) ([]PipelineStep, error) {
+	steps := []PipelineStep{}
+	if pipelineRaw == "" {
+		return steps, nil
+	}
+	for _, stepRaw := range strings.Split(pipelineRaw, ":") {
+		m := pipelineStepRegex.FindStringSubmatch(stepRaw)
+		if m == nil {
+			return nil, fmt.Errorf("wrong pipeline format")
+		}
+		step := PipelineStep{Modifier: m[1]}
+		if m[2] != "" {
+			step.Params = map[string]string{}
+			for _, paramRaw := range strings.Split(m[2], ",") {
+				pm := pipelineParamRegex.FindStringSubmatch(strings.TrimSpace(paramRaw))
+				if pm == nil {
+					return nil, fmt.Errorf("modifier \"%s\": wrong parameter format \"%s\"", step.Modifier, paramRaw)
+				}
+				if _, dup := step.Params[pm[1]]; dup {
+					return nil, fmt.Errorf("modifier \"%s\": duplicated parameter \"%s\"", step.Modifier, pm[1])
+				}
+				step.Params[pm[1]] = pm[2]
+			}
+		}
+		if err := validatePipelineStep(step); err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// validatePipelineStep checks the step modifier is registered and validates its parameters.
+func validatePipelineStep(step PipelineStep) error {
+	mod, err := getStepModifier(step)
+	if err != nil {
+		return err
+	}
+	if v, ok := mod.(ModifierParamsValidator); ok {
+		return v.ValidateParams(step)
+	}
+	return checkParams(step)
+}
+
+// pipelineToString returns the encoderPipeline string of the steps.
+func pipelineToString(steps []PipelineStep) string {
+	return strings.Join(pipelineStepStrings(steps), ":")
+}
+
+func pipelineStepStrings(steps []PipelineStep) []string {
+	strs := make([]string, 0, len(steps))
+	for _, step := range steps {
+		strs = append(strs, step.String())
+	}
+	return strs
+}
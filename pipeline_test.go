@@ -0,0 +1,104 @@
+package bstates
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParsePipeline(t *testing.T) {
+	steps, err := ParsePipeline("t:zstd(level=19):z(level=1)")
+	require.NoError(t, err)
+	require.Equal(t, []PipelineStep{
+		{Modifier: MOD_BITTRANS},
+		{Modifier: MOD_ZSTD, Params: map[string]string{"level": "19"}},
+		{Modifier: MOD_GZIP, Params: map[string]string{"level": "1"}},
+	}, steps)
+	require.Equal(t, "t:zstd(level=19):z(level=1)", pipelineToString(steps))
+
+	steps, err = ParsePipeline("")
+	require.NoError(t, err)
+	require.Empty(t, steps)
+
+	steps, err = ParsePipeline("zstd()")
+	require.NoError(t, err)
+	require.Equal(t, "zstd", pipelineToString(steps))
+
+	for _, pipe := range []string{
+		"t:",
+		"zstd(level=19",
+		"zstd(level)",
+		"zstd(level=1,level=2)",
+		"zstd(level=23)",
+		"zstd(level=fast)",
+		"z(level=10)",
+		"z(window=3)",
+		"t(level=1)",
+		"k(level=1)",
+	} {
+		_, err = ParsePipeline(pipe)
+		require.Error(t, err, pipe)
+	}
+}
+
+func Test_PipelineParams_Schema(t *testing.T) {
+	createSchema := func(pipeline string) *StateSchema {
+		schema, err := CreateStateSchema(&StateSchemaParams{
+			EncoderPipeline: pipeline,
+			Fields: []StateField{
+				{Name: "A", Type: T_UINT, Size: 8},
+			},
+		})
+		require.NoError(t, err)
+		return schema
+	}
+
+	schema := createSchema("t:zstd(level=19)")
+	require.Equal(t, []string{"t", "zstd(level=19)"}, schema.GetEncoderPipeline())
+	require.Equal(t, []string{"zstd(level=19)", "t"}, schema.GetDecoderPipeline())
+	require.Equal(t, "t:zstd(level=19)", schema.ToMsi()["encoderPipeline"])
+
+	raw, err := json.Marshal(schema)
+	require.NoError(t, err)
+	schemaFromJSON := &StateSchema{}
+	require.NoError(t, json.Unmarshal(raw, schemaFromJSON))
+	require.Equal(t, schema, schemaFromJSON)
+
+	// Parameters are part of the schema hash
+	require.NotEqual(t, createSchema("t:zstd").GetHashString(), schema.GetHashString())
+	require.NotEqual(t, createSchema("t:zstd(level=1)").GetHashString(), schema.GetHashString())
+	require.Equal(t, createSchema("t:zstd(level=19)").GetHashString(), schema.GetHashString())
+}
+
+func Test_PipelineParams_Levels(t *testing.T) {
+	tests := []struct {
+		fast string
+		best string
+	}{
+		{"z(level=1)", "z(level=9)"},
+		{"zstd(level=1)", "zstd(level=19)"},
+	}
+	for _, test := range tests {
+		blobs := [][]byte{}
+		for _, pipeline := range []string{test.fast, test.best} {
+			schema := testPipelineComparativeCreateSchema(t, pipeline)
+			states := testCreateCounterStates(t, schema, 5000)
+			q := CreateStateQueue(schema)
+			for _, state := range states {
+				require.NoError(t, q.Push(state))
+			}
+			blob, err := q.Encode()
+			require.NoError(t, err)
+
+			dq := CreateStateQueue(schema)
+			require.NoError(t, dq.Decode(blob))
+			decoded, err := dq.GetStates()
+			require.NoError(t, err)
+			testEqualStates(t, states, decoded)
+			blobs = append(blobs, blob)
+		}
+		// The level is applied: same states, different output
+		require.NotEqual(t, blobs[0], blobs[1], test.best)
+	}
+}
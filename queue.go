@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"github.com/jaracil/ei"
 	"github.com/nayarsystems/buffer/buffer"
-	"github.com/nayarsystems/buffer/shuffling"
 	"reflect"
 )
 
@@ -109,66 +108,51 @@ func (s *StateQueue) FromMsi(msg map[string]interface{}) error {
 
 // Encode runs the encoderPipeline of the schema and outputs a binary blob with the queue compressed.
 func (s *StateQueue) Encode() (dataOut []byte, err error) {
-	inputBuf := s.buffer
-	encPipe := s.StateSchema.GetEncoderPipeline()
-	for _, mod := range encPipe {
-		switch mod {
-		case MOD_GZIP:
-			inputBuf, err = GzipEnc(inputBuf)
-			if err != nil {
-				return
-			}
-		case MOD_ZSTD:
-			inputBuf, err = ZstdEnc(inputBuf)
-			if err != nil {
-				return
-			}
-		case MOD_BITTRANS:
-			stateBitSize := s.StateSchema.GetByteSize() * 8
-			inputBuf, err = shuffling.TransposeBits(inputBuf, stateBitSize)
-			if err != nil {
-				return
-			}
-		}
+	inputBuf, err := s.runEncoderSteps(s.StateSchema.GetEncoderSteps())
+	if err != nil {
+		return
 	}
 	dataOut = make([]byte, inputBuf.GetByteSize())
 	copy(dataOut, inputBuf.GetRawBuffer())
 	return
 }
 
+// runEncoderSteps runs the provided encoder pipeline steps on the queue buffer.
+func (s *StateQueue) runEncoderSteps(steps []PipelineStep) (inputBuf *buffer.Buffer, err error) {
+	inputBuf = s.buffer
+	for _, step := range steps {
+		var mod Modifier
+		if mod, err = getStepModifier(step); err != nil {
+			return
+		}
+		inputBuf, err = mod.Encode(inputBuf, s.StateSchema, step)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
 // Decode [Clear] the queue and then runs the decoderPipeline of the schema populating the queue.
 func (s *StateQueue) Decode(data []byte) (err error) {
 	s.Clear()
 	inputBuf := &buffer.Buffer{}
 	inputBuf.InitFromRawBuffer(data)
 
-	if len(data) == 0 {
+	decPipe := s.StateSchema.GetDecoderSteps()
+	if len(data) == 0 && !verifiesIntegrity(decPipe) {
 		// This want to avoid EOF error when decompressing empty data
 		return
 	}
 
-	decPipe := s.StateSchema.GetDecoderPipeline()
-	for _, mod := range decPipe {
-		switch mod {
-		case MOD_GZIP:
-			inputBuf, err = GzipDec(inputBuf)
-			if err != nil {
-				return
-			}
-		case MOD_ZSTD:
-			inputBuf, err = ZstdDec(inputBuf)
-			if err != nil {
-				return
-			}
-		case MOD_BITTRANS:
-			if inputBuf.GetBitSize() == 0 {
-				continue
-			}
-			numStates := inputBuf.GetByteSize() / s.StateSchema.GetByteSize()
-			inputBuf, err = shuffling.TransposeBits(inputBuf, numStates)
-			if err != nil {
-				return
-			}
+	for _, step := range decPipe {
+		var mod Modifier
+		if mod, err = getStepModifier(step); err != nil {
+			return
+		}
+		inputBuf, err = mod.Decode(inputBuf, s.StateSchema, step)
+		if err != nil {
+			return
 		}
 	}
 	s.buffer.Write(inputBuf.GetRawBuffer(), inputBuf.GetBitSize())
@@ -0,0 +1,159 @@
+package bstates
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/nayarsystems/buffer/buffer"
+)
+
+// ErrStreamingNotSupported is returned when a pipeline modifier can't be run by the streaming encoder.
+var ErrStreamingNotSupported = errors.New("modifier not supported in streaming mode")
+
+// QueueReader decodes the states of an encoded [StateQueue] (the output of [StateQueue.Encode]) from an
+// [io.Reader] yielding them one at a time, without loading the whole queue in memory.
+//
+// Stages of modifiers implementing [ModifierStreamDecoder], such as compression, XOR and delta, are decoded
+// incrementally. Other stages, such as transposition ([MOD_BITTRANS]) and decryption ([MOD_AESGCM]), need all
+// their input, so the output of the stage preceding them is buffered in memory (the encoded input if they are the
+// last stage of the encoder pipeline, or the output of the previous decoder otherwise).
+type QueueReader struct {
+	schema   *StateSchema
+	src      *bufio.Reader
+	reader   io.Reader   // output of the decoder pipeline
+	closers  []io.Closer // stages to close, in pipeline order
+	stateBuf []byte
+	started  bool
+	err      error
+}
+
+// CreateQueueReader creates a [QueueReader] which decodes the data read from r using the decoder
+// pipeline of the schema.
+func CreateQueueReader(schema *StateSchema, r io.Reader) (*QueueReader, error) {
+	for _, step := range schema.GetDecoderSteps() {
+		if _, err := getStepModifier(step); err != nil {
+			return nil, err
+		}
+	}
+	return &QueueReader{
+		schema:   schema,
+		src:      bufio.NewReader(r),
+		stateBuf: make([]byte, schema.GetByteSize()),
+	}, nil
+}
+
+// GetSchema returns the schema used to decode the states.
+func (q *QueueReader) GetSchema() *StateSchema {
+	return q.schema
+}
+
+// init builds the decoder pipeline. An empty input is a valid empty queue unless the pipeline verifies the
+// integrity of the data (see [StateQueue.Decode]).
+func (q *QueueReader) init() error {
+	q.started = true
+	if _, err := q.src.Peek(1); err != nil {
+		if err != io.EOF {
+			return err
+		}
+		if !verifiesIntegrity(q.schema.GetDecoderSteps()) {
+			q.reader = q.src
+			return nil
+		}
+	}
+	var r io.Reader = q.src
+	for _, step := range q.schema.GetDecoderSteps() {
+		mod, err := getStepModifier(step)
+		if err != nil {
+			return err
+		}
+		if dec, ok := mod.(ModifierStreamDecoder); ok {
+			r, err = dec.NewReader(r, q.schema, step)
+		} else {
+			r, err = q.decodeAll(r, mod, step)
+		}
+		if err != nil {
+			return err
+		}
+		if c, ok := r.(io.Closer); ok {
+			q.closers = append(q.closers, c)
+		}
+	}
+	q.reader = r
+	return nil
+}
+
+// decodeAll reads all the data of r and decodes it with a modifier which can't decode progressively.
+func (q *QueueReader) decodeAll(r io.Reader, mod Modifier, step PipelineStep) (io.Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	inputBuf := &buffer.Buffer{}
+	inputBuf.InitFromRawBuffer(data)
+	outBuf, err := mod.Decode(inputBuf, q.schema, step)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(outBuf.GetRawBuffer()[:outBuf.GetByteSize()]), nil
+}
+
+// Next returns the next state of the queue. It returns [io.EOF] when there are no more states.
+func (q *QueueReader) Next() (*State, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	if !q.started {
+		if q.err = q.init(); q.err != nil {
+			return nil, q.err
+		}
+	}
+	if _, err := io.ReadFull(q.reader, q.stateBuf); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = fmt.Errorf("truncated state: %w", err)
+		}
+		q.err = err
+		return nil, err
+	}
+	state, err := q.schema.CreateState()
+	if err != nil {
+		return nil, err
+	}
+	if err = state.Decode(q.stateBuf); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// ForEach calls f for every remaining state of the queue. It stops at the first error returned by f.
+func (q *QueueReader) ForEach(f func(state *State) error) error {
+	for {
+		state, err := q.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err = f(state); err != nil {
+			return err
+		}
+	}
+}
+
+// Close releases the resources used by the decompressors. It doesn't close the underlying reader.
+func (q *QueueReader) Close() error {
+	var err error
+	for i := len(q.closers) - 1; i >= 0; i-- {
+		if cerr := q.closers[i].Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	q.closers = nil
+	if q.err == nil {
+		q.err = errors.New("queue reader closed")
+	}
+	return err
+}
@@ -0,0 +1,98 @@
+package bstates
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testCreateCounterStates(t *testing.T, schema *StateSchema, n int) []*State {
+	states := []*State{}
+	for i := 0; i < n; i++ {
+		state, err := schema.CreateState()
+		require.NoError(t, err)
+		require.NoError(t, state.Set("F_COUNTER", i))
+		states = append(states, state)
+	}
+	return states
+}
+
+func Test_QueueReader(t *testing.T) {
+	pipelines := []string{"", "z", "zstd", "t", "t:z", "t:zstd", "zstd:z", "s2", "snappy", "lz4", "t:lz4"}
+	for _, pipeline := range pipelines {
+		schema := testPipelineComparativeCreateSchema(t, pipeline)
+		states := testCreateCounterStates(t, schema, 1000)
+		queue := CreateStateQueue(schema)
+		require.NoError(t, queue.PushAll(states))
+		data, err := queue.Encode()
+		require.NoError(t, err)
+
+		// Read one byte at a time to check the incremental decoding
+		reader, err := CreateQueueReader(schema, iotest.OneByteReader(bytes.NewReader(data)))
+		require.NoError(t, err)
+		rstates := []*State{}
+		err = reader.ForEach(func(state *State) error {
+			rstates = append(rstates, state)
+			return nil
+		})
+		require.NoError(t, err, pipeline)
+		testEqualStates(t, states, rstates)
+		_, err = reader.Next()
+		require.Equal(t, io.EOF, err)
+		require.NoError(t, reader.Close())
+	}
+}
+
+func Test_QueueReader_Empty(t *testing.T) {
+	for _, pipeline := range []string{"", "z", "t:zstd"} {
+		schema := testPipelineComparativeCreateSchema(t, pipeline)
+		reader, err := CreateQueueReader(schema, bytes.NewReader(nil))
+		require.NoError(t, err)
+		_, err = reader.Next()
+		require.Equal(t, io.EOF, err)
+
+		// Encoded empty queue
+		data, err := CreateStateQueue(schema).Encode()
+		require.NoError(t, err)
+		reader, err = CreateQueueReader(schema, bytes.NewReader(data))
+		require.NoError(t, err)
+		_, err = reader.Next()
+		require.Equal(t, io.EOF, err)
+	}
+}
+
+func Test_QueueReader_Errors(t *testing.T) {
+	schema := testPipelineComparativeCreateSchema(t, "")
+	states := testCreateCounterStates(t, schema, 3)
+	queue := CreateStateQueue(schema)
+	require.NoError(t, queue.PushAll(states))
+	data, err := queue.Encode()
+	require.NoError(t, err)
+
+	// Truncated state
+	reader, err := CreateQueueReader(schema, bytes.NewReader(data[:len(data)-1]))
+	require.NoError(t, err)
+	_, err = reader.Next()
+	require.NoError(t, err)
+	_, err = reader.Next()
+	require.NoError(t, err)
+	_, err = reader.Next()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// Corrupted compressed data
+	zschema := testPipelineComparativeCreateSchema(t, "z")
+	reader, err = CreateQueueReader(zschema, bytes.NewReader([]byte("not gzip data")))
+	require.NoError(t, err)
+	_, err = reader.Next()
+	require.Error(t, err)
+
+	// Reading after close
+	reader, err = CreateQueueReader(schema, bytes.NewReader(data))
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	_, err = reader.Next()
+	require.Error(t, err)
+}
@@ -112,12 +112,24 @@ func Test_PipelineComparative(t *testing.T) {
 	zstdschema := testPipelineComparativeCreateSchema(t, "zstd")
 	tzschema := testPipelineComparativeCreateSchema(t, "t:z")
 	tzstdschema := testPipelineComparativeCreateSchema(t, "t:zstd")
+	s2schema := testPipelineComparativeCreateSchema(t, "s2")
+	snappyschema := testPipelineComparativeCreateSchema(t, "snappy")
+	lz4schema := testPipelineComparativeCreateSchema(t, "lz4")
+	ts2schema := testPipelineComparativeCreateSchema(t, "t:s2")
+	tsnappyschema := testPipelineComparativeCreateSchema(t, "t:snappy")
+	tlz4schema := testPipelineComparativeCreateSchema(t, "t:lz4")
 
 	states := fillStates(schema)
 	zstates := fillStates(zschema)
 	zstdstates := fillStates(zstdschema)
 	tzstates := fillStates(tzschema)
 	tzstdstates := fillStates(tzstdschema)
+	s2states := fillStates(s2schema)
+	snappystates := fillStates(snappyschema)
+	lz4states := fillStates(lz4schema)
+	ts2states := fillStates(ts2schema)
+	tsnappystates := fillStates(tsnappyschema)
+	tlz4states := fillStates(tlz4schema)
 
 	checkPipeline := func(states []*State, schema *StateSchema) (edata []byte) {
 		// Encode
@@ -153,15 +165,33 @@ func Test_PipelineComparative(t *testing.T) {
 	// Encode with t:zstd
 	tzstddata := checkPipeline(tzstdstates, tzstdschema)
 
+	// Encode with s2, snappy and lz4
+	s2data := checkPipeline(s2states, s2schema)
+	snappydata := checkPipeline(snappystates, snappyschema)
+	lz4data := checkPipeline(lz4states, lz4schema)
+
+	// Encode with t:s2, t:snappy and t:lz4
+	ts2data := checkPipeline(ts2states, ts2schema)
+	tsnappydata := checkPipeline(tsnappystates, tsnappyschema)
+	tlz4data := checkPipeline(tlz4states, tlz4schema)
+
 	// -----------------
 
 	fmt.Printf("PipelineComparative: %d states, %d bytes, %d bytes (z), %d bytes (zstd), %d bytes (t:z), %d bytes (t:zstd)\n",
 		len(states), len(data), len(zdata), len(zstddata), len(tzdata), len(tzstddata))
+	fmt.Printf("PipelineComparative: %d bytes (s2), %d bytes (snappy), %d bytes (lz4), %d bytes (t:s2), %d bytes (t:snappy), %d bytes (t:lz4)\n",
+		len(s2data), len(snappydata), len(lz4data), len(ts2data), len(tsnappydata), len(tlz4data))
 
 	require.Less(t, len(zdata), len(data))
 	require.Less(t, len(zstddata), len(data))
 	require.Less(t, len(tzdata), len(zdata))
 	require.Less(t, len(tzstddata), len(zstddata))
+	require.Less(t, len(s2data), len(data))
+	require.Less(t, len(snappydata), len(data))
+	require.Less(t, len(lz4data), len(data))
+	require.Less(t, len(ts2data), len(s2data))
+	require.Less(t, len(tsnappydata), len(snappydata))
+	require.Less(t, len(tlz4data), len(lz4data))
 
 }
 
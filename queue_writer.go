@@ -0,0 +1,118 @@
+package bstates
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// QueueWriter encodes states progressively into an [io.Writer] using the encoder pipeline of the schema.
+// The output can be decoded with [StateQueue.Decode] or [QueueReader].
+//
+// All the modifiers of the pipeline must implement [ModifierStreamEncoder]. [MOD_BITTRANS] and [MOD_AESGCM] don't
+// because the transposition and the encryption need the whole queue.
+type QueueWriter struct {
+	schema *StateSchema
+	sha256 [32]byte
+	writer io.Writer        // input of the encoder pipeline
+	stages []ModifierWriter // in pipeline order
+	count  int
+	closed bool
+}
+
+// CreateQueueWriter creates a [QueueWriter] which writes the encoded states into w.
+func CreateQueueWriter(schema *StateSchema, w io.Writer) (*QueueWriter, error) {
+	q := &QueueWriter{
+		schema: schema,
+		sha256: schema.GetSHA256(),
+	}
+	encPipe := schema.GetEncoderSteps()
+	// Stages are created from the output backwards
+	stages := make([]ModifierWriter, len(encPipe))
+	out := w
+	for i := len(encPipe) - 1; i >= 0; i-- {
+		mod, err := getStepModifier(encPipe[i])
+		if err != nil {
+			return nil, err
+		}
+		enc, ok := mod.(ModifierStreamEncoder)
+		if !ok {
+			return nil, fmt.Errorf("%w: \"%s\"", ErrStreamingNotSupported, encPipe[i].Modifier)
+		}
+		if stages[i], err = enc.NewWriter(out, schema, encPipe[i]); err != nil {
+			return nil, err
+		}
+		out = stages[i]
+	}
+	q.stages = stages
+	q.writer = out
+	return q, nil
+}
+
+// GetSchema returns the schema used to encode the states.
+func (q *QueueWriter) GetSchema() *StateSchema {
+	return q.schema
+}
+
+// GetNumStates returns the number of states pushed.
+func (q *QueueWriter) GetNumStates() int {
+	return q.count
+}
+
+// Push encodes the state and writes it into the pipeline. Compressors buffer their output so data may not
+// reach the underlying writer until [QueueWriter.Flush] or [QueueWriter.Close] are called.
+func (q *QueueWriter) Push(state *State) error {
+	if q.closed {
+		return errors.New("queue writer closed")
+	}
+	if state.GetSchema() != q.schema && state.GetSchema().GetSHA256() != q.sha256 {
+		return fmt.Errorf("schema used in new state does not match the schema used by this queue writer")
+	}
+	stateBuf, err := state.Encode()
+	if err != nil {
+		return err
+	}
+	if _, err = q.writer.Write(stateBuf); err != nil {
+		return err
+	}
+	q.count++
+	return nil
+}
+
+// PushAll pushes all the states provided.
+func (q *QueueWriter) PushAll(states []*State) error {
+	for _, state := range states {
+		if err := q.Push(state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush writes any pending data of the compressors into the underlying writer. The output written
+// so far can be decompressed, but it's not a complete queue until [QueueWriter.Close] is called.
+func (q *QueueWriter) Flush() error {
+	if q.closed {
+		return errors.New("queue writer closed")
+	}
+	for _, stage := range q.stages {
+		if err := stage.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close flushes and finishes the compressed streams. It doesn't close the underlying writer.
+func (q *QueueWriter) Close() error {
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	for _, stage := range q.stages {
+		if err := stage.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package bstates
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_QueueWriter(t *testing.T) {
+	for _, pipeline := range []string{"", "z", "zstd", "zstd:z", "s2", "snappy"} {
+		schema := testPipelineComparativeCreateSchema(t, pipeline)
+		states := testCreateCounterStates(t, schema, 1000)
+
+		out := &bytes.Buffer{}
+		writer, err := CreateQueueWriter(schema, out)
+		require.NoError(t, err)
+		require.NoError(t, writer.PushAll(states[:500]))
+
+		// Flushed data can be read while the stream is still open
+		require.NoError(t, writer.Flush())
+		reader, err := CreateQueueReader(schema, bytes.NewReader(out.Bytes()))
+		require.NoError(t, err)
+		for i := 0; i < 500; i++ {
+			_, err = reader.Next()
+			require.NoError(t, err, pipeline)
+		}
+
+		require.NoError(t, writer.PushAll(states[500:]))
+		require.NoError(t, writer.Close())
+		require.Equal(t, 1000, writer.GetNumStates())
+
+		queue := CreateStateQueue(schema)
+		require.NoError(t, queue.Decode(out.Bytes()))
+		dstates, err := queue.GetStates()
+		require.NoError(t, err)
+		testEqualStates(t, states, dstates)
+	}
+}
+
+func Test_QueueWriter_Empty(t *testing.T) {
+	for _, pipeline := range []string{"", "z", "zstd"} {
+		schema := testPipelineComparativeCreateSchema(t, pipeline)
+		out := &bytes.Buffer{}
+		writer, err := CreateQueueWriter(schema, out)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		queue := CreateStateQueue(schema)
+		require.NoError(t, queue.Decode(out.Bytes()))
+		require.Equal(t, 0, queue.GetNumStates())
+	}
+}
+
+func Test_QueueWriter_Errors(t *testing.T) {
+	_, err := CreateQueueWriter(testPipelineComparativeCreateSchema(t, "t:z"), &bytes.Buffer{})
+	require.True(t, errors.Is(err, ErrStreamingNotSupported))
+	_, err = CreateQueueWriter(testPipelineComparativeCreateSchema(t, "lz4"), &bytes.Buffer{})
+	require.True(t, errors.Is(err, ErrStreamingNotSupported))
+
+	schema := testPipelineComparativeCreateSchema(t, "z")
+	writer, err := CreateQueueWriter(schema, &bytes.Buffer{})
+	require.NoError(t, err)
+
+	otherSchema, err := CreateStateSchema(&StateSchemaParams{
+		Fields: []StateField{{Name: "A", Type: T_INT, Size: 4}},
+	})
+	require.NoError(t, err)
+	otherState, err := otherSchema.CreateState()
+	require.NoError(t, err)
+	require.Error(t, writer.Push(otherState))
+
+	// A state of an equivalent schema is accepted
+	sameSchema := testPipelineComparativeCreateSchema(t, "z")
+	require.NoError(t, writer.PushAll(testCreateCounterStates(t, sameSchema, 1)))
+
+	require.NoError(t, writer.Close())
+	require.NoError(t, writer.Close())
+	require.Error(t, writer.PushAll(testCreateCounterStates(t, schema, 1)))
+	require.Error(t, writer.Flush())
+}
@@ -0,0 +1,115 @@
+package bstates
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/jaracil/ei"
+)
+
+// RangeMapEntry maps the values in [Min, Max) to Label. Use math.Inf(-1) and math.Inf(1) for unbounded ranges,
+// which are serialized omitting "min" or "max".
+type RangeMapEntry struct {
+	Min   float64
+	Max   float64
+	Label string
+}
+
+// Contains reports whether v is in the range of the entry.
+func (e RangeMapEntry) Contains(v float64) bool {
+	return v >= e.Min && v < e.Max
+}
+
+// ToMsi converts the entry into a map[string]interface{}. Unbounded limits are omitted.
+func (e RangeMapEntry) ToMsi() map[string]any {
+	m := map[string]any{"label": e.Label}
+	if !math.IsInf(e.Min, -1) {
+		m["min"] = e.Min
+	}
+	if !math.IsInf(e.Max, 1) {
+		m["max"] = e.Max
+	}
+	return m
+}
+
+// MarshalJSON serializes the entry into JSON format (see [RangeMapEntry.ToMsi]).
+func (e RangeMapEntry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.ToMsi())
+}
+
+// FromMsi initializes the entry from a map[string]interface{}. Missing limits are unbounded.
+func (e *RangeMapEntry) FromMsi(msi map[string]any) (err error) {
+	e.Min, e.Max = math.Inf(-1), math.Inf(1)
+	if _, ok := msi["min"]; ok {
+		if e.Min, err = ei.N(msi).M("min").Float64(); err != nil {
+			return fmt.Errorf("\"min\" field error: %v", err)
+		}
+	}
+	if _, ok := msi["max"]; ok {
+		if e.Max, err = ei.N(msi).M("max").Float64(); err != nil {
+			return fmt.Errorf("\"max\" field error: %v", err)
+		}
+	}
+	if e.Label, err = ei.N(msi).M("label").String(); err != nil {
+		return fmt.Errorf("\"label\" field error: %v", err)
+	}
+	return nil
+}
+
+// UnmarshalJSON deserializes the entry from JSON format.
+func (e *RangeMapEntry) UnmarshalJSON(b []byte) error {
+	msi := map[string]any{}
+	if err := json.Unmarshal(b, &msi); err != nil {
+		return err
+	}
+	return e.FromMsi(msi)
+}
+
+// setDecoderRangeMaps sets a copy of the range maps provided, with the entries sorted by range. Returns an error
+// if a range is empty or if two ranges of the same map overlap.
+func (e *StateSchema) setDecoderRangeMaps(maps map[string][]RangeMapEntry) error {
+	e.decoderRangeMaps = map[string][]RangeMapEntry{}
+	for mapId, entries := range maps {
+		sorted := append([]RangeMapEntry{}, entries...)
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Min < sorted[j].Min })
+		for i, entry := range sorted {
+			if math.IsNaN(entry.Min) || math.IsNaN(entry.Max) || !(entry.Min < entry.Max) {
+				return fmt.Errorf("range map \"%s\": empty range [%v, %v) (%s)", mapId, entry.Min, entry.Max, entry.Label)
+			}
+			if i > 0 && entry.Min < sorted[i-1].Max {
+				prev := sorted[i-1]
+				return fmt.Errorf("range map \"%s\": range [%v, %v) (%s) overlaps [%v, %v) (%s)", mapId,
+					entry.Min, entry.Max, entry.Label, prev.Min, prev.Max, prev.Label)
+			}
+		}
+		e.decoderRangeMaps[mapId] = sorted
+	}
+	return nil
+}
+
+// parseDecoderRangeMaps parses the "decoderRangeMaps" section of a serialized schema.
+func parseDecoderRangeMaps(raw map[string]any) (map[string][]RangeMapEntry, error) {
+	maps := map[string][]RangeMapEntry{}
+	for mapId, mapDataRaw := range raw {
+		mapData, err := ei.N(mapDataRaw).Slice()
+		if err != nil {
+			return nil, fmt.Errorf("can't parse range map \"%s\": %v", mapId, err)
+		}
+		entries := make([]RangeMapEntry, 0, len(mapData))
+		for i, entryRaw := range mapData {
+			msi, err := ei.N(entryRaw).MapStr()
+			if err != nil {
+				return nil, fmt.Errorf("can't parse range map \"%s\" entry %d: %v", mapId, i, err)
+			}
+			entry := RangeMapEntry{}
+			if err = entry.FromMsi(msi); err != nil {
+				return nil, fmt.Errorf("can't parse range map \"%s\" entry %d: %v", mapId, i, err)
+			}
+			entries = append(entries, entry)
+		}
+		maps[mapId] = entries
+	}
+	return maps, nil
+}
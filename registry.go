@@ -0,0 +1,187 @@
+package bstates
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jaracil/ei"
+)
+
+// ErrSchemaNotFound represents an error caused by a schema hash not present in a [SchemaRegistry]
+var ErrSchemaNotFound = errors.New("schema not found")
+
+// ErrSchemaAmbiguous represents an error caused by a schema hash prefix which matches several schemas in a
+// [SchemaRegistry]
+var ErrSchemaAmbiguous = errors.New("ambiguous schema hash prefix")
+
+// SchemaRegistry stores a set of [StateSchema] indexed by their hash ([StateSchema.GetHashString]).
+//
+// It is safe for concurrent use.
+type SchemaRegistry struct {
+	mutex   sync.RWMutex
+	schemas map[string]*registryEntry // Registered schemas indexed by hash string
+}
+
+type registryEntry struct {
+	schema *StateSchema
+	sha256 [32]byte
+}
+
+// CreateSchemaRegistry initializes an empty [SchemaRegistry].
+func CreateSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{
+		schemas: map[string]*registryEntry{},
+	}
+}
+
+// Add stores the schema in the registry and returns its hash string.
+// Adding a schema which is already registered is a no-op.
+func (r *SchemaRegistry) Add(schema *StateSchema) string {
+	sha := schema.GetSHA256()
+	hash := base64.StdEncoding.EncodeToString(sha[:])
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.schemas[hash]; !ok {
+		r.schemas[hash] = &registryEntry{schema: schema, sha256: sha}
+	}
+	return hash
+}
+
+// Remove deletes the schema with the provided hash string from the registry.
+func (r *SchemaRegistry) Remove(hash string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.schemas, hash)
+}
+
+// Get returns the schema with the provided hash string.
+func (r *SchemaRegistry) Get(hash string) (*StateSchema, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	entry, ok := r.schemas[hash]
+	if !ok {
+		return nil, fmt.Errorf("%w: \"%s\"", ErrSchemaNotFound, hash)
+	}
+	return entry.schema, nil
+}
+
+// GetHashes returns the sorted list of hash strings of the registered schemas.
+func (r *SchemaRegistry) GetHashes() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	hashes := make([]string, 0, len(r.schemas))
+	for hash := range r.schemas {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	return hashes
+}
+
+// Len returns the number of registered schemas.
+func (r *SchemaRegistry) Len() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return len(r.schemas)
+}
+
+// LoadFile parses a JSON schema file and adds it to the registry. Returns the hash string of the schema.
+func (r *SchemaRegistry) LoadFile(path string) (string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	schema := &StateSchema{}
+	if err = json.Unmarshal(raw, schema); err != nil {
+		return "", fmt.Errorf("can't parse schema file \"%s\": %v", path, err)
+	}
+	return r.Add(schema), nil
+}
+
+// LoadDir adds to the registry every JSON schema file (*.json) found in the directory provided.
+// Subdirectories are not visited. Returns the hash strings of the loaded schemas.
+func (r *SchemaRegistry) LoadDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	hashes := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".json") {
+			continue
+		}
+		hash, err := r.LoadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
+
+// GetBySHA256Prefix returns the schema whose SHA256 starts with the prefix provided.
+// Returns [ErrSchemaAmbiguous] if more than one registered schema matches the prefix.
+func (r *SchemaRegistry) GetBySHA256Prefix(prefix []byte) (*StateSchema, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	var matches []*StateSchema
+	for _, entry := range r.schemas {
+		if bytes.HasPrefix(entry.sha256[:], prefix) {
+			matches = append(matches, entry.schema)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%w: hash prefix %x", ErrSchemaNotFound, prefix)
+	case 1:
+		return matches[0], nil
+	}
+	return nil, fmt.Errorf("%w: hash prefix %x matches %d schemas", ErrSchemaAmbiguous, prefix, len(matches))
+}
+
+// DecodeQueueMsi creates a [StateQueue] from a map created with [StateQueue.ToMsi],
+// resolving the schema from the registry.
+func (r *SchemaRegistry) DecodeQueueMsi(msg map[string]any) (*StateQueue, error) {
+	hash, err := ei.N(msg).M("schema").String()
+	if err != nil {
+		return nil, err
+	}
+	schema, err := r.Get(hash)
+	if err != nil {
+		return nil, err
+	}
+	queue := CreateStateQueue(schema)
+	if err = queue.FromMsi(msg); err != nil {
+		return nil, err
+	}
+	return queue, nil
+}
+
+// DecodeEnvelope creates a [StateQueue] from an envelope created with [StateQueue.EncodeEnvelope],
+// resolving the schema from the registry. Returns a [SchemaMismatchError] if no registered schema matches the
+// envelope, or [ErrSchemaAmbiguous] if several schemas match its hash prefix.
+func (r *SchemaRegistry) DecodeEnvelope(data []byte) (*StateQueue, error) {
+	header, payload, err := ParseEnvelopeHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := r.GetBySHA256Prefix(header.HashPrefix)
+	if errors.Is(err, ErrSchemaNotFound) {
+		return nil, &SchemaMismatchError{HashPrefix: header.HashPrefix}
+	}
+	if err != nil {
+		return nil, err
+	}
+	queue := CreateStateQueue(schema)
+	if err = queue.decodeEnvelopePayload(header, payload); err != nil {
+		return nil, err
+	}
+	return queue, nil
+}
@@ -0,0 +1,193 @@
+package bstates
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SchemaRegistry(t *testing.T) {
+	schema := testPipelineComparativeCreateSchema(t, "t:z")
+	otherSchema := testPipelineComparativeCreateSchema(t, "z")
+
+	registry := CreateSchemaRegistry()
+	hash := registry.Add(schema)
+	require.Equal(t, schema.GetHashString(), hash)
+	require.Equal(t, hash, registry.Add(schema))
+	require.Equal(t, 1, registry.Len())
+
+	s, err := registry.Get(hash)
+	require.NoError(t, err)
+	require.Equal(t, schema, s)
+
+	_, err = registry.Get(otherSchema.GetHashString())
+	require.True(t, errors.Is(err, ErrSchemaNotFound))
+
+	otherHash := registry.Add(otherSchema)
+	require.Equal(t, 2, registry.Len())
+	require.ElementsMatch(t, []string{hash, otherHash}, registry.GetHashes())
+
+	registry.Remove(otherHash)
+	require.Equal(t, 1, registry.Len())
+}
+
+func Test_SchemaRegistry_LoadDir(t *testing.T) {
+	schema := testPipelineComparativeCreateSchema(t, "t:z")
+	otherSchema := createSchema(t)
+
+	dir := t.TempDir()
+	for name, s := range map[string]*StateSchema{"a.json": schema, "b.JSON": otherSchema} {
+		raw, err := json.Marshal(s)
+		require.NoError(t, err)
+		err = os.WriteFile(filepath.Join(dir, name), raw, 0644)
+		require.NoError(t, err)
+	}
+	err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a schema"), 0644)
+	require.NoError(t, err)
+	err = os.Mkdir(filepath.Join(dir, "subdir.json"), 0755)
+	require.NoError(t, err)
+
+	registry := CreateSchemaRegistry()
+	hashes, err := registry.LoadDir(dir)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{schema.GetHashString(), otherSchema.GetHashString()}, hashes)
+
+	s, err := registry.Get(otherSchema.GetHashString())
+	require.NoError(t, err)
+	require.Equal(t, otherSchema.GetSHA256(), s.GetSHA256())
+
+	// Invalid schema file
+	err = os.WriteFile(filepath.Join(dir, "c.json"), []byte("{}"), 0644)
+	require.NoError(t, err)
+	_, err = registry.LoadDir(dir)
+	require.Error(t, err)
+}
+
+func Test_SchemaRegistry_Decode(t *testing.T) {
+	schema := testPipelineComparativeCreateSchema(t, "t:z")
+	otherSchema := testPipelineComparativeCreateSchema(t, "z")
+
+	registry := CreateSchemaRegistry()
+	registry.Add(schema)
+
+	queue := CreateStateQueue(schema)
+	states := []*State{}
+	for i := 0; i < 10; i++ {
+		state, err := schema.CreateState()
+		require.NoError(t, err)
+		err = state.Set("F_COUNTER", i)
+		require.NoError(t, err)
+		states = append(states, state)
+	}
+	err := queue.PushAll(states)
+	require.NoError(t, err)
+
+	// From msi
+	msg, err := queue.ToMsi()
+	require.NoError(t, err)
+	dqueue, err := registry.DecodeQueueMsi(msg)
+	require.NoError(t, err)
+	dstates, err := dqueue.GetStates()
+	require.NoError(t, err)
+	testEqualStates(t, states, dstates)
+
+	// From envelope
+	data, err := queue.EncodeEnvelope()
+	require.NoError(t, err)
+	dqueue, err = registry.DecodeEnvelope(data)
+	require.NoError(t, err)
+	dstates, err = dqueue.GetStates()
+	require.NoError(t, err)
+	testEqualStates(t, states, dstates)
+
+	// Unknown schema
+	otherQueue := CreateStateQueue(otherSchema)
+	msg, err = otherQueue.ToMsi()
+	require.NoError(t, err)
+	_, err = registry.DecodeQueueMsi(msg)
+	require.True(t, errors.Is(err, ErrSchemaNotFound))
+
+	data, err = otherQueue.EncodeEnvelope()
+	require.NoError(t, err)
+	_, err = registry.DecodeEnvelope(data)
+	require.True(t, errors.Is(err, ErrSchemaMismatch))
+}
+
+func Test_SchemaRegistry_AmbiguousPrefix(t *testing.T) {
+	// Find two schemas whose hashes share the first byte
+	registry := CreateSchemaRegistry()
+	seen := map[byte]*StateSchema{}
+	var schema, other *StateSchema
+	for i := 0; other == nil; i++ {
+		s, err := CreateStateSchema(&StateSchemaParams{
+			Fields: []StateField{{Name: fmt.Sprintf("F%d", i), Type: T_UINT, Size: 8}},
+		})
+		require.NoError(t, err)
+		sha := s.GetSHA256()
+		if prev, ok := seen[sha[0]]; ok {
+			schema, other = prev, s
+		}
+		seen[sha[0]] = s
+	}
+	sha := schema.GetSHA256()
+	registry.Add(schema)
+	found, err := registry.GetBySHA256Prefix(sha[:1])
+	require.NoError(t, err)
+	require.Same(t, schema, found)
+
+	registry.Add(other)
+	_, err = registry.GetBySHA256Prefix(sha[:1])
+	require.ErrorIs(t, err, ErrSchemaAmbiguous)
+	found, err = registry.GetBySHA256Prefix(sha[:])
+	require.NoError(t, err)
+	require.Same(t, schema, found)
+
+	// Envelopes only store a prefix of the hash, so a collision can't be resolved
+	registry.schemas["collision"] = &registryEntry{schema: other, sha256: sha}
+	data, err := CreateStateQueue(schema).EncodeEnvelope()
+	require.NoError(t, err)
+	_, err = registry.DecodeEnvelope(data)
+	require.ErrorIs(t, err, ErrSchemaAmbiguous)
+}
+
+func Test_SchemaRegistry_ConcurrentAccess(t *testing.T) {
+	schema := testPipelineComparativeCreateSchema(t, "t:z")
+	registry := CreateSchemaRegistry()
+	registry.Add(schema)
+
+	queue := CreateStateQueue(schema)
+	state, err := schema.CreateState()
+	require.NoError(t, err)
+	err = queue.Push(state)
+	require.NoError(t, err)
+	msg, err := queue.ToMsi()
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := registry.DecodeQueueMsi(msg)
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			registry.Add(schema)
+			_ = registry.GetHashes()
+			errs <- nil
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,88 @@
+package bstates
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/klauspost/compress/s2"
+
+	"github.com/nayarsystems/buffer/buffer"
+)
+
+// S2Enc compresses the provided buffer using the S2 stream format and returns a new buffer
+// containing the compressed data. S2 is faster than gzip and zstd at the expense of ratio.
+func S2Enc(b *buffer.Buffer) (*buffer.Buffer, error) {
+	return s2Enc(b, s2.WriterConcurrency(1))
+}
+
+// SnappyEnc compresses the provided buffer using the Snappy stream format and returns a new buffer
+// containing the compressed data. The output can be decoded by any Snappy implementation.
+func SnappyEnc(b *buffer.Buffer) (*buffer.Buffer, error) {
+	return s2Enc(b, s2.WriterConcurrency(1), s2.WriterSnappyCompat())
+}
+
+func s2Enc(b *buffer.Buffer, opts ...s2.WriterOption) (*buffer.Buffer, error) {
+	buf := new(bytes.Buffer)
+	wr := s2.NewWriter(buf, opts...)
+	_, err := wr.Write(b.GetRawBuffer()[:b.GetByteSize()])
+	if cerr := wr.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []byte
+	out, err = io.ReadAll(buf)
+	if err != nil {
+		return nil, err
+	}
+	outb := &buffer.Buffer{}
+	outb.InitFromRawBuffer(out)
+	return outb, nil
+}
+
+// S2Dec decompresses the provided buffer which is expected to be in S2 or Snappy stream
+// format and returns a new buffer containing the decompressed data.
+func S2Dec(b *buffer.Buffer) (*buffer.Buffer, error) {
+	r := bytes.NewReader(b.GetRawBuffer()[:b.GetByteSize()])
+	out, err := io.ReadAll(s2.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+	outb := &buffer.Buffer{}
+	outb.InitFromRawBuffer(out)
+	return outb, nil
+}
+
+// SnappyDec decompresses the provided buffer which is expected to be in Snappy stream
+// format and returns a new buffer containing the decompressed data.
+func SnappyDec(b *buffer.Buffer) (*buffer.Buffer, error) {
+	return S2Dec(b)
+}
+
+// s2Modifier is the [MOD_S2] modifier, or the [MOD_SNAPPY] one if snappy is set.
+type s2Modifier struct {
+	snappy bool
+}
+
+func (m s2Modifier) Encode(b *buffer.Buffer, schema *StateSchema, step PipelineStep) (*buffer.Buffer, error) {
+	if m.snappy {
+		return SnappyEnc(b)
+	}
+	return S2Enc(b)
+}
+
+func (s2Modifier) Decode(b *buffer.Buffer, schema *StateSchema, step PipelineStep) (*buffer.Buffer, error) {
+	return S2Dec(b)
+}
+
+func (m s2Modifier) NewWriter(w io.Writer, schema *StateSchema, step PipelineStep) (ModifierWriter, error) {
+	if m.snappy {
+		return s2.NewWriter(w, s2.WriterConcurrency(1), s2.WriterSnappyCompat()), nil
+	}
+	return s2.NewWriter(w, s2.WriterConcurrency(1)), nil
+}
+
+func (s2Modifier) NewReader(r io.Reader, schema *StateSchema, step PipelineStep) (io.Reader, error) {
+	return s2.NewReader(r), nil
+}
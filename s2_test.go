@@ -0,0 +1,39 @@
+package bstates
+
+import (
+	"testing"
+
+	"github.com/nayarsystems/buffer/buffer"
+	"github.com/nayarsystems/buffer/shuffling"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_S2BufferCompression(t *testing.T) {
+	b := &buffer.Buffer{}
+	b.InitFromRawBuffer(make([]byte, 10000))
+
+	for i := 0; i < b.GetByteSize(); i++ {
+		b.GetRawBuffer()[i] = uint8(i)
+	}
+
+	bt, err := shuffling.TransposeBits(b, 8)
+	require.Nil(t, err)
+	require.Equal(t, b.GetByteSize(), bt.GetByteSize())
+
+	for _, enc := range []func(*buffer.Buffer) (*buffer.Buffer, error){S2Enc, SnappyEnc} {
+		eb, err := enc(b)
+		require.Nil(t, err)
+		db, err := S2Dec(eb)
+		require.Nil(t, err)
+		require.Equal(t, b, db)
+
+		ebt, err := enc(bt)
+		require.Nil(t, err)
+		dbt, err := SnappyDec(ebt)
+		require.Nil(t, err)
+		require.Equal(t, bt, dbt)
+
+		require.Less(t, eb.GetByteSize(), b.GetByteSize())
+		require.Less(t, ebt.GetByteSize(), bt.GetByteSize())
+	}
+}
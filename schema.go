@@ -8,17 +8,24 @@ import (
 	"fmt"
 	"github.com/jaracil/ei"
 	"math"
-	"regexp"
 	"sort"
 	"strconv"
-	"strings"
+	"sync"
 )
 
 // encoderPipeline options
 const (
-	MOD_GZIP     = "z"    // run gzip compression
-	MOD_ZSTD     = "zstd" // run zstd compression
-	MOD_BITTRANS = "t"    // transpose the event matrix, for better compression
+	MOD_GZIP     = "z"      // run gzip compression
+	MOD_ZSTD     = "zstd"   // run zstd compression
+	MOD_BITTRANS = "t"      // transpose the event matrix, for better compression
+	MOD_XOR      = "x"      // XOR every state row with the previous one, for better compression
+	MOD_DELTA    = "d"      // replace numeric fields by the difference with the previous state, for better compression
+	MOD_S2       = "s2"     // run s2 compression, faster than gzip and zstd
+	MOD_SNAPPY   = "snappy" // run snappy compression (compatible with any snappy decoder)
+	MOD_LZ4      = "lz4"    // run lz4 compression, the fastest one
+	MOD_AESGCM   = "aesgcm" // encrypt and authenticate with AES-GCM, using a key of the [KeyProvider] of the schema
+	MOD_CRC32C   = "crc32c" // append a CRC32C checksum of the data, verified on decoding
+	MOD_COLUMNS  = "col"    // lay out the values of every field of all the states together, for better compression
 )
 
 const (
@@ -29,24 +36,30 @@ const (
 // StateSchema represents the schema used for encoding/decoding states.
 // Fields within an schema can be plain or encoded.
 type StateSchema struct {
-	meta            map[string]any               // Meta data associated with the schema
-	fields          []StateField                 // List of state fields defined in the schema
-	fieldsMap       map[string]*StateField       // Map of field names to StateField objects for quick access
-	decodedFields   map[string]DecodedStateField // List of decoders defined in the schema
-	fieldsBitSize   int                          // Total size of fields in bits
-	fieldsByteSize  int                          // Total size of fields in bytes
-	encoderPipeline []string                     // Pipeline used for compressing an [StateQueue], an [StateQueue] is a set of states.
-	decoderPipeline []string                     // Pipeline used for decompressing an [StateQueue], same as [encoderPipeline] but in reverse order
-	decoderIntMaps  map[string]map[int64]any     // Integer mappings used for decoding encoded fields
+	meta             map[string]any               // Meta data associated with the schema
+	fields           []StateField                 // List of state fields defined in the schema
+	fieldsMap        map[string]*StateField       // Map of field names to StateField objects for quick access
+	decodedFields    map[string]DecodedStateField // List of decoders defined in the schema
+	fieldsBitSize    int                          // Total size of fields in bits
+	fieldsByteSize   int                          // Total size of fields in bytes
+	encoderPipeline  []PipelineStep               // Pipeline used for compressing an [StateQueue], an [StateQueue] is a set of states.
+	decoderPipeline  []PipelineStep               // Pipeline used for decompressing an [StateQueue], same as [encoderPipeline] but in reverse order
+	decoderIntMaps   map[string]map[int64]any     // Integer mappings used for decoding encoded fields
+	decoderRangeMaps map[string][]RangeMapEntry   // Range mappings used for decoding encoded fields, sorted by range
+	zstdDicts        map[uint32][]byte            // Zstd dictionaries referenced by the encoder pipeline, by id
+	keyProvider      KeyProvider                  // Keys used by the encryption modifiers (not serialized)
+	keyProviderMutex sync.RWMutex                 // Guards keyProvider
 }
 
 // StateSchemaParams represents the parameters for constructing a [StateSchema].
 type StateSchemaParams struct {
-	Meta            map[string]any           // Meta data to associate with the schema
-	Fields          []StateField             // List of fields to define in the schema
-	DecodedFields   []DecodedStateField      // List of decoded views to define in the schema
-	EncoderPipeline string                   // Encoder pipeline to use to package and unpackage a [StateQueue]
-	DecoderIntMaps  map[string]map[int64]any // Integer mappings used for decoding encoded integer fields
+	Meta             map[string]any             // Meta data to associate with the schema
+	Fields           []StateField               // List of fields to define in the schema
+	DecodedFields    []DecodedStateField        // List of decoded views to define in the schema
+	EncoderPipeline  string                     // Encoder pipeline to use to package and unpackage a [StateQueue]
+	DecoderIntMaps   map[string]map[int64]any   // Integer mappings used for decoding encoded integer fields
+	DecoderRangeMaps map[string][]RangeMapEntry // Range mappings used for decoding encoded numeric fields (see [RangeMapDecoder])
+	ZstdDicts        map[uint32][]byte          // Zstd dictionaries referenced by the encoder pipeline (see [TrainZstdDictionary])
 }
 
 // CreateStateSchema initializes a [StateSchema] from the provided parameters.
@@ -57,6 +70,9 @@ func CreateStateSchema(params *StateSchemaParams) (e *StateSchema, err error) {
 	} else {
 		e.meta = map[string]any{}
 	}
+	if err = e.setZstdDicts(params.ZstdDicts); err != nil {
+		return nil, err
+	}
 	if err = e.setPipelines(params.EncoderPipeline); err != nil {
 		return nil, err
 	}
@@ -90,6 +106,9 @@ func CreateStateSchema(params *StateSchemaParams) (e *StateSchema, err error) {
 			nm[i] = v
 		}
 	}
+	if err = e.setDecoderRangeMaps(params.DecoderRangeMaps); err != nil {
+		return nil, err
+	}
 	return
 }
 
@@ -139,7 +158,7 @@ func (s *StateSchema) ToMsi() map[string]any {
 	})
 	data := map[string]any{
 		"version":         SCHEMA_VERSION_2_0,
-		"encoderPipeline": strings.Join(s.encoderPipeline, ":"),
+		"encoderPipeline": pipelineToString(s.encoderPipeline),
 		"decoderIntMaps":  s.decoderIntMaps,
 		"decodedFields":   decodedFieldsList,
 		"fields":          s.fields,
@@ -149,6 +168,14 @@ func (s *StateSchema) ToMsi() map[string]any {
 	if len(s.meta) > 0 {
 		data["meta"] = s.meta
 	}
+	// Same for zstd dictionaries
+	if len(s.zstdDicts) > 0 {
+		data["zstdDicts"] = s.zstdDicts
+	}
+	// Same for range maps
+	if len(s.decoderRangeMaps) > 0 {
+		data["decoderRangeMaps"] = s.decoderRangeMaps
+	}
 	return data
 }
 
@@ -179,6 +206,26 @@ func (s *StateSchema) UnmarshalJSON(b []byte) error {
 		s.meta = map[string]any{}
 	}
 
+	zstdDicts := map[uint32][]byte{}
+	for idStr, dictRaw := range ei.N(rawMap).M("zstdDicts").MapStrZ() {
+		id, err := strconv.ParseUint(idStr, 10, 32)
+		if err != nil {
+			return fmt.Errorf("can't parse \"%s\" as zstd dictionary id: %v", idStr, err)
+		}
+		dictStr, err := ei.N(dictRaw).String()
+		if err != nil {
+			return fmt.Errorf("can't parse zstd dictionary %d: %v", id, err)
+		}
+		dict, err := base64.StdEncoding.DecodeString(dictStr)
+		if err != nil {
+			return fmt.Errorf("can't parse zstd dictionary %d: %v", id, err)
+		}
+		zstdDicts[uint32(id)] = dict
+	}
+	if err = s.setZstdDicts(zstdDicts); err != nil {
+		return err
+	}
+
 	if err = s.setPipelines(ei.N(rawMap).M("encoderPipeline").StringZ()); err != nil {
 		return err
 	}
@@ -227,6 +274,13 @@ func (s *StateSchema) UnmarshalJSON(b []byte) error {
 		}
 		s.decoderIntMaps[mapId] = newMap
 	}
+	rangeMaps, err := parseDecoderRangeMaps(ei.N(rawMap).M("decoderRangeMaps").MapStrZ())
+	if err != nil {
+		return err
+	}
+	if err = s.setDecoderRangeMaps(rangeMaps); err != nil {
+		return err
+	}
 	s.decodedFields = map[string]DecodedStateField{}
 
 	if version == SCHEMA_VERSION_2_0 {
@@ -309,14 +363,55 @@ func (s *StateSchema) GetSHA256() [32]byte {
 
 // GetEncoderPipeline returns the encoder pipeline steps as a list of strings.
 func (s *StateSchema) GetEncoderPipeline() []string {
-	return s.encoderPipeline
+	return pipelineStepStrings(s.encoderPipeline)
 }
 
 // GetDecoderPipeline returns the decoder pipeline steps as a list of strings.
 func (s *StateSchema) GetDecoderPipeline() []string {
+	return pipelineStepStrings(s.decoderPipeline)
+}
+
+// GetEncoderSteps returns the encoder pipeline steps with their parameters.
+func (s *StateSchema) GetEncoderSteps() []PipelineStep {
+	return s.encoderPipeline
+}
+
+// GetDecoderSteps returns the decoder pipeline steps with their parameters.
+func (s *StateSchema) GetDecoderSteps() []PipelineStep {
 	return s.decoderPipeline
 }
 
+// SetKeyProvider sets the [KeyProvider] used to look up the keys of the encryption modifiers. The key
+// provider is not part of the serialized schema, so it must be set on every schema which uses encryption.
+//
+// It's safe to call it while the schema is in use, but it should be set before the schema is shared (e.g.
+// through a [SchemaRegistry]) so every queue encoded or decoded with the schema uses the same keys.
+func (s *StateSchema) SetKeyProvider(provider KeyProvider) {
+	s.keyProviderMutex.Lock()
+	defer s.keyProviderMutex.Unlock()
+	s.keyProvider = provider
+}
+
+// GetKeyProvider returns the [KeyProvider] of the schema.
+func (s *StateSchema) GetKeyProvider() KeyProvider {
+	s.keyProviderMutex.RLock()
+	defer s.keyProviderMutex.RUnlock()
+	return s.keyProvider
+}
+
+// GetDecoderIntMap returns the integer mapping with the provided id used by [IntMapDecoder].
+// The returned map must not be modified.
+func (s *StateSchema) GetDecoderIntMap(id string) (map[int64]any, bool) {
+	intMap, ok := s.decoderIntMaps[id]
+	return intMap, ok
+}
+
+// GetZstdDict returns the zstd dictionary with the provided id.
+func (s *StateSchema) GetZstdDict(id uint32) ([]byte, bool) {
+	dict, ok := s.zstdDicts[id]
+	return dict, ok
+}
+
 func (s *StateSchema) updateByteSize() {
 	s.fieldsByteSize = s.fieldsBitSize / 8
 	if s.fieldsBitSize%8 != 0 {
@@ -325,35 +420,45 @@ func (s *StateSchema) updateByteSize() {
 }
 
 // setPipelines sets up the encoding and decoding pipelines based on a raw pipeline string.
-// Returns an error if the pipeline format is incorrect or if there is an unknown modifier.
+// Returns an error if the pipeline format is incorrect, if there is an unknown modifier,
+// if the parameters of a modifier are not valid or if a zstd modifier references a dictionary
+// which is not in the schema (the dictionaries must be set before).
 func (e *StateSchema) setPipelines(pipelineRaw string) error {
-	modifiers := []string{}
-	if pipelineRaw != "" {
-		pipelineRegex, err := regexp.Compile(`^([^:]+)(:[^:]+)*$`)
-		if err != nil {
-			return err
-		}
-		ok := pipelineRegex.MatchString(pipelineRaw)
-		if !ok {
-			return fmt.Errorf("wrong pipeline format")
+	steps, err := ParsePipeline(pipelineRaw)
+	if err != nil {
+		return err
+	}
+	for _, step := range steps {
+		if step.Modifier != MOD_ZSTD {
+			continue
 		}
-		modifiers = strings.Split(pipelineRaw, ":")
-		for _, mod := range modifiers {
-			switch mod {
-			case MOD_GZIP, MOD_ZSTD, MOD_BITTRANS:
-			default:
-				return fmt.Errorf("\"%s\" is not a modifier", mod)
-			}
+		if _, _, err = e.getZstdStepDict(step); err != nil {
+			return err
 		}
 	}
-	e.encoderPipeline = modifiers
-	e.decoderPipeline = make([]string, len(e.encoderPipeline))
+	e.encoderPipeline = steps
+	e.decoderPipeline = make([]PipelineStep, len(e.encoderPipeline))
 	for mi, m := range e.encoderPipeline {
 		e.decoderPipeline[len(e.encoderPipeline)-1-mi] = m
 	}
 	return nil
 }
 
+// setZstdDicts sets a copy of the zstd dictionaries provided.
+func (e *StateSchema) setZstdDicts(dicts map[uint32][]byte) error {
+	e.zstdDicts = map[uint32][]byte{}
+	for id, dict := range dicts {
+		if id == 0 {
+			return fmt.Errorf("zstd dictionary id 0 is reserved")
+		}
+		if len(dict) == 0 {
+			return fmt.Errorf("zstd dictionary %d is empty", id)
+		}
+		e.zstdDicts[id] = append([]byte{}, dict...)
+	}
+	return nil
+}
+
 // StateFieldType represents the type of a field in the StateSchema.
 type StateFieldType int
 
@@ -487,27 +592,11 @@ func (e *StateField) FromMsi(rawField map[string]any) (err error) {
 	// Reset decimals and cached factor fields to ensure clean state
 	e.Decimals = 0
 	e.fixedPointCachedFactor = 0
-	switch {
-	case typeStr == "int":
-		e.Type = T_INT
-	case typeStr == "uint":
-		e.Type = T_UINT
-	case typeStr == "bool":
-		e.Type = T_BOOL
-	case typeStr == "float32":
-		e.Type = T_FLOAT32
-	case typeStr == "float64":
-		e.Type = T_FLOAT64
-	case typeStr == "buffer":
-		e.Type = T_BUFFER
-	case typeStr == "fixed":
-		e.Type = T_FIXED
-		e.Decimals = ei.N(rawField).M("decimals").UintZ()
-	case typeStr == "ufixed":
-		e.Type = T_UFIXED
+	if e.Type, err = parseStateFieldType(typeStr); err != nil {
+		return err
+	}
+	if e.Type == T_FIXED || e.Type == T_UFIXED {
 		e.Decimals = ei.N(rawField).M("decimals").UintZ()
-	default:
-		return fmt.Errorf("unkown field type '%s'", typeStr)
 	}
 
 	// Parse aliases using shared function
@@ -522,6 +611,29 @@ func (e *StateField) FromMsi(rawField map[string]any) (err error) {
 	return
 }
 
+// parseStateFieldType returns the [StateFieldType] matching the type name used in the JSON representation of a field.
+func parseStateFieldType(typeStr string) (StateFieldType, error) {
+	switch typeStr {
+	case "int":
+		return T_INT, nil
+	case "uint":
+		return T_UINT, nil
+	case "bool":
+		return T_BOOL, nil
+	case "float32":
+		return T_FLOAT32, nil
+	case "float64":
+		return T_FLOAT64, nil
+	case "buffer":
+		return T_BUFFER, nil
+	case "fixed":
+		return T_FIXED, nil
+	case "ufixed":
+		return T_UFIXED, nil
+	}
+	return 0, fmt.Errorf("unkown field type '%s'", typeStr)
+}
+
 // normalize ensures the field's type and size are consistent and initialize default values.
 func (e *StateField) normalize() error {
 	var defaultValue any
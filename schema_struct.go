@@ -0,0 +1,263 @@
+package bstates
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// SchemaIntMapsProvider can be implemented by the structs passed to [SchemaFromStruct] to provide the
+// integer mappings (StateSchemaParams.DecoderIntMaps) of the schema.
+type SchemaIntMapsProvider interface {
+	BstatesIntMaps() map[string]map[int64]any
+}
+
+// SchemaPipelineProvider can be implemented by the structs passed to [SchemaFromStruct] to provide the
+// encoder pipeline of the schema.
+type SchemaPipelineProvider interface {
+	BstatesEncoderPipeline() string
+}
+
+// SchemaMetaProvider can be implemented by the structs passed to [SchemaFromStruct] to provide the
+// meta data of the schema.
+type SchemaMetaProvider interface {
+	BstatesMeta() map[string]any
+}
+
+// Struct tag keys which are not decoder params
+var structTagFieldKeys = map[string]bool{
+	"name":     true,
+	"type":     true,
+	"size":     true,
+	"decimals": true,
+	"default":  true,
+	"aliases":  true,
+	"decoder":  true,
+	"map":      true,
+	"readonly": true,
+}
+
+// SchemaFromStruct builds the [StateSchemaParams] described by the "bstates" struct tags of v (a struct or
+// a pointer to struct). Every tagged struct field defines either a [StateField] or, if the "decoder" key is
+// present, a [DecodedStateField]. Fields are added to the schema in the same order as they are declared.
+//
+// Tags are lists of comma separated key=value pairs:
+//
+//	type Status struct {
+//		Temp      float64  `bstates:"name=TEMP,type=fixed,size=12,decimals=1"`
+//		Code      uint8    `bstates:"name=STATE_CODE,size=2,aliases=CODE|OLD_CODE"`
+//		State     string   `bstates:"name=STATE,decoder=IntMap,from=STATE_CODE,mapId=STATE_MAP,map=0:IDLE|1:RUNNING"`
+//		Flags     []string `bstates:"name=FLAGS,decoder=Flags,from=STATE_CODE,flags=A:0|B:1"`
+//		Message   []byte   `bstates:"name=MESSAGE,size=64"`
+//	}
+//
+// Field keys are "name", "type" (int, uint, bool, float32, float64, buffer, fixed or ufixed), "size", "decimals",
+// "default" and "aliases" (separated by '|'). When "type" is omitted it is inferred from the Go type: bool, signed
+// and unsigned integers (using the size of the Go type if "size" is omitted), float32, float64, and []byte or string
+// for buffers. Decoded fields use the keys "name", "decoder" and "aliases", every other key is passed to [NewDecoder]
+// as a parameter ("flags" is parsed as a list of name:bit pairs). IntMap decoders can declare their map inline with
+// "map" as a list of int:value pairs. The "readonly" option used by [State.Marshal] is allowed in both.
+//
+// Int maps, encoder pipeline and meta data can also be provided implementing [SchemaIntMapsProvider],
+// [SchemaPipelineProvider] and [SchemaMetaProvider].
+func SchemaFromStruct(v any) (*StateSchemaParams, error) {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a struct, got %T", v)
+	}
+	params := &StateSchemaParams{
+		Fields:         []StateField{},
+		DecodedFields:  []DecodedStateField{},
+		DecoderIntMaps: map[string]map[int64]any{},
+	}
+	for _, bf := range getBoundFields(reflect.New(t).Elem()) {
+		if _, ok := bf.tag.options["decoder"]; ok {
+			df, err := decodedFieldFromTag(bf, params.DecoderIntMaps)
+			if err != nil {
+				return nil, fmt.Errorf("decoded field \"%s\": %v", bf.tag.name, err)
+			}
+			params.DecodedFields = append(params.DecodedFields, *df)
+			continue
+		}
+		field, err := stateFieldFromTag(bf)
+		if err != nil {
+			return nil, fmt.Errorf("field \"%s\": %v", bf.tag.name, err)
+		}
+		params.Fields = append(params.Fields, *field)
+	}
+
+	// Companion methods can be declared on either the value or the pointer receiver
+	instance := reflect.New(t).Interface()
+	if p, ok := instance.(SchemaIntMapsProvider); ok {
+		for mapId, m := range p.BstatesIntMaps() {
+			if _, exists := params.DecoderIntMaps[mapId]; exists {
+				return nil, fmt.Errorf("int map \"%s\" declared twice", mapId)
+			}
+			params.DecoderIntMaps[mapId] = m
+		}
+	}
+	if p, ok := instance.(SchemaPipelineProvider); ok {
+		params.EncoderPipeline = p.BstatesEncoderPipeline()
+	}
+	if p, ok := instance.(SchemaMetaProvider); ok {
+		params.Meta = p.BstatesMeta()
+	}
+	return params, nil
+}
+
+// CreateStateSchemaFromStruct creates a [StateSchema] from the struct tags of v. See [SchemaFromStruct].
+func CreateStateSchemaFromStruct(v any) (*StateSchema, error) {
+	params, err := SchemaFromStruct(v)
+	if err != nil {
+		return nil, err
+	}
+	return CreateStateSchema(params)
+}
+
+func stateFieldFromTag(bf boundField) (*StateField, error) {
+	field := &StateField{Name: bf.tag.name}
+	opts := bf.tag.options
+	for k := range opts {
+		if !structTagFieldKeys[k] {
+			return nil, fmt.Errorf("unknown tag key \"%s\"", k)
+		}
+	}
+	var err error
+	if sizeStr, ok := opts["size"]; ok {
+		if field.Size, err = strconv.Atoi(sizeStr); err != nil {
+			return nil, fmt.Errorf("invalid size \"%s\"", sizeStr)
+		}
+	}
+	if decimalsStr, ok := opts["decimals"]; ok {
+		decimals, err := strconv.ParseUint(decimalsStr, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid decimals \"%s\"", decimalsStr)
+		}
+		field.Decimals = uint(decimals)
+	}
+	if typeStr, ok := opts["type"]; ok {
+		if field.Type, err = parseStateFieldType(typeStr); err != nil {
+			return nil, err
+		}
+	} else if field.Type, err = inferFieldType(bf.field.Type, field); err != nil {
+		return nil, err
+	}
+	if defaultStr, ok := opts["default"]; ok {
+		field.DefaultValue = defaultStr
+	}
+	if aliasesStr, ok := opts["aliases"]; ok && aliasesStr != "" {
+		field.Aliases = strings.Split(aliasesStr, "|")
+	}
+	if err = field.normalize(); err != nil {
+		return nil, err
+	}
+	return field, nil
+}
+
+// inferFieldType returns the StateFieldType matching the Go type. The field size is set to the
+// Go type size for integers if not already defined.
+func inferFieldType(t reflect.Type, field *StateField) (StateFieldType, error) {
+	setSize := func() {
+		if field.Size == 0 {
+			field.Size = int(t.Size()) * 8
+		}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return T_BOOL, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		setSize()
+		return T_INT, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		setSize()
+		return T_UINT, nil
+	case reflect.Float32:
+		return T_FLOAT32, nil
+	case reflect.Float64:
+		return T_FLOAT64, nil
+	case reflect.String:
+		return T_BUFFER, nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return T_BUFFER, nil
+		}
+	}
+	return 0, fmt.Errorf("can't infer field type from Go type %s (use the \"type\" key)", t)
+}
+
+func decodedFieldFromTag(bf boundField, intMaps map[string]map[int64]any) (*DecodedStateField, error) {
+	opts := bf.tag.options
+	params := map[string]any{}
+	for k, v := range opts {
+		if structTagFieldKeys[k] {
+			continue
+		}
+		if k == "flags" {
+			flags, err := parseTagPairs(v, func(s string) (any, error) {
+				return strconv.ParseUint(s, 10, 8)
+			})
+			if err != nil {
+				return nil, fmt.Errorf("invalid flags: %v", err)
+			}
+			flagsMsi := map[string]any{}
+			for name, bit := range flags {
+				flagsMsi[name] = bit
+			}
+			params[k] = flagsMsi
+			continue
+		}
+		params[k] = v
+	}
+	if mapStr, ok := opts["map"]; ok {
+		mapId, ok := opts["mapId"]
+		if !ok {
+			return nil, fmt.Errorf("\"map\" requires \"mapId\"")
+		}
+		if _, exists := intMaps[mapId]; exists {
+			return nil, fmt.Errorf("int map \"%s\" declared twice", mapId)
+		}
+		pairs, err := parseTagPairs(mapStr, func(s string) (any, error) { return s, nil })
+		if err != nil {
+			return nil, fmt.Errorf("invalid map: %v", err)
+		}
+		intMap := map[int64]any{}
+		for k, v := range pairs {
+			i, err := strconv.ParseInt(k, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid map key \"%s\"", k)
+			}
+			intMap[i] = v
+		}
+		intMaps[mapId] = intMap
+	}
+	decoder, err := NewDecoder(opts["decoder"], params)
+	if err != nil {
+		return nil, err
+	}
+	df := &DecodedStateField{Name: bf.tag.name, Decoder: decoder}
+	if aliasesStr, ok := opts["aliases"]; ok && aliasesStr != "" {
+		df.Aliases = strings.Split(aliasesStr, "|")
+	}
+	return df, nil
+}
+
+// parseTagPairs parses a list of key:value pairs separated by '|'.
+func parseTagPairs(s string, parseValue func(string) (any, error)) (map[string]any, error) {
+	out := map[string]any{}
+	for _, pair := range strings.Split(s, "|") {
+		k, v, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("\"%s\" is not a key:value pair", pair)
+		}
+		value, err := parseValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for \"%s\": %v", k, err)
+		}
+		out[k] = value
+	}
+	return out, nil
+}
@@ -0,0 +1,174 @@
+package bstates
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type structSchemaTestBase struct {
+	Code uint8 `bstates:"name=STATE_CODE,size=2,aliases=CODE|OLD_CODE"`
+}
+
+type structSchemaTest struct {
+	structSchemaTestBase
+	Temp    float64  `bstates:"name=TEMP,type=fixed,size=12,decimals=1"`
+	Level   int8     `bstates:"name=LEVEL,default=-3"`
+	Enabled bool     `bstates:"name=ENABLED"`
+	Raw     uint16   `bstates:"name=FLAGS_RAW,size=4"`
+	TsRaw   uint32   `bstates:"name=TS_RAW"`
+	Message []byte   `bstates:"name=MESSAGE,size=64"`
+	State   string   `bstates:"name=STATE,decoder=IntMap,from=STATE_CODE,mapId=STATE_MAP,map=0:IDLE|1:RUNNING,readonly"`
+	Flags   []string `bstates:"name=FLAGS,decoder=Flags,from=FLAGS_RAW,flags=A:0|B:3"`
+	Ts      uint64   `bstates:"name=TS,decoder=NumberToUnixTsMs,from=TS_RAW,year=2020,factor=1000"`
+	Mode    string   `bstates:"name=MODE,decoder=IntMap,from=LEVEL,mapId=MODE_MAP,readonly"`
+	Ignored int      `bstates:"-"`
+	NoTag   int
+}
+
+func (structSchemaTest) BstatesEncoderPipeline() string {
+	return "t:z"
+}
+
+func (*structSchemaTest) BstatesIntMaps() map[string]map[int64]any {
+	return map[string]map[int64]any{
+		"MODE_MAP": {-3: "LOW", 3: "HIGH"},
+	}
+}
+
+func (structSchemaTest) BstatesMeta() map[string]any {
+	return map[string]any{"device": "sensor"}
+}
+
+const structSchemaTestJSON = `
+{
+	"version": "2.0",
+	"encoderPipeline": "t:z",
+	"meta": {"device": "sensor"},
+	"decoderIntMaps": {
+		"STATE_MAP": {"0": "IDLE", "1": "RUNNING"},
+		"MODE_MAP": {"-3": "LOW", "3": "HIGH"}
+	},
+	"decodedFields": [
+		{"name": "STATE", "decoder": "IntMap", "params": {"from": "STATE_CODE", "mapId": "STATE_MAP"}},
+		{"name": "FLAGS", "decoder": "Flags", "params": {"from": "FLAGS_RAW", "flags": {"A": 0, "B": 3}}},
+		{"name": "TS", "decoder": "NumberToUnixTsMs", "params": {"from": "TS_RAW", "year": 2020, "factor": 1000}},
+		{"name": "MODE", "decoder": "IntMap", "params": {"from": "LEVEL", "mapId": "MODE_MAP"}}
+	],
+	"fields": [
+		{"name": "STATE_CODE", "type": "uint", "size": 2, "aliases": ["CODE", "OLD_CODE"]},
+		{"name": "TEMP", "type": "fixed", "size": 12, "decimals": 1},
+		{"name": "LEVEL", "type": "int", "size": 8, "defaultValue": -3},
+		{"name": "ENABLED", "type": "bool", "size": 1},
+		{"name": "FLAGS_RAW", "type": "uint", "size": 4},
+		{"name": "TS_RAW", "type": "uint", "size": 32},
+		{"name": "MESSAGE", "type": "buffer", "size": 64}
+	]
+}
+`
+
+func Test_SchemaFromStruct(t *testing.T) {
+	params, err := SchemaFromStruct(&structSchemaTest{})
+	require.NoError(t, err)
+	require.Len(t, params.Fields, 7)
+	require.Len(t, params.DecodedFields, 4)
+	require.Equal(t, "t:z", params.EncoderPipeline)
+	require.Equal(t, map[int64]any{0: "IDLE", 1: "RUNNING"}, params.DecoderIntMaps["STATE_MAP"])
+
+	names := []string{}
+	for _, f := range params.Fields {
+		names = append(names, f.Name)
+	}
+	require.Equal(t, []string{"STATE_CODE", "TEMP", "LEVEL", "ENABLED", "FLAGS_RAW", "TS_RAW", "MESSAGE"}, names)
+	require.Equal(t, int64(-3), params.Fields[2].DefaultValue)
+
+	// Same hash as the hand-written JSON
+	fromStruct, err := CreateStateSchemaFromStruct(structSchemaTest{})
+	require.NoError(t, err)
+	var fromJSON StateSchema
+	err = json.Unmarshal([]byte(structSchemaTestJSON), &fromJSON)
+	require.NoError(t, err)
+	require.Equal(t, fromJSON.GetHashString(), fromStruct.GetHashString())
+
+	// The generated schema can be used with State.Marshal and State.Unmarshal
+	state, err := fromStruct.CreateState()
+	require.NoError(t, err)
+	in := structSchemaTest{
+		structSchemaTestBase: structSchemaTestBase{Code: 1},
+		Temp:                 -20.5,
+		Level:                3,
+		Flags:                []string{"B"},
+		Message:              []byte("hi"),
+	}
+	in.Ts = 1577836800000 + 5000 // 2020-01-01 + 5s
+	require.NoError(t, state.Marshal(in))
+	var out structSchemaTest
+	err = state.Unmarshal(&out)
+	require.NoError(t, err)
+	require.Equal(t, uint8(1), out.Code)
+	require.Equal(t, -20.5, out.Temp)
+	require.Equal(t, "RUNNING", out.State)
+	require.Equal(t, "HIGH", out.Mode)
+	require.Equal(t, []string{"B"}, out.Flags)
+	require.Equal(t, in.Ts, out.Ts)
+}
+
+func Test_SchemaFromStruct_Errors(t *testing.T) {
+	_, err := SchemaFromStruct(5)
+	require.Error(t, err)
+	_, err = SchemaFromStruct(nil)
+	require.Error(t, err)
+
+	var unknownType struct {
+		A int `bstates:"name=A,type=int23,size=4"`
+	}
+	_, err = SchemaFromStruct(unknownType)
+	require.Error(t, err)
+
+	var unknownKey struct {
+		A int `bstates:"name=A,sise=4"`
+	}
+	_, err = SchemaFromStruct(unknownKey)
+	require.Error(t, err)
+
+	var noInference struct {
+		A map[string]int `bstates:"name=A"`
+	}
+	_, err = SchemaFromStruct(noInference)
+	require.Error(t, err)
+
+	var bufferWithoutSize struct {
+		A []byte `bstates:"name=A"`
+	}
+	_, err = SchemaFromStruct(bufferWithoutSize)
+	require.Error(t, err)
+
+	var badDecoder struct {
+		A int    `bstates:"name=A,size=4"`
+		B string `bstates:"name=B,decoder=Unknown,from=A"`
+	}
+	_, err = SchemaFromStruct(badDecoder)
+	require.Error(t, err)
+
+	var mapWithoutId struct {
+		A int    `bstates:"name=A,size=4"`
+		B string `bstates:"name=B,decoder=IntMap,from=A,map=0:X"`
+	}
+	_, err = SchemaFromStruct(mapWithoutId)
+	require.Error(t, err)
+
+	var badMap struct {
+		A int    `bstates:"name=A,size=4"`
+		B string `bstates:"name=B,decoder=IntMap,from=A,mapId=M,map=X:0"`
+	}
+	_, err = SchemaFromStruct(badMap)
+	require.Error(t, err)
+
+	var duplicatedField struct {
+		A int `bstates:"name=A,size=4"`
+		B int `bstates:"name=A,size=4"`
+	}
+	_, err = CreateStateSchemaFromStruct(duplicatedField)
+	require.Error(t, err)
+}
@@ -0,0 +1,159 @@
+package bstates
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultPipelineCandidates are the pipelines evaluated by [EvaluatePipelines] when no candidates are provided.
+var DefaultPipelineCandidates = []string{
+	"",
+	"z",
+	"zstd",
+	"zstd(level=19)",
+	"s2",
+	"lz4",
+	"t:z",
+	"t:zstd",
+	"t:zstd(level=19)",
+	"t:s2",
+	"t:lz4",
+	"x:zstd",
+	"col:zstd",
+	"d:zstd",
+	"d:col:zstd",
+	"d:t:z",
+	"d:t:zstd",
+	"d:t:zstd(level=19)",
+}
+
+// PipelineEvaluation holds the result of encoding and decoding a sample of states with a candidate pipeline.
+type PipelineEvaluation struct {
+	Pipeline   string        // Candidate encoder pipeline
+	Size       int           // Size of the encoded queue in bytes
+	Ratio      float64       // Size of the raw states divided by the size of the encoded queue
+	EncodeTime time.Duration // Time spent by [StateQueue.Encode]
+	DecodeTime time.Duration // Time spent by [StateQueue.Decode]
+	Err        error         // Error if the pipeline can't be used with the schema or the sample
+}
+
+// PipelineReport is the result of [EvaluatePipelines].
+type PipelineReport struct {
+	NumStates   int                  // Number of states of the sample
+	RawSize     int                  // Size of the raw states in bytes
+	Evaluations []PipelineEvaluation // Evaluations in the order of the candidates
+	Recommended string               // Candidate which produces the smallest output
+}
+
+// EvaluatePipelines encodes and decodes the states with every candidate pipeline (see [DefaultPipelineCandidates]
+// if candidates is nil) and reports the compressed size, the compression ratio and the encoding/decoding times.
+// The states must use the provided schema, whose fields are kept while its encoder pipeline is replaced by every
+// candidate.
+//
+// The recommended pipeline is the one with the smallest output (the first one in case of a tie). Times depend on
+// the machine running the evaluation, but they can be compared to each other to trade ratio for speed.
+func EvaluatePipelines(schema *StateSchema, states []*State, candidates []string) (*PipelineReport, error) {
+	if len(states) == 0 {
+		return nil, errors.New("no states to evaluate")
+	}
+	if candidates == nil {
+		candidates = DefaultPipelineCandidates
+	}
+	raw := make([][]byte, 0, len(states))
+	for _, state := range states {
+		if state.GetSchema() != schema && state.GetSchema().GetSHA256() != schema.GetSHA256() {
+			return nil, fmt.Errorf("schema used in state does not match the schema provided")
+		}
+		stateRaw, err := state.Encode()
+		if err != nil {
+			return nil, err
+		}
+		raw = append(raw, stateRaw)
+	}
+	report := &PipelineReport{
+		NumStates: len(states),
+		RawSize:   len(states) * schema.GetByteSize(),
+	}
+	best := -1
+	for _, candidate := range candidates {
+		eval := evaluatePipeline(schema, raw, candidate)
+		if eval.Err == nil {
+			eval.Ratio = float64(report.RawSize) / float64(eval.Size)
+			if best < 0 || eval.Size < report.Evaluations[best].Size {
+				best = len(report.Evaluations)
+			}
+		}
+		report.Evaluations = append(report.Evaluations, eval)
+	}
+	if best < 0 {
+		return report, errors.New("no valid candidate pipeline")
+	}
+	report.Recommended = report.Evaluations[best].Pipeline
+	return report, nil
+}
+
+func evaluatePipeline(schema *StateSchema, raw [][]byte, pipeline string) (eval PipelineEvaluation) {
+	eval.Pipeline = pipeline
+	candidate, err := schemaWithPipeline(schema, pipeline)
+	if err != nil {
+		eval.Err = err
+		return
+	}
+	q := CreateStateQueue(candidate)
+	for _, stateRaw := range raw {
+		state, err := candidate.CreateState()
+		if err != nil {
+			eval.Err = err
+			return
+		}
+		if err = state.Decode(stateRaw); err != nil {
+			eval.Err = err
+			return
+		}
+		if err = q.Push(state); err != nil {
+			eval.Err = err
+			return
+		}
+	}
+
+	start := time.Now()
+	blob, err := q.Encode()
+	eval.EncodeTime = time.Since(start)
+	if err != nil {
+		eval.Err = err
+		return
+	}
+	eval.Size = len(blob)
+
+	dq := CreateStateQueue(candidate)
+	start = time.Now()
+	err = dq.Decode(blob)
+	eval.DecodeTime = time.Since(start)
+	if err != nil {
+		eval.Err = err
+		return
+	}
+	if !bytes.Equal(q.buffer.GetRawBuffer()[:q.GetByteSize()], dq.buffer.GetRawBuffer()[:dq.GetByteSize()]) {
+		eval.Err = errors.New("decoded states don't match the encoded ones")
+	}
+	return
+}
+
+// schemaWithPipeline returns a copy of the schema with a different encoder pipeline.
+func schemaWithPipeline(schema *StateSchema, pipeline string) (*StateSchema, error) {
+	msi := schema.ToMsi()
+	msi["encoderPipeline"] = pipeline
+	raw, err := json.Marshal(msi)
+	if err != nil {
+		return nil, err
+	}
+	candidate := &StateSchema{}
+	if err = json.Unmarshal(raw, candidate); err != nil {
+		return nil, err
+	}
+	candidate.SetKeyProvider(schema.GetKeyProvider())
+	return candidate, nil
+}
@@ -0,0 +1,66 @@
+package bstates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EvaluatePipelines(t *testing.T) {
+	schema := testPipelineComparativeCreateSchema(t, "z")
+	states := testCreateCounterStates(t, schema, 2000)
+
+	candidates := []string{"", "z", "t:zstd", "wrong", "zstd(level=30)"}
+	report, err := EvaluatePipelines(schema, states, candidates)
+	require.NoError(t, err)
+	require.Equal(t, 2000, report.NumStates)
+	require.Equal(t, 2000*schema.GetByteSize(), report.RawSize)
+	require.Len(t, report.Evaluations, len(candidates))
+	for i, eval := range report.Evaluations {
+		require.Equal(t, candidates[i], eval.Pipeline)
+	}
+
+	raw := report.Evaluations[0]
+	require.NoError(t, raw.Err)
+	require.Equal(t, report.RawSize, raw.Size)
+	require.Equal(t, 1.0, raw.Ratio)
+
+	z, tzstd := report.Evaluations[1], report.Evaluations[2]
+	require.NoError(t, z.Err)
+	require.NoError(t, tzstd.Err)
+	require.Less(t, tzstd.Size, z.Size)
+	require.Less(t, z.Size, raw.Size)
+	require.Greater(t, tzstd.Ratio, z.Ratio)
+	require.Greater(t, z.EncodeTime, time.Duration(0))
+
+	require.Error(t, report.Evaluations[3].Err)
+	require.Error(t, report.Evaluations[4].Err)
+	require.Equal(t, "t:zstd", report.Recommended)
+
+	// The schema used by the states is not modified
+	require.Equal(t, []string{"z"}, schema.GetEncoderPipeline())
+
+	// Default candidates
+	report, err = EvaluatePipelines(schema, states[:500], nil)
+	require.NoError(t, err)
+	require.Len(t, report.Evaluations, len(DefaultPipelineCandidates))
+	for _, eval := range report.Evaluations {
+		require.NoError(t, eval.Err, eval.Pipeline)
+	}
+	require.NotEmpty(t, report.Recommended)
+}
+
+func Test_EvaluatePipelines_Errors(t *testing.T) {
+	schema := testPipelineComparativeCreateSchema(t, "")
+	_, err := EvaluatePipelines(schema, nil, nil)
+	require.Error(t, err)
+
+	states := testCreateCounterStates(t, schema, 10)
+	_, err = EvaluatePipelines(schema, states, []string{"wrong"})
+	require.Error(t, err)
+
+	other := testZstdDictCreateSchema(t, "", nil)
+	_, err = EvaluatePipelines(other, states, nil)
+	require.Error(t, err)
+}
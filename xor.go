@@ -0,0 +1,116 @@
+package bstates
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/nayarsystems/buffer/buffer"
+)
+
+// XorEnc XORs every row of rowSize bytes of the provided buffer with the previous row (the first row is kept as is)
+// and returns a new buffer with the result. Consecutive rows which are almost equal become runs of zeros.
+//
+// The last row can be shorter than rowSize, so the modifier can be applied to data of any length.
+func XorEnc(b *buffer.Buffer, rowSize int) (*buffer.Buffer, error) {
+	if rowSize <= 0 {
+		return nil, fmt.Errorf("invalid row size (%d)", rowSize)
+	}
+	in := b.GetRawBuffer()[:b.GetByteSize()]
+	out := make([]byte, len(in))
+	copy(out, in)
+	for i := len(out) - 1; i >= rowSize; i-- {
+		out[i] ^= in[i-rowSize]
+	}
+	outb := &buffer.Buffer{}
+	outb.InitFromRawBuffer(out)
+	return outb, nil
+}
+
+// XorDec reverts [XorEnc] and returns a new buffer with the original rows.
+func XorDec(b *buffer.Buffer, rowSize int) (*buffer.Buffer, error) {
+	if rowSize <= 0 {
+		return nil, fmt.Errorf("invalid row size (%d)", rowSize)
+	}
+	in := b.GetRawBuffer()[:b.GetByteSize()]
+	out := make([]byte, len(in))
+	copy(out, in)
+	for i := rowSize; i < len(out); i++ {
+		out[i] ^= out[i-rowSize]
+	}
+	outb := &buffer.Buffer{}
+	outb.InitFromRawBuffer(out)
+	return outb, nil
+}
+
+// xorWriter applies [XorEnc] to the data written, keeping the last row written.
+type xorWriter struct {
+	w    io.Writer
+	prev []byte // last rowSize bytes written (before XOR), zeros at the start
+	pos  int    // number of bytes written
+}
+
+func newXorWriter(w io.Writer, rowSize int) *xorWriter {
+	return &xorWriter{w: w, prev: make([]byte, rowSize)}
+}
+
+func (x *xorWriter) Write(p []byte) (int, error) {
+	out := make([]byte, len(p))
+	rowSize := len(x.prev)
+	for i, c := range p {
+		idx := (x.pos + i) % rowSize
+		out[i] = c ^ x.prev[idx]
+		x.prev[idx] = c
+	}
+	x.pos += len(p)
+	return x.w.Write(out)
+}
+
+func (x *xorWriter) Flush() error {
+	return nil
+}
+
+func (x *xorWriter) Close() error {
+	return nil
+}
+
+// xorReader applies [XorDec] to the data read.
+type xorReader struct {
+	r    io.Reader
+	prev []byte
+	pos  int
+}
+
+func newXorReader(r io.Reader, rowSize int) *xorReader {
+	return &xorReader{r: r, prev: make([]byte, rowSize)}
+}
+
+func (x *xorReader) Read(p []byte) (int, error) {
+	n, err := x.r.Read(p)
+	rowSize := len(x.prev)
+	for i := 0; i < n; i++ {
+		idx := (x.pos + i) % rowSize
+		p[i] ^= x.prev[idx]
+		x.prev[idx] = p[i]
+	}
+	x.pos += n
+	return n, err
+}
+
+// xorModifier is the [MOD_XOR] modifier, with one row per state.
+type xorModifier struct{}
+
+func (xorModifier) Encode(b *buffer.Buffer, schema *StateSchema, step PipelineStep) (*buffer.Buffer, error) {
+	return XorEnc(b, schema.GetByteSize())
+}
+
+func (xorModifier) Decode(b *buffer.Buffer, schema *StateSchema, step PipelineStep) (*buffer.Buffer, error) {
+	return XorDec(b, schema.GetByteSize())
+}
+
+func (xorModifier) NewWriter(w io.Writer, schema *StateSchema, step PipelineStep) (ModifierWriter, error) {
+	return newXorWriter(w, schema.GetByteSize()), nil
+}
+
+func (xorModifier) NewReader(r io.Reader, schema *StateSchema, step PipelineStep) (io.Reader, error) {
+	return newXorReader(r, schema.GetByteSize()), nil
+}
@@ -0,0 +1,117 @@
+package bstates
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nayarsystems/buffer/buffer"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Xor(t *testing.T) {
+	data := []byte{1, 2, 3, 1, 2, 4, 1, 3, 4, 9}
+	b := &buffer.Buffer{}
+	b.InitFromRawBuffer(append([]byte{}, data...))
+	enc, err := XorEnc(b, 3)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 3, 0, 0, 7, 0, 1, 0, 8}, enc.GetRawBuffer())
+	require.Equal(t, data, b.GetRawBuffer())
+
+	dec, err := XorDec(enc, 3)
+	require.NoError(t, err)
+	require.Equal(t, data, dec.GetRawBuffer())
+
+	_, err = XorEnc(b, 0)
+	require.Error(t, err)
+	_, err = XorDec(b, 0)
+	require.Error(t, err)
+
+	// Streaming versions, writing and reading in small chunks
+	out := &bytes.Buffer{}
+	w := newXorWriter(out, 3)
+	for _, chunk := range [][]byte{data[:2], data[2:7], data[7:]} {
+		_, err = w.Write(chunk)
+		require.NoError(t, err)
+	}
+	require.Equal(t, enc.GetRawBuffer(), out.Bytes())
+	r := newXorReader(bytes.NewReader(out.Bytes()), 3)
+	res := []byte{}
+	chunk := make([]byte, 4)
+	for {
+		n, err := r.Read(chunk)
+		res = append(res, chunk[:n]...)
+		if err != nil {
+			break
+		}
+	}
+	require.Equal(t, data, res)
+}
+
+func Test_Xor_Pipelines(t *testing.T) {
+	for _, pipeline := range []string{"x", "x:z", "x:zstd", "t:x:zstd", "x:t:zstd", "zstd:x", "x:x"} {
+		schema := testPipelineComparativeCreateSchema(t, pipeline)
+		states := testCreateCounterStates(t, schema, 333)
+		queue := CreateStateQueue(schema)
+		require.NoError(t, queue.PushAll(states))
+		data, err := queue.Encode()
+		require.NoError(t, err)
+
+		dqueue := CreateStateQueue(schema)
+		require.NoError(t, dqueue.Decode(data), pipeline)
+		dstates, err := dqueue.GetStates()
+		require.NoError(t, err)
+		testEqualStates(t, states, dstates)
+
+		reader, err := CreateQueueReader(schema, bytes.NewReader(data))
+		require.NoError(t, err)
+		rstates := []*State{}
+		require.NoError(t, reader.ForEach(func(s *State) error {
+			rstates = append(rstates, s)
+			return nil
+		}))
+		testEqualStates(t, states, rstates)
+	}
+
+	// Streaming writer
+	schema := testPipelineComparativeCreateSchema(t, "x:zstd")
+	states := testCreateCounterStates(t, schema, 100)
+	out := &bytes.Buffer{}
+	writer, err := CreateQueueWriter(schema, out)
+	require.NoError(t, err)
+	require.NoError(t, writer.PushAll(states))
+	require.NoError(t, writer.Close())
+	dqueue := CreateStateQueue(schema)
+	require.NoError(t, dqueue.Decode(out.Bytes()))
+	dstates, err := dqueue.GetStates()
+	require.NoError(t, err)
+	testEqualStates(t, states, dstates)
+}
+
+func Test_Xor_Compression(t *testing.T) {
+	schema, err := CreateStateSchema(&StateSchemaParams{
+		Fields: []StateField{
+			{Name: "COUNTER", Type: T_UINT, Size: 32},
+			{Name: "SLOW", Type: T_UINT, Size: 16},
+			{Name: "NOISE", Type: T_UINT, Size: 8},
+		},
+	})
+	require.NoError(t, err)
+	queue := CreateStateQueue(schema)
+	for i := 0; i < 5000; i++ {
+		state, err := schema.CreateState()
+		require.NoError(t, err)
+		require.NoError(t, state.Set("COUNTER", i*7919))
+		require.NoError(t, state.Set("SLOW", i/100))
+		require.NoError(t, state.Set("NOISE", (i*31)%256))
+		require.NoError(t, queue.Push(state))
+	}
+	sizes := map[string]int{}
+	for _, pipeline := range []string{"zstd", "x:zstd"} {
+		require.NoError(t, schema.setPipelines(pipeline))
+		data, err := queue.Encode()
+		require.NoError(t, err)
+		sizes[pipeline] = len(data)
+	}
+	t.Logf("zstd: %d bytes, x:zstd: %d bytes", sizes["zstd"], sizes["x:zstd"])
+	require.Less(t, sizes["x:zstd"], sizes["zstd"])
+}
@@ -2,18 +2,40 @@ package bstates
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"math"
+	"strconv"
 
 	"github.com/klauspost/compress/zstd"
 
 	"github.com/nayarsystems/buffer/buffer"
 )
 
+// zstdDefaultLevel is the zstd compression level used when none is provided.
+const zstdDefaultLevel = 3
+
 // ZstdEnc compresses the provided buffer using Zstandard (Zstd) compression
 // and returns a new buffer containing the compressed data.
 func ZstdEnc(b *buffer.Buffer) (*buffer.Buffer, error) {
+	return ZstdEncLevel(b, zstdDefaultLevel)
+}
+
+// ZstdEncLevel is like [ZstdEnc] but using the provided zstd compression level (1 to 22). Levels are mapped
+// to the closest encoder speed supported (see [zstd.EncoderLevelFromZstd]).
+func ZstdEncLevel(b *buffer.Buffer, level int) (*buffer.Buffer, error) {
+	return ZstdEncDict(b, level, 0, nil)
+}
+
+// ZstdEncDict is like [ZstdEncLevel] but using the raw content dictionary provided (see [TrainZstdDictionary]).
+// The dictionary id is stored in the compressed frame. No dictionary is used if dict is nil.
+func ZstdEncDict(b *buffer.Buffer, level int, id uint32, dict []byte) (*buffer.Buffer, error) {
+	opts := []zstd.EOption{zstd.WithEncoderConcurrency(1), zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level))}
+	if dict != nil {
+		opts = append(opts, zstd.WithEncoderDictRaw(id, dict))
+	}
 	buf := new(bytes.Buffer)
-	wr, err := zstd.NewWriter(buf, zstd.WithEncoderConcurrency(1))
+	wr, err := zstd.NewWriter(buf, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -36,9 +58,19 @@ func ZstdEnc(b *buffer.Buffer) (*buffer.Buffer, error) {
 // Zstandard (Zstd) format and returns a new buffer containing the
 // decompressed data.
 func ZstdDec(b *buffer.Buffer) (*buffer.Buffer, error) {
+	return ZstdDecDict(b, 0, nil)
+}
+
+// ZstdDecDict is like [ZstdDec] but registering the raw content dictionary used by [ZstdEncDict].
+// No dictionary is registered if dict is nil.
+func ZstdDecDict(b *buffer.Buffer, id uint32, dict []byte) (*buffer.Buffer, error) {
+	opts := []zstd.DOption{zstd.WithDecoderConcurrency(1)}
+	if dict != nil {
+		opts = append(opts, zstd.WithDecoderDictRaw(id, dict))
+	}
 	r := bytes.NewReader(b.GetRawBuffer()[:b.GetByteSize()])
 	var gzr *zstd.Decoder
-	gzr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	gzr, err := zstd.NewReader(r, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -52,3 +84,85 @@ func ZstdDec(b *buffer.Buffer) (*buffer.Buffer, error) {
 	outb.InitFromRawBuffer(out)
 	return outb, nil
 }
+
+// zstdModifier is the [MOD_ZSTD] modifier. The compression level can be set with the level parameter and
+// a dictionary of the schema can be used with the dict parameter.
+type zstdModifier struct{}
+
+func (zstdModifier) ValidateParams(step PipelineStep) error {
+	if err := checkParams(step, "level", "dict"); err != nil {
+		return err
+	}
+	level, err := step.GetIntParam("level", zstdDefaultLevel)
+	if err != nil {
+		return err
+	}
+	if level < 1 || level > 22 {
+		return fmt.Errorf("modifier \"%s\": level must be between 1 and 22", step.Modifier)
+	}
+	if dict, ok := step.Params["dict"]; ok {
+		if id, err := strconv.ParseUint(dict, 10, 32); err != nil || id == 0 {
+			return fmt.Errorf("modifier \"%s\": dict must be a dictionary id between 1 and %d", step.Modifier, uint32(math.MaxUint32))
+		}
+	}
+	return nil
+}
+
+func (zstdModifier) level(step PipelineStep) int {
+	level, _ := step.GetIntParam("level", zstdDefaultLevel)
+	return level
+}
+
+func (m zstdModifier) Encode(b *buffer.Buffer, schema *StateSchema, step PipelineStep) (*buffer.Buffer, error) {
+	dictID, dict, err := schema.getZstdStepDict(step)
+	if err != nil {
+		return nil, err
+	}
+	return ZstdEncDict(b, m.level(step), dictID, dict)
+}
+
+func (zstdModifier) Decode(b *buffer.Buffer, schema *StateSchema, step PipelineStep) (*buffer.Buffer, error) {
+	dictID, dict, err := schema.getZstdStepDict(step)
+	if err != nil {
+		return nil, err
+	}
+	return ZstdDecDict(b, dictID, dict)
+}
+
+func (m zstdModifier) NewWriter(w io.Writer, schema *StateSchema, step PipelineStep) (ModifierWriter, error) {
+	opts := []zstd.EOption{zstd.WithEncoderConcurrency(1), zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(m.level(step)))}
+	dictID, dict, err := schema.getZstdStepDict(step)
+	if err != nil {
+		return nil, err
+	}
+	if dict != nil {
+		opts = append(opts, zstd.WithEncoderDictRaw(dictID, dict))
+	}
+	return zstd.NewWriter(w, opts...)
+}
+
+func (zstdModifier) NewReader(r io.Reader, schema *StateSchema, step PipelineStep) (io.Reader, error) {
+	opts := []zstd.DOption{zstd.WithDecoderConcurrency(1)}
+	dictID, dict, err := schema.getZstdStepDict(step)
+	if err != nil {
+		return nil, err
+	}
+	if dict != nil {
+		opts = append(opts, zstd.WithDecoderDictRaw(dictID, dict))
+	}
+	zr, err := zstd.NewReader(r, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return zstdReadCloser{zr}, nil
+}
+
+// zstdReadCloser adapts the Close method of [zstd.Decoder] to [io.Closer].
+type zstdReadCloser struct {
+	*zstd.Decoder
+}
+
+func (z zstdReadCloser) Close() error {
+	z.Decoder.Close()
+	return nil
+}
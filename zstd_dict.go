@@ -0,0 +1,189 @@
+package bstates
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrZstdDictNotFound is returned when a zstd modifier references a dictionary which is not in the schema.
+var ErrZstdDictNotFound = errors.New("zstd dictionary not found")
+
+// ZstdDictMaxSize is the maximum size of the dictionaries built by [TrainZstdDictionary].
+const ZstdDictMaxSize = 16 * 1024
+
+const (
+	zstdDictKmerSize    = 8  // size of the substrings counted by the trainer
+	zstdDictSegmentSize = 64 // size of the segments of the samples copied into the dictionary
+)
+
+// getZstdStepDict returns the dictionary referenced by a zstd pipeline step. It returns a nil
+// dictionary if the step doesn't use one.
+func (s *StateSchema) getZstdStepDict(step PipelineStep) (uint32, []byte, error) {
+	id := zstdDictID(step)
+	if id == 0 {
+		return 0, nil, nil
+	}
+	dict, ok := s.zstdDicts[id]
+	if !ok {
+		return 0, nil, fmt.Errorf("%w: %d", ErrZstdDictNotFound, id)
+	}
+	return id, dict, nil
+}
+
+// TrainZstdDictionary builds a raw content zstd dictionary from historical queues, which must share the same
+// schema. The encoder pipeline of the schema must contain a zstd modifier: the dictionary is built from the data
+// which reaches the first one (for example, the transposed states in a "t:zstd" pipeline).
+//
+// The dictionary is meant to be added to the schema (see [StateSchemaParams]) and referenced by id from the
+// encoder pipeline, for example "t:zstd(dict=1)". Dictionaries help to compress small queues, which zstd
+// can't compress well without previous context.
+func TrainZstdDictionary(queues []*StateQueue) ([]byte, error) {
+	if len(queues) == 0 {
+		return nil, errors.New("no queues to train the zstd dictionary")
+	}
+	schema := queues[0].StateSchema
+	steps := schema.GetEncoderSteps()
+	zstdIdx := -1
+	for i, step := range steps {
+		if step.Modifier == MOD_ZSTD {
+			zstdIdx = i
+			break
+		}
+	}
+	if zstdIdx < 0 {
+		return nil, fmt.Errorf("encoder pipeline \"%s\" has no zstd modifier", pipelineToString(steps))
+	}
+	hash := schema.GetSHA256()
+	samples := [][]byte{}
+	for _, q := range queues {
+		if q.StateSchema.GetSHA256() != hash {
+			return nil, errors.New("all the queues must use the same schema")
+		}
+		buf, err := q.runEncoderSteps(steps[:zstdIdx])
+		if err != nil {
+			return nil, err
+		}
+		if buf.GetByteSize() > 0 {
+			samples = append(samples, buf.GetRawBuffer()[:buf.GetByteSize()])
+		}
+	}
+	dict := buildZstdDict(samples, ZstdDictMaxSize)
+	if len(dict) == 0 {
+		return nil, errors.New("not enough repeated data to train the zstd dictionary")
+	}
+	return dict, nil
+}
+
+// zstdDictSegment is a candidate segment of a sample.
+type zstdDictSegment struct {
+	sample int
+	start  int
+	end    int
+	score  int
+}
+
+// zstdDictSegmentHeap is a max heap of segments by score.
+type zstdDictSegmentHeap []zstdDictSegment
+
+func (h zstdDictSegmentHeap) Len() int { return len(h) }
+func (h zstdDictSegmentHeap) Less(i, j int) bool {
+	if h[i].score != h[j].score {
+		return h[i].score > h[j].score
+	}
+	if h[i].sample != h[j].sample {
+		return h[i].sample < h[j].sample
+	}
+	return h[i].start < h[j].start
+}
+func (h zstdDictSegmentHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *zstdDictSegmentHeap) Push(x any)   { *h = append(*h, x.(zstdDictSegment)) }
+func (h *zstdDictSegmentHeap) Pop() any {
+	old := *h
+	seg := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return seg
+}
+
+// buildZstdDict selects the segments of the samples which contain more substrings shared by several samples,
+// in the spirit of the COVER algorithm of the zstd trainer. Once a segment is selected its substrings don't
+// count anymore, so the dictionary doesn't repeat content. The most valuable segments are placed at the end
+// of the dictionary, where offsets are cheaper.
+func buildZstdDict(samples [][]byte, maxSize int) []byte {
+	freq := map[string]int{}
+	for _, sample := range samples {
+		seen := map[string]bool{}
+		for i := 0; i+zstdDictKmerSize <= len(sample); i++ {
+			kmer := string(sample[i : i+zstdDictKmerSize])
+			if !seen[kmer] {
+				seen[kmer] = true
+				freq[kmer]++
+			}
+		}
+	}
+	score := func(data []byte) int {
+		total := 0
+		seen := map[string]bool{}
+		for i := 0; i+zstdDictKmerSize <= len(data); i++ {
+			kmer := string(data[i : i+zstdDictKmerSize])
+			if f := freq[kmer]; f > 1 && !seen[kmer] {
+				seen[kmer] = true
+				total += f
+			}
+		}
+		return total
+	}
+
+	h := &zstdDictSegmentHeap{}
+	for si, sample := range samples {
+		for start := 0; start+zstdDictKmerSize <= len(sample); start += zstdDictSegmentSize / 2 {
+			end := start + zstdDictSegmentSize
+			if end > len(sample) {
+				end = len(sample)
+			}
+			seg := zstdDictSegment{sample: si, start: start, end: end}
+			if seg.score = score(sample[start:end]); seg.score > 0 {
+				*h = append(*h, seg)
+			}
+			if end == len(sample) {
+				break
+			}
+		}
+	}
+	heap.Init(h)
+
+	selected := [][]byte{}
+	size := 0
+	for h.Len() > 0 && size < maxSize {
+		seg := heap.Pop(h).(zstdDictSegment)
+		data := samples[seg.sample][seg.start:seg.end]
+		// Scores only decrease, so the segment is the best one if its updated score is still the highest
+		if s := score(data); s < seg.score {
+			if seg.score = s; s > 0 {
+				heap.Push(h, seg)
+			}
+			continue
+		}
+		if size+len(data) > maxSize {
+			data = data[len(data)-(maxSize-size):]
+		}
+		selected = append(selected, data)
+		size += len(data)
+		for i := 0; i+zstdDictKmerSize <= len(data); i++ {
+			delete(freq, string(data[i:i+zstdDictKmerSize]))
+		}
+	}
+
+	dict := make([]byte, 0, size)
+	for i := len(selected) - 1; i >= 0; i-- {
+		dict = append(dict, selected[i]...)
+	}
+	return dict
+}
+
+// zstdDictID returns the id of the dictionary used by a zstd step (0 if none).
+func zstdDictID(step PipelineStep) uint32 {
+	id, _ := strconv.ParseUint(step.Params["dict"], 10, 32)
+	return uint32(id)
+}
@@ -0,0 +1,166 @@
+package bstates
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testZstdDictCreateSchema(t *testing.T, pipeline string, dicts map[uint32][]byte) *StateSchema {
+	schema, err := CreateStateSchema(&StateSchemaParams{
+		EncoderPipeline: pipeline,
+		ZstdDicts:       dicts,
+		Fields: []StateField{
+			{Name: "DEVICE", Type: T_BUFFER, Size: 96},
+			{Name: "FW_VERSION", Type: T_UINT, Size: 16},
+			{Name: "TEMPERATURE", Type: T_INT, Size: 12},
+			{Name: "COUNTER", Type: T_UINT, Size: 20},
+			{Name: "STATUS", Type: T_UINT, Size: 4},
+		},
+	})
+	require.NoError(t, err)
+	return schema
+}
+
+// testZstdDictCreateQueue creates a small queue similar to the ones sent by a device.
+func testZstdDictCreateQueue(t *testing.T, schema *StateSchema, rnd *rand.Rand) *StateQueue {
+	q := CreateStateQueue(schema)
+	counter := rnd.Intn(100000)
+	for i := 0; i < 3; i++ {
+		state, err := schema.CreateState()
+		require.NoError(t, err)
+		require.NoError(t, state.Set("DEVICE", []byte("DEVICE-00042")))
+		require.NoError(t, state.Set("FW_VERSION", 0x0102))
+		require.NoError(t, state.Set("TEMPERATURE", 20+rnd.Intn(5)))
+		require.NoError(t, state.Set("COUNTER", counter+i))
+		require.NoError(t, state.Set("STATUS", rnd.Intn(2)))
+		require.NoError(t, q.Push(state))
+	}
+	return q
+}
+
+func Test_ZstdDict(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	trainSchema := testZstdDictCreateSchema(t, "zstd", nil)
+	queues := []*StateQueue{}
+	for i := 0; i < 200; i++ {
+		queues = append(queues, testZstdDictCreateQueue(t, trainSchema, rnd))
+	}
+	dict, err := TrainZstdDictionary(queues)
+	require.NoError(t, err)
+	require.NotEmpty(t, dict)
+	require.LessOrEqual(t, len(dict), ZstdDictMaxSize)
+
+	schema := testZstdDictCreateSchema(t, "zstd(dict=1)", map[uint32][]byte{1: dict})
+	got, ok := schema.GetZstdDict(1)
+	require.True(t, ok)
+	require.Equal(t, dict, got)
+
+	// Dictionaries are serialized with the schema and are part of the hash
+	raw, err := json.Marshal(schema)
+	require.NoError(t, err)
+	schemaFromJSON := &StateSchema{}
+	require.NoError(t, json.Unmarshal(raw, schemaFromJSON))
+	require.Equal(t, schema, schemaFromJSON)
+	otherDict := append([]byte{}, dict...)
+	otherDict[0]++
+	require.NotEqual(t, schema.GetHashString(), testZstdDictCreateSchema(t, "zstd(dict=1)", map[uint32][]byte{1: otherDict}).GetHashString())
+	require.Equal(t, trainSchema.GetHashString(), testZstdDictCreateSchema(t, "zstd", map[uint32][]byte{}).GetHashString())
+
+	// Small queues are compressed better with the dictionary
+	plainSize, dictSize := 0, 0
+	for i := 0; i < 20; i++ {
+		plainQueue := testZstdDictCreateQueue(t, trainSchema, rnd)
+		plainBlob, err := plainQueue.Encode()
+		require.NoError(t, err)
+		plainSize += len(plainBlob)
+
+		states, err := plainQueue.GetStates()
+		require.NoError(t, err)
+		q := CreateStateQueue(schema)
+		for _, s := range states {
+			state, err := schema.CreateState()
+			require.NoError(t, err)
+			raw, err := s.Encode()
+			require.NoError(t, err)
+			require.NoError(t, state.Decode(raw))
+			require.NoError(t, q.Push(state))
+		}
+		blob, err := q.Encode()
+		require.NoError(t, err)
+		dictSize += len(blob)
+
+		dq := CreateStateQueue(schema)
+		require.NoError(t, dq.Decode(blob))
+		decoded, err := dq.GetStates()
+		require.NoError(t, err)
+		testEqualStates(t, states, decoded)
+
+		// Streaming encoder and decoder
+		out := &bytes.Buffer{}
+		w, err := CreateQueueWriter(schema, out)
+		require.NoError(t, err)
+		require.NoError(t, w.PushAll(decoded))
+		require.NoError(t, w.Close())
+		r, err := CreateQueueReader(schema, out)
+		require.NoError(t, err)
+		streamed := []*State{}
+		require.NoError(t, r.ForEach(func(state *State) error {
+			streamed = append(streamed, state)
+			return nil
+		}))
+		require.NoError(t, r.Close())
+		testEqualStates(t, states, streamed)
+	}
+	require.Less(t, dictSize, plainSize*3/4)
+}
+
+func Test_ZstdDict_Missing(t *testing.T) {
+	params := &StateSchemaParams{
+		EncoderPipeline: "t:zstd(dict=7)",
+		ZstdDicts:       map[uint32][]byte{1: {1, 2, 3}},
+		Fields:          []StateField{{Name: "COUNTER", Type: T_UINT, Size: 20}},
+	}
+	_, err := CreateStateSchema(params)
+	require.ErrorIs(t, err, ErrZstdDictNotFound)
+	require.ErrorContains(t, err, "7")
+
+	// A schema without the dictionary referenced by its pipeline can't be loaded either
+	params.ZstdDicts = map[uint32][]byte{7: {1, 2, 3}}
+	schema, err := CreateStateSchema(params)
+	require.NoError(t, err)
+	msi := schema.ToMsi()
+	delete(msi, "zstdDicts")
+	raw, err := json.Marshal(msi)
+	require.NoError(t, err)
+	err = json.Unmarshal(raw, &StateSchema{})
+	require.ErrorIs(t, err, ErrZstdDictNotFound)
+}
+
+func Test_ZstdDict_Errors(t *testing.T) {
+	_, err := TrainZstdDictionary(nil)
+	require.Error(t, err)
+
+	rnd := rand.New(rand.NewSource(1))
+	q := testZstdDictCreateQueue(t, testZstdDictCreateSchema(t, "t:z", nil), rnd)
+	_, err = TrainZstdDictionary([]*StateQueue{q})
+	require.ErrorContains(t, err, "no zstd modifier")
+
+	q0 := testZstdDictCreateQueue(t, testZstdDictCreateSchema(t, "zstd", nil), rnd)
+	q1 := testZstdDictCreateQueue(t, testZstdDictCreateSchema(t, "t:zstd", nil), rnd)
+	_, err = TrainZstdDictionary([]*StateQueue{q0, q1})
+	require.ErrorContains(t, err, "same schema")
+
+	for _, pipeline := range []string{"zstd(dict=0)", "zstd(dict=-1)", "zstd(dict=4294967296)", "zstd(dict=a)"} {
+		_, err = ParsePipeline(pipeline)
+		require.Error(t, err, pipeline)
+	}
+
+	_, err = CreateStateSchema(&StateSchemaParams{ZstdDicts: map[uint32][]byte{0: {1}}})
+	require.Error(t, err)
+	_, err = CreateStateSchema(&StateSchemaParams{ZstdDicts: map[uint32][]byte{1: {}}})
+	require.Error(t, err)
+}